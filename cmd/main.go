@@ -6,12 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-co-op/gocron"
 	"github.com/go-redsync/redsync/v4"
 	configenv "github.com/ijlik/dating-user/pkg/config"
 	configdata "github.com/ijlik/dating-user/pkg/config/data"
+	"github.com/ijlik/dating-user/pkg/constant"
 	httpmiddlewaresdk "github.com/ijlik/dating-user/pkg/http/middleware"
+	jwtpkg "github.com/ijlik/dating-user/pkg/jwt"
 	"github.com/jmoiron/sqlx"
 
 	rediseight "github.com/go-redis/redis/v8"
@@ -22,7 +26,10 @@ import (
 	"github.com/ijlik/dating-user/internal/business/port"
 	"github.com/ijlik/dating-user/internal/business/service"
 	httpdelivery "github.com/ijlik/dating-user/internal/handler/http"
+	blobstorepkg "github.com/ijlik/dating-user/pkg/blobstore"
+	facematchpkg "github.com/ijlik/dating-user/pkg/facematch"
 	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
+	otpsenderpkg "github.com/ijlik/dating-user/pkg/otpsender"
 	_ "github.com/lib/pq"
 )
 
@@ -61,6 +68,7 @@ func getRedisClient() rediseight.Cmdable {
 func getService(
 	db *sqlx.DB,
 	rdb rdbrepo.RedisDomain,
+	keySet jwtpkg.KeySet,
 ) port.UserDomainService {
 	mailPort := config.GetInt("MAILER_PORT")
 	mailUsername := config.GetString("MAILER_USERNAME")
@@ -82,11 +90,109 @@ func getService(
 		config,
 		mailer,
 		rdb,
+		getOtpSenders(mailer),
+		keySet,
+		getBlobStore(),
+		getFaceMatcher(),
 	)
 
 	return services
 }
 
+// startAccountPurge hard delete account which deletion grace period is over
+// and remove expired data export, redis lock keep only one instance running
+// the purge
+func startAccountPurge(services port.UserDomainService, rdb rediseight.Cmdable) {
+	interval := config.GetInt("ACCOUNT_PURGE_INTERVAL_IN_MINUTE")
+	if interval == 0 {
+		interval = 60
+	}
+
+	s := gocron.NewScheduler(time.UTC)
+	if _, err := s.Every(interval).Minutes().Do(func() {
+		if module, ok := rdb.(*RedisModule); ok {
+			mutex := module.NewMutex("account_purge", redsync.WithExpiry(time.Duration(interval)*time.Minute))
+			if err := mutex.Lock(); err != nil {
+				return
+			}
+			defer mutex.Unlock()
+		}
+
+		if errs := services.PurgeDeletedAccounts(context.Background()); errs != nil {
+			log.Println("FAILED TO PURGE DELETED ACCOUNT: ", errs.Error())
+		}
+
+		if errs := services.PurgeExpiredDataExports(context.Background()); errs != nil {
+			log.Println("FAILED TO PURGE EXPIRED DATA EXPORT: ", errs.Error())
+		}
+	}); err != nil {
+		log.Println("scheduler specify jobFunc: ", err)
+		return
+	}
+
+	s.StartAsync()
+}
+
+func getOtpSenders(mailer mailerpkg.Mail) otpsenderpkg.Registry {
+	senders := []otpsenderpkg.OtpSender{
+		otpsenderpkg.NewEmailSender(mailer),
+	}
+
+	// sms, whatsapp and phone call only available when gateway configured
+	gatewayUrl := config.GetString("OTP_GATEWAY_URL")
+	if gatewayUrl != "" {
+		gatewayToken := config.GetString("OTP_GATEWAY_TOKEN")
+		senders = append(
+			senders,
+			otpsenderpkg.NewSmsSender(gatewayUrl, gatewayToken),
+			otpsenderpkg.NewWhatsappSender(gatewayUrl, gatewayToken),
+			otpsenderpkg.NewPhoneCallSender(gatewayUrl, gatewayToken),
+		)
+	} else if config.GetBool("OTP_LOCAL_SENDER") {
+		// local run, codes are printed to the log
+		senders = append(
+			senders,
+			otpsenderpkg.NewLogSender(constant.OTP_TYPE_SMS),
+			otpsenderpkg.NewLogSender(constant.OTP_TYPE_WHATSAPP),
+			otpsenderpkg.NewLogSender(constant.OTP_TYPE_PHONE_CALL),
+		)
+	}
+
+	return otpsenderpkg.NewRegistry(senders...)
+}
+
+// getBlobStore photos are kept on the local disk unless an S3 compatible
+// storage is configured
+func getBlobStore() blobstorepkg.BlobStore {
+	if config.GetString("BLOB_STORE_DRIVER") == "s3" {
+		return blobstorepkg.NewS3Store(blobstorepkg.S3Config{
+			Endpoint:  config.GetString("S3_ENDPOINT"),
+			Region:    config.GetString("S3_REGION"),
+			Bucket:    config.GetString("S3_BUCKET"),
+			AccessKey: config.GetString("S3_ACCESS_KEY"),
+			SecretKey: config.GetString("S3_SECRET_KEY"),
+		})
+	}
+
+	root := config.GetString("BLOB_LOCAL_ROOT")
+	if root == "" {
+		root = "storage"
+	}
+
+	return blobstorepkg.NewLocalStore(root)
+}
+
+// getFaceMatcher nil without FACE_MATCH_URL, verifications are then all
+// reviewed manually
+func getFaceMatcher() facematchpkg.FaceMatcher {
+	url := config.GetString("FACE_MATCH_URL")
+	if url == "" {
+		return nil
+	}
+
+	return facematchpkg.NewHttpMatcher(url, config.GetString("FACE_MATCH_TOKEN"))
+}
+
 func getConfig() configdata.Config {
 	c := configenv.NewConfig("", 5)
 
@@ -148,12 +254,18 @@ func main() {
 	}
 	httpmiddlewaresdk.HealthCheckHandler("user", router, options...)
 
-	services := getService(db, rdbConn)
+	// keys are read from config on every call, vault reload rotate them
+	keySet := jwtpkg.NewKeySet(jwtpkg.ConfigKeyLoader(config))
+
+	services := getService(db, rdbConn, keySet)
+
+	startAccountPurge(services, rdb)
 
 	httpdelivery.HandlerHttp(
 		router,
 		config,
 		services,
 		rdb,
+		keySet,
 	)
 }
@@ -7,6 +7,9 @@ import (
 	"golang.org/x/net/context"
 )
 
+// Nil returned by Get when key does not exist
+var Nil = redis.Nil
+
 func NewRedisRepository(conn redis.Cmdable) RedisDomain {
 	return &rdb{conn}
 }
@@ -18,7 +21,13 @@ type rdb struct {
 type RedisDomain interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, value, key string, interval int) error
-	Del(ctx context.Context, key string) error
+	SetWithExpiration(ctx context.Context, key, value string, expiration time.Duration) error
+	SetNX(ctx context.Context, key, value string, expiration time.Duration) (bool, error)
+	Del(ctx context.Context, keys ...string) error
+	SAdd(ctx context.Context, key string, members ...string) error
+	SRem(ctx context.Context, key string, members ...string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+	Expire(ctx context.Context, key string, expiration time.Duration) error
 }
 
 func (r *rdb) Get(ctx context.Context, key string) (string, error) {
@@ -38,6 +47,40 @@ func (r *rdb) Set(ctx context.Context, value, key string, interval int) error {
 	return r.conn.Set(ctx, key, value, time.Duration(interval*int(time.Minute))).Err()
 }
 
-func (r *rdb) Del(ctx context.Context, key string) error {
-	return r.conn.Del(ctx, key).Err()
+func (r *rdb) SetWithExpiration(ctx context.Context, key, value string, expiration time.Duration) error {
+	return r.conn.Set(ctx, key, value, expiration).Err()
+}
+
+// SetNX only set the key when it is not exist yet, false mean key already exist
+func (r *rdb) SetNX(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+	return r.conn.SetNX(ctx, key, value, expiration).Result()
+}
+
+func (r *rdb) Del(ctx context.Context, keys ...string) error {
+	return r.conn.Del(ctx, keys...).Err()
+}
+
+func (r *rdb) SAdd(ctx context.Context, key string, members ...string) error {
+	return r.conn.SAdd(ctx, key, toInterfaces(members)...).Err()
+}
+
+func (r *rdb) SRem(ctx context.Context, key string, members ...string) error {
+	return r.conn.SRem(ctx, key, toInterfaces(members)...).Err()
+}
+
+func (r *rdb) SMembers(ctx context.Context, key string) ([]string, error) {
+	return r.conn.SMembers(ctx, key).Result()
+}
+
+func (r *rdb) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	return r.conn.Expire(ctx, key, expiration).Err()
+}
+
+func toInterfaces(values []string) []interface{} {
+	data := make([]interface{}, len(values))
+	for i, value := range values {
+		data[i] = value
+	}
+
+	return data
 }
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
+
+// deletion already scheduled is kept, deactivate only hide the account
+const deactivateUserQuery = `UPDATE users SET status = $2, deactivated_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+
+func (r *repo) DeactivateUser(
+	ctx context.Context,
+	UserID string,
+) error {
+	if _, err := r.conn.ExecContext(
+		ctx,
+		deactivateUserQuery,
+		UserID,
+		constant.USER_STATUS_DEACTIVE,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const scheduleDeleteUserQuery = `UPDATE users SET status = $2, deactivated_at = COALESCE(deactivated_at, CURRENT_TIMESTAMP), delete_scheduled_at = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+
+// ScheduleDeleteUser deactivate the account and mark it for hard delete
+func (r *repo) ScheduleDeleteUser(
+	ctx context.Context,
+	UserID string,
+	deleteAt time.Time,
+) error {
+	if _, err := r.conn.ExecContext(
+		ctx,
+		scheduleDeleteUserQuery,
+		UserID,
+		constant.USER_STATUS_DEACTIVE,
+		deleteAt,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const reactivateUserQuery = `UPDATE users SET status = $2, deactivated_at = NULL, delete_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+
+// ReactivateUser also cancel scheduled deletion
+func (r *repo) ReactivateUser(
+	ctx context.Context,
+	UserID string,
+) error {
+	if _, err := r.conn.ExecContext(
+		ctx,
+		reactivateUserQuery,
+		UserID,
+		constant.USER_STATUS_ACTIVE,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const getUserIDsScheduledForDeletionQuery = `SELECT id FROM users WHERE status = $1 AND delete_scheduled_at <= $2 ORDER BY delete_scheduled_at LIMIT $3`
+
+func (r *repo) GetUserIDsScheduledForDeletion(
+	ctx context.Context,
+	before time.Time,
+	limit int,
+) ([]string, error) {
+	var data []string
+	err := r.conn.SelectContext(
+		ctx,
+		&data,
+		getUserIDsScheduledForDeletionQuery,
+		constant.USER_STATUS_DEACTIVE,
+		before,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+const (
+	deleteUserSwipesQuery     = `DELETE FROM swipes WHERE swiper_id IN (SELECT id FROM profiles WHERE user_id = $1) OR swiped_id IN (SELECT id FROM profiles WHERE user_id = $1)`
+	deleteUserPhotosQuery     = `DELETE FROM profile_photos WHERE profile_id IN (SELECT id FROM profiles WHERE user_id = $1)`
+	deleteUserVerifyQuery     = `DELETE FROM profile_verifications WHERE profile_id IN (SELECT id FROM profiles WHERE user_id = $1)`
+	deleteUserProfileQuery    = `DELETE FROM profiles WHERE user_id = $1`
+	deleteUserOtpLogsQuery    = `DELETE FROM one_time_password_logs WHERE user_id = $1`
+	deleteUserPaymentsQuery   = `DELETE FROM payments WHERE user_id = $1`
+	deleteUserFailureQuery    = `DELETE FROM login_failures WHERE user_id = $1`
+	deleteUserEmailsQuery     = `DELETE FROM email_changes WHERE user_id = $1`
+	deleteUserOnboardingQuery = `DELETE FROM user_onboarding_steps WHERE user_id = $1`
+	deleteUserDiscoveryQuery  = `DELETE FROM discovery_preferences WHERE user_id = $1`
+	deleteUserQuery           = `DELETE FROM users WHERE id = $1`
+)
+
+// DeleteUser hard delete the user and every row owned by the user
+func (r *repo) DeleteUser(
+	ctx context.Context,
+	UserID string,
+) (err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txAction(tx, &err)
+
+	for _, query := range []string{
+		deleteUserSwipesQuery,
+		deleteUserPhotosQuery,
+		deleteUserVerifyQuery,
+		deleteUserProfileQuery,
+		deleteUserOtpLogsQuery,
+		deleteUserPaymentsQuery,
+		deleteUserFailureQuery,
+		deleteUserEmailsQuery,
+		deleteUserOnboardingQuery,
+		deleteUserDiscoveryQuery,
+		deleteUserQuery,
+	} {
+		if _, err = tx.ExecContext(ctx, query, UserID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
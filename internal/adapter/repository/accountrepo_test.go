@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/pkg/constant"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleDeleteUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	deleteAt := time.Now().Add(30 * 24 * time.Hour)
+
+	scheduleDeleteUserQueryMock := "UPDATE users SET status = \\$2, deactivated_at = COALESCE\\(deactivated_at, CURRENT_TIMESTAMP\\), delete_scheduled_at = \\$3, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
+	mock.ExpectExec(scheduleDeleteUserQueryMock).
+		WithArgs("user_id_1", constant.USER_STATUS_DEACTIVE, deleteAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.ScheduleDeleteUser(context.Background(), "user_id_1", deleteAt)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactivateUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	reactivateUserQueryMock := "UPDATE users SET status = \\$2, deactivated_at = NULL, delete_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
+	mock.ExpectExec(reactivateUserQueryMock).
+		WithArgs("user_id_1", constant.USER_STATUS_ACTIVE).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.ReactivateUser(context.Background(), "user_id_1")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserIDsScheduledForDeletion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	now := time.Now()
+
+	getUserIDsScheduledForDeletionQueryMock := "SELECT id FROM users WHERE status = \\$1 AND delete_scheduled_at <= \\$2 ORDER BY delete_scheduled_at LIMIT \\$3"
+	mock.ExpectQuery(getUserIDsScheduledForDeletionQueryMock).
+		WithArgs(constant.USER_STATUS_DEACTIVE, now, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("user_id_1").AddRow("user_id_2"))
+
+	data, err := repo.GetUserIDsScheduledForDeletion(context.Background(), now, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user_id_1", "user_id_2"}, data)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	queries := []string{
+		"DELETE FROM swipes WHERE swiper_id IN \\(SELECT id FROM profiles WHERE user_id = \\$1\\) OR swiped_id IN \\(SELECT id FROM profiles WHERE user_id = \\$1\\)",
+		"DELETE FROM profile_photos WHERE profile_id IN \\(SELECT id FROM profiles WHERE user_id = \\$1\\)",
+		"DELETE FROM profile_verifications WHERE profile_id IN \\(SELECT id FROM profiles WHERE user_id = \\$1\\)",
+		"DELETE FROM profiles WHERE user_id = \\$1",
+		"DELETE FROM one_time_password_logs WHERE user_id = \\$1",
+		"DELETE FROM payments WHERE user_id = \\$1",
+		"DELETE FROM login_failures WHERE user_id = \\$1",
+		"DELETE FROM email_changes WHERE user_id = \\$1",
+		"DELETE FROM user_onboarding_steps WHERE user_id = \\$1",
+		"DELETE FROM discovery_preferences WHERE user_id = \\$1",
+		"DELETE FROM users WHERE id = \\$1",
+	}
+
+	mock.ExpectBegin()
+	for _, query := range queries {
+		mock.ExpectExec(query).WithArgs("user_id_1").WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	err = repo.DeleteUser(context.Background(), "user_id_1")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// nothing is deleted when one of the table failed
+	mock.ExpectBegin()
+	mock.ExpectExec(queries[0]).WithArgs("user_id_1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(queries[1]).WithArgs("user_id_1").WillReturnError(errors.New("deadlock detected"))
+	mock.ExpectRollback()
+
+	err = repo.DeleteUser(context.Background(), "user_id_1")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
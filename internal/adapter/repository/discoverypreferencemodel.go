@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DiscoveryPreferences filter applied on the feed of the user, zero max
+// distance is anywhere. VerifiedOnly hide profiles without the verified badge
+type DiscoveryPreferences struct {
+	UserID        string       `db:"user_id"`
+	MinAge        int          `db:"min_age"`
+	MaxAge        int          `db:"max_age"`
+	MaxDistanceKm int          `db:"max_distance_km"`
+	VerifiedOnly  bool         `db:"verified_only"`
+	CreatedAt     time.Time    `db:"created_at"`
+	UpdatedAt     sql.NullTime `db:"updated_at"`
+}
+
+func (d *DiscoveryPreferences) RowData() []interface{} {
+	var data = []interface{}{
+		d.UserID,
+		d.MinAge,
+		d.MaxAge,
+		d.MaxDistanceKm,
+		d.VerifiedOnly,
+	}
+	return data
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+const getDiscoveryPreferencesQuery = `SELECT user_id, min_age, max_age, max_distance_km, verified_only, created_at, updated_at FROM discovery_preferences WHERE user_id = $1 LIMIT 1`
+
+func (r *repo) GetDiscoveryPreferences(
+	ctx context.Context,
+	UserID string,
+) (*DiscoveryPreferences, error) {
+	var data DiscoveryPreferences
+	err := r.conn.GetContext(
+		ctx,
+		&data,
+		getDiscoveryPreferencesQuery,
+		UserID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+const upsertDiscoveryPreferencesQuery = `INSERT INTO discovery_preferences (user_id, min_age, max_age, max_distance_km, verified_only, created_at) VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP) ON CONFLICT (user_id) DO UPDATE SET min_age = EXCLUDED.min_age, max_age = EXCLUDED.max_age, max_distance_km = EXCLUDED.max_distance_km, verified_only = EXCLUDED.verified_only, updated_at = CURRENT_TIMESTAMP`
+
+func (r *repo) UpsertDiscoveryPreferences(
+	ctx context.Context,
+	req *DiscoveryPreferences,
+) error {
+	if _, err := r.conn.ExecContext(
+		ctx,
+		upsertDiscoveryPreferencesQuery,
+		req.RowData()...,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const createDefaultDiscoveryPreferencesQuery = `INSERT INTO discovery_preferences (user_id, min_age, max_age, max_distance_km, verified_only, created_at) VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP) ON CONFLICT (user_id) DO NOTHING`
+
+// CreateDefaultDiscoveryPreferences preferences already chosen by the user
+// are kept
+func (r *repo) CreateDefaultDiscoveryPreferences(
+	ctx context.Context,
+	req *DiscoveryPreferences,
+) error {
+	if _, err := r.conn.ExecContext(
+		ctx,
+		createDefaultDiscoveryPreferencesQuery,
+		req.RowData()...,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDiscoveryPreferences(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := NewUserRepo(dbx)
+
+	UserID := "user_id_1"
+	getDiscoveryPreferencesQueryMock := "SELECT user_id, min_age, max_age, max_distance_km, verified_only, created_at, updated_at FROM discovery_preferences WHERE user_id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getDiscoveryPreferencesQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "min_age", "max_age", "max_distance_km", "created_at", "updated_at"}).
+			AddRow(UserID, 25, 35, 50, time.Now(), nil))
+	mock.ExpectQuery(getDiscoveryPreferencesQueryMock).WithArgs("user_id_2").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "min_age", "max_age", "max_distance_km", "created_at", "updated_at"}))
+
+	ctx := context.Background()
+	preferences, err := repo.GetDiscoveryPreferences(ctx, UserID)
+	assert.NoError(t, err)
+	assert.Equal(t, 25, preferences.MinAge)
+	assert.Equal(t, 35, preferences.MaxAge)
+	assert.Equal(t, 50, preferences.MaxDistanceKm)
+
+	// user without preferences
+	preferences, err = repo.GetDiscoveryPreferences(ctx, "user_id_2")
+	assert.NoError(t, err)
+	assert.Nil(t, preferences)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertDiscoveryPreferences(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := NewUserRepo(dbx)
+
+	req := &DiscoveryPreferences{UserID: "user_id_1", MinAge: 21, MaxAge: 30, MaxDistanceKm: 0}
+
+	upsertDiscoveryPreferencesQueryMock := "INSERT INTO discovery_preferences \\(user_id, min_age, max_age, max_distance_km, verified_only, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, CURRENT_TIMESTAMP\\) ON CONFLICT \\(user_id\\) DO UPDATE"
+	mock.ExpectExec(upsertDiscoveryPreferencesQueryMock).
+		WithArgs(req.UserID, req.MinAge, req.MaxAge, req.MaxDistanceKm, req.VerifiedOnly).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	createDefaultDiscoveryPreferencesQueryMock := "INSERT INTO discovery_preferences \\(user_id, min_age, max_age, max_distance_km, verified_only, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, CURRENT_TIMESTAMP\\) ON CONFLICT \\(user_id\\) DO NOTHING"
+	mock.ExpectExec(createDefaultDiscoveryPreferencesQueryMock).
+		WithArgs(req.UserID, req.MinAge, req.MaxAge, req.MaxDistanceKm, req.VerifiedOnly).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ctx := context.Background()
+	assert.NoError(t, repo.UpsertDiscoveryPreferences(ctx, req))
+	assert.NoError(t, repo.CreateDefaultDiscoveryPreferences(ctx, req))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
+
+// EmailChange request to replace the login email, row is kept after the
+// change is done as audit of every email the account had
+type EmailChange struct {
+	ID          string                     `db:"id"`
+	UserID      string                     `db:"user_id"`
+	OldEmail    string                     `db:"old_email"`
+	NewEmail    string                     `db:"new_email"`
+	CancelToken string                     `db:"cancel_token"`
+	Status      constant.EmailChangeStatus `db:"status"`
+	CreatedAt   time.Time                  `db:"created_at"`
+	ConfirmedAt sql.NullTime               `db:"confirmed_at"`
+	CancelledAt sql.NullTime               `db:"cancelled_at"`
+}
+
+type CreateEmailChange struct {
+	UserID      string `db:"user_id"`
+	OldEmail    string `db:"old_email"`
+	NewEmail    string `db:"new_email"`
+	CancelToken string `db:"cancel_token"`
+}
+
+func (e *CreateEmailChange) RowData() []interface{} {
+	var data = []interface{}{
+		e.UserID,
+		e.OldEmail,
+		e.NewEmail,
+		e.CancelToken,
+	}
+	return data
+}
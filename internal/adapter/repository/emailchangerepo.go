@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+const (
+	cancelPendingEmailChangeQuery = `UPDATE email_changes SET status = 'CANCELLED', cancelled_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND status = 'PENDING'`
+	createEmailChangeQuery        = `INSERT INTO email_changes (user_id, old_email, new_email, cancel_token, status, created_at) VALUES ($1, $2, $3, $4, 'PENDING', CURRENT_TIMESTAMP) RETURNING id`
+)
+
+// CreateEmailChange cancel previous pending request of the user, only the
+// latest request can be confirmed
+func (r *repo) CreateEmailChange(
+	ctx context.Context,
+	req *CreateEmailChange,
+) (id string, err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer txAction(tx, &err)
+
+	if _, err = tx.ExecContext(ctx, cancelPendingEmailChangeQuery, req.UserID); err != nil {
+		return "", err
+	}
+
+	if err = tx.GetContext(ctx, &id, createEmailChangeQuery, req.RowData()...); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+const getPendingEmailChangeQuery = `SELECT id, user_id, old_email, new_email, cancel_token, status, created_at, confirmed_at, cancelled_at FROM email_changes WHERE user_id = $1 AND status = 'PENDING' ORDER BY created_at DESC LIMIT 1`
+
+func (r *repo) GetPendingEmailChange(
+	ctx context.Context,
+	UserID string,
+) (*EmailChange, error) {
+	var data EmailChange
+	err := r.conn.GetContext(
+		ctx,
+		&data,
+		getPendingEmailChangeQuery,
+		UserID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+const getEmailChangeByCancelTokenQuery = `SELECT id, user_id, old_email, new_email, cancel_token, status, created_at, confirmed_at, cancelled_at FROM email_changes WHERE cancel_token = $1 LIMIT 1`
+
+func (r *repo) GetEmailChangeByCancelToken(
+	ctx context.Context,
+	cancelToken string,
+) (*EmailChange, error) {
+	var data EmailChange
+	err := r.conn.GetContext(
+		ctx,
+		&data,
+		getEmailChangeByCancelTokenQuery,
+		cancelToken,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+const getEmailChangesByUserIDQuery = `SELECT id, user_id, old_email, new_email, cancel_token, status, created_at, confirmed_at, cancelled_at FROM email_changes WHERE user_id = $1 ORDER BY created_at DESC`
+
+func (r *repo) GetEmailChangesByUserID(
+	ctx context.Context,
+	UserID string,
+) ([]*EmailChange, error) {
+	var data []*EmailChange
+	err := r.conn.SelectContext(
+		ctx,
+		&data,
+		getEmailChangesByUserIDQuery,
+		UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+const (
+	updateUserEmailQuery    = `UPDATE users SET email = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	confirmEmailChangeQuery = `UPDATE email_changes SET status = 'CONFIRMED', confirmed_at = CURRENT_TIMESTAMP WHERE id = $1 AND status = 'PENDING'`
+	revertEmailChangeQuery  = `UPDATE email_changes SET status = 'REVERTED', cancelled_at = CURRENT_TIMESTAMP WHERE id = $1 AND status = 'CONFIRMED'`
+	cancelEmailChangeQuery  = `UPDATE email_changes SET status = 'CANCELLED', cancelled_at = CURRENT_TIMESTAMP WHERE id = $1 AND status = 'PENDING'`
+)
+
+// ConfirmEmailChange swap users.email to the new address
+func (r *repo) ConfirmEmailChange(
+	ctx context.Context,
+	change *EmailChange,
+) (err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txAction(tx, &err)
+
+	tag, err := tx.ExecContext(ctx, confirmEmailChangeQuery, change.ID)
+	if err != nil {
+		return err
+	}
+	if err = checkTagInt(tag, "confirm email change"); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, updateUserEmailQuery, change.UserID, change.NewEmail); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevertEmailChange put back the old address of a confirmed change
+func (r *repo) RevertEmailChange(
+	ctx context.Context,
+	change *EmailChange,
+) (err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txAction(tx, &err)
+
+	tag, err := tx.ExecContext(ctx, revertEmailChangeQuery, change.ID)
+	if err != nil {
+		return err
+	}
+	if err = checkTagInt(tag, "revert email change"); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, updateUserEmailQuery, change.UserID, change.OldEmail); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) CancelEmailChange(
+	ctx context.Context,
+	id string,
+) error {
+	if _, err := r.conn.ExecContext(
+		ctx,
+		cancelEmailChangeQuery,
+		id,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/pkg/constant"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var emailChangeColumns = []string{"id", "user_id", "old_email", "new_email", "cancel_token", "status", "created_at", "confirmed_at", "cancelled_at"}
+
+func TestCreateEmailChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	req := &CreateEmailChange{
+		UserID:      "user_id_1",
+		OldEmail:    "old@example.com",
+		NewEmail:    "new@example.com",
+		CancelToken: "hashed_token",
+	}
+
+	mock.ExpectBegin()
+	cancelPendingEmailChangeQueryMock := "UPDATE email_changes SET status = 'CANCELLED', cancelled_at = CURRENT_TIMESTAMP WHERE user_id = \\$1 AND status = 'PENDING'"
+	mock.ExpectExec(cancelPendingEmailChangeQueryMock).WithArgs(req.UserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	createEmailChangeQueryMock := "INSERT INTO email_changes \\(user_id, old_email, new_email, cancel_token, status, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, 'PENDING', CURRENT_TIMESTAMP\\) RETURNING id"
+	mock.ExpectQuery(createEmailChangeQueryMock).
+		WithArgs(req.UserID, req.OldEmail, req.NewEmail, req.CancelToken).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("change_id_1"))
+	mock.ExpectCommit()
+
+	id, err := repo.CreateEmailChange(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "change_id_1", id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPendingEmailChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	getPendingEmailChangeQueryMock := "SELECT id, user_id, old_email, new_email, cancel_token, status, created_at, confirmed_at, cancelled_at FROM email_changes WHERE user_id = \\$1 AND status = 'PENDING' ORDER BY created_at DESC LIMIT 1"
+	mock.ExpectQuery(getPendingEmailChangeQueryMock).WithArgs("user_id_1").
+		WillReturnRows(sqlmock.NewRows(emailChangeColumns).
+			AddRow("change_id_1", "user_id_1", "old@example.com", "new@example.com", "hashed_token", constant.EMAIL_CHANGE_STATUS_PENDING, time.Now(), nil, nil))
+
+	data, err := repo.GetPendingEmailChange(context.Background(), "user_id_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "new@example.com", data.NewEmail)
+	assert.Equal(t, constant.EMAIL_CHANGE_STATUS_PENDING, data.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	change := &EmailChange{
+		ID:       "change_id_1",
+		UserID:   "user_id_1",
+		OldEmail: "old@example.com",
+		NewEmail: "new@example.com",
+	}
+
+	mock.ExpectBegin()
+	confirmEmailChangeQueryMock := "UPDATE email_changes SET status = 'CONFIRMED', confirmed_at = CURRENT_TIMESTAMP WHERE id = \\$1 AND status = 'PENDING'"
+	mock.ExpectExec(confirmEmailChangeQueryMock).WithArgs(change.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	updateUserEmailQueryMock := "UPDATE users SET email = \\$2, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
+	mock.ExpectExec(updateUserEmailQueryMock).WithArgs(change.UserID, change.NewEmail).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.ConfirmEmailChange(context.Background(), change)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfirmEmailChangeAlreadyCancelled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	// cancelled between lookup and confirmation, email is untouched
+	mock.ExpectBegin()
+	confirmEmailChangeQueryMock := "UPDATE email_changes SET status = 'CONFIRMED', confirmed_at = CURRENT_TIMESTAMP WHERE id = \\$1 AND status = 'PENDING'"
+	mock.ExpectExec(confirmEmailChangeQueryMock).WithArgs("change_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.ConfirmEmailChange(context.Background(), &EmailChange{ID: "change_id_1", UserID: "user_id_1"})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevertEmailChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	change := &EmailChange{
+		ID:       "change_id_1",
+		UserID:   "user_id_1",
+		OldEmail: "old@example.com",
+		NewEmail: "new@example.com",
+	}
+
+	mock.ExpectBegin()
+	revertEmailChangeQueryMock := "UPDATE email_changes SET status = 'REVERTED', cancelled_at = CURRENT_TIMESTAMP WHERE id = \\$1 AND status = 'CONFIRMED'"
+	mock.ExpectExec(revertEmailChangeQueryMock).WithArgs(change.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	updateUserEmailQueryMock := "UPDATE users SET email = \\$2, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
+	mock.ExpectExec(updateUserEmailQueryMock).WithArgs(change.UserID, change.OldEmail).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.RevertEmailChange(context.Background(), change)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
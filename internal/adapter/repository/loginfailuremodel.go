@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// LoginFailure failure ledger of one account, LockoutLevel is kept after the
+// lock is released so next lockout use longer window
+type LoginFailure struct {
+	UserID         string         `db:"user_id"`
+	FailedAttempts int            `db:"failed_attempts"`
+	LockoutLevel   int            `db:"lockout_level"`
+	LockedUntil    sql.NullTime   `db:"locked_until"`
+	PreviousStatus sql.NullString `db:"previous_status"`
+	LastFailedAt   sql.NullTime   `db:"last_failed_at"`
+}
+
+type LockUser struct {
+	UserID         string    `db:"user_id"`
+	LockoutLevel   int       `db:"lockout_level"`
+	LockedUntil    time.Time `db:"locked_until"`
+	PreviousStatus string    `db:"previous_status"`
+}
+
+func (l *LockUser) RowData() []interface{} {
+	var data = []interface{}{
+		l.UserID,
+		l.LockoutLevel,
+		l.LockedUntil,
+		l.PreviousStatus,
+	}
+	return data
+}
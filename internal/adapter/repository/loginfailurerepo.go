@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
+
+const getLoginFailureQuery = `SELECT user_id, failed_attempts, lockout_level, locked_until, previous_status, last_failed_at FROM login_failures WHERE user_id = $1 LIMIT 1`
+
+func (r *repo) GetLoginFailure(
+	ctx context.Context,
+	UserID string,
+) (*LoginFailure, error) {
+	var data LoginFailure
+	err := r.conn.GetContext(
+		ctx,
+		&data,
+		getLoginFailureQuery,
+		UserID,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// counter is incremented on the database so concurrent failure is not lost
+const recordLoginFailureQuery = `INSERT INTO login_failures (user_id, failed_attempts, last_failed_at) VALUES ($1, 1, CURRENT_TIMESTAMP) ON CONFLICT (user_id) DO UPDATE SET failed_attempts = login_failures.failed_attempts + 1, last_failed_at = CURRENT_TIMESTAMP RETURNING user_id, failed_attempts, lockout_level, locked_until, previous_status, last_failed_at`
+
+func (r *repo) RecordLoginFailure(
+	ctx context.Context,
+	UserID string,
+) (*LoginFailure, error) {
+	var data LoginFailure
+	err := r.conn.GetContext(
+		ctx,
+		&data,
+		recordLoginFailureQuery,
+		UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+const (
+	lockLoginFailureQuery = `UPDATE login_failures SET failed_attempts = 0, lockout_level = $2, locked_until = $3, previous_status = $4 WHERE user_id = $1`
+	lockUserQuery         = `UPDATE users SET status = $2 WHERE id = $1`
+)
+
+// LockUser reset failure counter, save the lock window and block the user
+func (r *repo) LockUser(
+	ctx context.Context,
+	req *LockUser,
+) (err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txAction(tx, &err)
+
+	if _, err = tx.ExecContext(
+		ctx,
+		lockLoginFailureQuery,
+		req.RowData()...,
+	); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		lockUserQuery,
+		req.UserID,
+		constant.USER_STATUS_TEMPORARY_BLOCKED,
+	)
+
+	return err
+}
+
+const releaseLoginFailureQuery = `UPDATE login_failures SET locked_until = NULL, previous_status = NULL WHERE user_id = $1`
+
+// ReleaseLockUser restore user status once lock window is over, lockout level
+// is kept
+func (r *repo) ReleaseLockUser(
+	ctx context.Context,
+	req *UpdateStatus,
+) (err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txAction(tx, &err)
+
+	if _, err = tx.ExecContext(
+		ctx,
+		releaseLoginFailureQuery,
+		req.ID,
+	); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		updateStatusUserQuery,
+		req.RowData()...,
+	)
+
+	return err
+}
+
+const deleteLoginFailureQuery = `DELETE FROM login_failures WHERE user_id = $1`
+
+func (r *repo) DeleteLoginFailure(
+	ctx context.Context,
+	UserID string,
+) error {
+	if _, err := r.conn.ExecContext(
+		ctx,
+		deleteLoginFailureQuery,
+		UserID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/pkg/constant"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLoginFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	getLoginFailureQueryMock := "SELECT user_id, failed_attempts, lockout_level, locked_until, previous_status, last_failed_at FROM login_failures WHERE user_id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getLoginFailureQueryMock).WithArgs("user_id_1").
+		WillReturnError(sql.ErrNoRows)
+
+	data, err := repo.GetLoginFailure(context.Background(), "user_id_1")
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordLoginFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	lastFailedAt := time.Now()
+
+	recordLoginFailureQueryMock := "INSERT INTO login_failures \\(user_id, failed_attempts, last_failed_at\\) VALUES \\(\\$1, 1, CURRENT_TIMESTAMP\\) ON CONFLICT \\(user_id\\) DO UPDATE SET failed_attempts = login_failures.failed_attempts \\+ 1, last_failed_at = CURRENT_TIMESTAMP RETURNING user_id, failed_attempts, lockout_level, locked_until, previous_status, last_failed_at"
+	mock.ExpectQuery(recordLoginFailureQueryMock).WithArgs("user_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "failed_attempts", "lockout_level", "locked_until", "previous_status", "last_failed_at"}).
+			AddRow("user_id_1", 2, 1, nil, nil, lastFailedAt))
+
+	data, err := repo.RecordLoginFailure(context.Background(), "user_id_1")
+	assert.NoError(t, err)
+	assert.Equal(t, &LoginFailure{
+		UserID:         "user_id_1",
+		FailedAttempts: 2,
+		LockoutLevel:   1,
+		LastFailedAt:   sql.NullTime{Time: lastFailedAt, Valid: true},
+	}, data)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLockUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	req := &LockUser{
+		UserID:         "user_id_1",
+		LockoutLevel:   2,
+		LockedUntil:    time.Now().Add(5 * time.Minute),
+		PreviousStatus: constant.USER_STATUS_ACTIVE.String(),
+	}
+
+	lockLoginFailureQueryMock := "UPDATE login_failures SET failed_attempts = 0, lockout_level = \\$2, locked_until = \\$3, previous_status = \\$4 WHERE user_id = \\$1"
+	lockUserQueryMock := "UPDATE users SET status = \\$2 WHERE id = \\$1"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(lockLoginFailureQueryMock).WithArgs(req.UserID, req.LockoutLevel, req.LockedUntil, req.PreviousStatus).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(lockUserQueryMock).WithArgs(req.UserID, constant.USER_STATUS_TEMPORARY_BLOCKED).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.LockUser(context.Background(), req)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// ledger is not updated when user can not be blocked
+	mock.ExpectBegin()
+	mock.ExpectExec(lockLoginFailureQueryMock).WithArgs(req.UserID, req.LockoutLevel, req.LockedUntil, req.PreviousStatus).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(lockUserQueryMock).WithArgs(req.UserID, constant.USER_STATUS_TEMPORARY_BLOCKED).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	err = repo.LockUser(context.Background(), req)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReleaseLockUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE login_failures SET locked_until = NULL, previous_status = NULL WHERE user_id = \\$1").
+		WithArgs("user_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET status = \\$2 WHERE id = \\$1").
+		WithArgs("user_id_1", constant.USER_STATUS_ACTIVE).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.ReleaseLockUser(context.Background(), &UpdateStatus{
+		ID:     "user_id_1",
+		Status: constant.USER_STATUS_ACTIVE,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteLoginFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	mock.ExpectExec("DELETE FROM login_failures WHERE user_id = \\$1").WithArgs("user_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.DeleteLoginFailure(context.Background(), "user_id_1")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
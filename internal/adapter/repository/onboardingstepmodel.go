@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
+
+// OnboardingStep progress of one step, step without row is still pending
+type OnboardingStep struct {
+	UserID      string                        `db:"user_id"`
+	Step        constant.OnboardingStep       `db:"step"`
+	Status      constant.OnboardingStepStatus `db:"status"`
+	CompletedAt sql.NullTime                  `db:"completed_at"`
+	CreatedAt   time.Time                     `db:"created_at"`
+}
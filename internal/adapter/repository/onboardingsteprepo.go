@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
+
+const getOnboardingStepsByUserIDQuery = `SELECT user_id, step, status, completed_at, created_at FROM user_onboarding_steps WHERE user_id = $1`
+
+func (r *repo) GetOnboardingStepsByUserID(
+	ctx context.Context,
+	UserID string,
+) ([]*OnboardingStep, error) {
+	var data []*OnboardingStep
+	err := r.conn.SelectContext(
+		ctx,
+		&data,
+		getOnboardingStepsByUserIDQuery,
+		UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// step is completed by a single upsert, completed_at of a done step is never
+// overwritten
+const completeOnboardingStepQuery = `INSERT INTO user_onboarding_steps (user_id, step, status, completed_at, created_at) VALUES ($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) ON CONFLICT (user_id, step) DO UPDATE SET status = EXCLUDED.status, completed_at = EXCLUDED.completed_at WHERE user_onboarding_steps.status <> EXCLUDED.status`
+
+// CompleteOnboardingStep return false when the step was already done
+func (r *repo) CompleteOnboardingStep(
+	ctx context.Context,
+	UserID string,
+	step constant.OnboardingStep,
+) (bool, error) {
+	result, err := r.conn.ExecContext(
+		ctx,
+		completeOnboardingStepQuery,
+		UserID,
+		step,
+		constant.ONBOARDING_STEP_STATUS_DONE,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
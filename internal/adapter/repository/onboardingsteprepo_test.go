@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/pkg/constant"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOnboardingStepsByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	getOnboardingStepsByUserIDQueryMock := "SELECT user_id, step, status, completed_at, created_at FROM user_onboarding_steps WHERE user_id = \\$1"
+	mock.ExpectQuery(getOnboardingStepsByUserIDQueryMock).WithArgs("user_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "step", "status", "completed_at", "created_at"}).
+			AddRow("user_id_1", constant.ONBOARDING_STEP_PERSONAL_INFO, constant.ONBOARDING_STEP_STATUS_DONE, time.Now(), time.Now()).
+			AddRow("user_id_1", constant.ONBOARDING_STEP_PHOTOS, constant.ONBOARDING_STEP_STATUS_PENDING, nil, time.Now()))
+
+	data, err := repo.GetOnboardingStepsByUserID(context.Background(), "user_id_1")
+	assert.NoError(t, err)
+	assert.Len(t, data, 2)
+	assert.Equal(t, constant.ONBOARDING_STEP_PERSONAL_INFO, data[0].Step)
+	assert.True(t, data[0].CompletedAt.Valid)
+	assert.Equal(t, constant.ONBOARDING_STEP_STATUS_PENDING, data[1].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteOnboardingStep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	completeOnboardingStepQueryMock := "INSERT INTO user_onboarding_steps \\(user_id, step, status, completed_at, created_at\\) VALUES \\(\\$1, \\$2, \\$3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP\\) ON CONFLICT \\(user_id, step\\) DO UPDATE SET status = EXCLUDED.status, completed_at = EXCLUDED.completed_at WHERE user_onboarding_steps.status <> EXCLUDED.status"
+	mock.ExpectExec(completeOnboardingStepQueryMock).
+		WithArgs("user_id_1", constant.ONBOARDING_STEP_PHOTOS, constant.ONBOARDING_STEP_STATUS_DONE).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	completed, err := repo.CompleteOnboardingStep(context.Background(), "user_id_1", constant.ONBOARDING_STEP_PHOTOS)
+	assert.NoError(t, err)
+	assert.True(t, completed)
+
+	// step already done is left untouched
+	mock.ExpectExec(completeOnboardingStepQueryMock).
+		WithArgs("user_id_1", constant.ONBOARDING_STEP_PHOTOS, constant.ONBOARDING_STEP_STATUS_DONE).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	completed, err = repo.CompleteOnboardingStep(context.Background(), "user_id_1", constant.ONBOARDING_STEP_PHOTOS)
+	assert.NoError(t, err)
+	assert.False(t, completed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
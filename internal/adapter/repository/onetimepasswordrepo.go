@@ -109,3 +109,74 @@ func (r *repo) GetCountOneTimePasswordByTime(
 
 	return count, nil
 }
+
+const getOneTimePasswordLogByCodeQuery = `SELECT id, user_id, onetime_password_type, code, status, created_at, otp_limit FROM one_time_password_logs WHERE onetime_password_type = $1 AND code = $2 LIMIT 1`
+
+func (r *repo) GetOneTimePasswordLogByCode(
+	ctx context.Context,
+	otpType, code string,
+) (*OneTimePasswordLog, error) {
+	var data OneTimePasswordLog
+	err := r.conn.GetContext(
+		ctx,
+		&data,
+		getOneTimePasswordLogByCodeQuery,
+		otpType, code,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+const useOneTimePasswordLogQuery = `UPDATE one_time_password_logs SET status = 'USED', updated_at = $2 WHERE id = $1 AND status = 'UNUSED'`
+
+// UseOneTimePasswordLog mark unused log as used, false mean it was already
+// used or expired by another request
+func (r *repo) UseOneTimePasswordLog(
+	ctx context.Context,
+	id string,
+) (bool, error) {
+	tag, err := r.conn.ExecContext(
+		ctx,
+		useOneTimePasswordLogQuery,
+		id,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := tag.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// code is not selected, only hash of it is stored anyway
+const getOneTimePasswordLogsByUserIDQuery = `SELECT id, user_id, onetime_password_type, status, created_at, updated_at, otp_limit FROM one_time_password_logs WHERE user_id = $1 ORDER BY created_at`
+
+func (r *repo) GetOneTimePasswordLogsByUserID(
+	ctx context.Context,
+	UserID string,
+) ([]*OneTimePasswordLog, error) {
+	var data []*OneTimePasswordLog
+	err := r.conn.SelectContext(
+		ctx,
+		&data,
+		getOneTimePasswordLogsByUserIDQuery,
+		UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -132,3 +132,90 @@ func TestGetCountOneTimePasswordByTime(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 5, count) // Expecting 5 one-time passwords found within the given time range
 }
+
+func TestGetOneTimePasswordLogByCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := NewUserRepo(dbx)
+
+	otpType := constant.OTP_TYPE_MAGIC_LINK
+	code := "magic_link_nonce"
+
+	getOneTimePasswordLogByCodeMock := "SELECT id, user_id, onetime_password_type, code, status, created_at, otp_limit FROM one_time_password_logs WHERE onetime_password_type = \\$1 AND code = \\$2 LIMIT 1"
+	mock.ExpectQuery(getOneTimePasswordLogByCodeMock).
+		WithArgs(otpType, code).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "code", "status", "created_at", "otp_limit"}).
+			AddRow("log_id_1", "user_id_1", otpType, code, constant.OTP_STATUS_UNUSED, time.Now().UTC(), 3))
+
+	ctx := context.Background()
+	log, err := repo.GetOneTimePasswordLogByCode(ctx, otpType.String(), code)
+	assert.NoError(t, err)
+	assert.NotNil(t, log)
+	assert.Equal(t, "log_id_1", log.ID)
+	assert.Equal(t, "user_id_1", log.UserID)
+
+	// Unknown code return nil without error
+	mock.ExpectQuery(getOneTimePasswordLogByCodeMock).
+		WithArgs(otpType, "unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "code", "status", "created_at", "otp_limit"}))
+
+	log, err = repo.GetOneTimePasswordLogByCode(ctx, otpType.String(), "unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, log)
+}
+
+func TestUseOneTimePasswordLog(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := NewUserRepo(dbx)
+
+	useOneTimePasswordLogMock := "UPDATE one_time_password_logs SET status = 'USED', updated_at = \\$2 WHERE id = \\$1 AND status = 'UNUSED'"
+	mock.ExpectExec(useOneTimePasswordLogMock).
+		WithArgs("log_id_1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(useOneTimePasswordLogMock).
+		WithArgs("log_id_1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ctx := context.Background()
+	used, err := repo.UseOneTimePasswordLog(ctx, "log_id_1")
+	assert.NoError(t, err)
+	assert.True(t, used)
+
+	// Second use is rejected
+	used, err = repo.UseOneTimePasswordLog(ctx, "log_id_1")
+	assert.NoError(t, err)
+	assert.False(t, used)
+}
+
+func TestGetOneTimePasswordLogsByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := NewUserRepo(dbx)
+
+	UserID := "user_id_1"
+	createdAt := time.Now().UTC()
+
+	getOneTimePasswordLogsByUserIDMock := "SELECT id, user_id, onetime_password_type, status, created_at, updated_at, otp_limit FROM one_time_password_logs WHERE user_id = \\$1 ORDER BY created_at"
+	mock.ExpectQuery(getOneTimePasswordLogsByUserIDMock).
+		WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "status", "created_at", "updated_at", "otp_limit"}).
+			AddRow("log_id_1", UserID, constant.OTP_TYPE_EMAIL, constant.OTP_STATUS_USED, createdAt, createdAt, 3))
+
+	ctx := context.Background()
+	logs, err := repo.GetOneTimePasswordLogsByUserID(ctx, UserID)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, constant.OTP_STATUS_USED, logs[0].Status)
+	assert.Empty(t, logs[0].Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,14 +1,22 @@
 package repository
 
-import "github.com/ijlik/dating-user/pkg/constant"
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
 
 type Payment struct {
+	ID            string                 `db:"id"`
 	UserID        string                 `db:"user_id"`
 	Amount        float32                `db:"amount"`
 	Identifier    string                 `db:"identifier"`
 	PaymentMethod constant.PaymentMethod `db:"payment_method"`
 	PaymentData   string                 `db:"payment_data"`
 	Status        constant.PaymentStatus `db:"status"`
+	CreatedAt     time.Time              `db:"created_at"`
+	UpdatedAt     sql.NullTime           `db:"updated_at"`
 }
 
 func (p *Payment) RowData() []interface{} {
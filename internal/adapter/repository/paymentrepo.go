@@ -20,3 +20,23 @@ func (r *repo) CreatePayment(
 
 	return nil
 }
+
+const getPaymentsByUserIDQuery = `SELECT id, user_id, amount, identifier, payment_method, payment_data, status, created_at, updated_at FROM payments WHERE user_id = $1 ORDER BY created_at`
+
+func (r *repo) GetPaymentsByUserID(
+	ctx context.Context,
+	UserID string,
+) ([]*Payment, error) {
+	var data []*Payment
+	err := r.conn.SelectContext(
+		ctx,
+		&data,
+		getPaymentsByUserIDQuery,
+		UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
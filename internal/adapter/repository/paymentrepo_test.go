@@ -7,6 +7,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestCreatePayment(t *testing.T) {
@@ -44,3 +45,29 @@ func TestCreatePayment(t *testing.T) {
 	err = repo.CreatePayment(ctx, req)
 	assert.NoError(t, err)
 }
+
+func TestGetPaymentsByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := NewUserRepo(dbx)
+
+	UserID := "user_id_1"
+	createdAt := time.Now().UTC()
+
+	getPaymentsByUserIDQueryMock := "SELECT id, user_id, amount, identifier, payment_method, payment_data, status, created_at, updated_at FROM payments WHERE user_id = \\$1 ORDER BY created_at"
+	mock.ExpectQuery(getPaymentsByUserIDQueryMock).
+		WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "amount", "identifier", "payment_method", "payment_data", "status", "created_at", "updated_at"}).
+			AddRow("payment_id_1", UserID, 100.0, "payment_identifier", constant.PAYMENT_METHOD_GOOGLE_WALLET, "credit_card_data", constant.PAYMENT_STATUS_SUCCESS, createdAt, nil))
+
+	ctx := context.Background()
+	payments, err := repo.GetPaymentsByUserID(ctx, UserID)
+	assert.NoError(t, err)
+	assert.Len(t, payments, 1)
+	assert.Equal(t, "payment_identifier", payments[0].Identifier)
+	assert.Equal(t, constant.PAYMENT_STATUS_SUCCESS, payments[0].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
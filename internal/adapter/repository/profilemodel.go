@@ -6,20 +6,36 @@ import (
 )
 
 type Profile struct {
-	ID                  string         `db:"id"`
-	UserID              string         `db:"user_id"`
-	Name                sql.NullString `db:"name"`
-	BirthDate           sql.NullTime   `db:"birth_date"`
-	Gender              sql.NullString `db:"gender"`
-	Photos              sql.NullString `db:"photos"`
-	Hobby               sql.NullString `db:"hobby"`
-	Interest            sql.NullString `db:"interest"`
-	Location            sql.NullString `db:"location"`
-	IsPremium           bool           `db:"is_premium"`
-	IsPremiumValidUntil sql.NullTime   `db:"is_premium_valid_until"`
-	DailySwapQuota      int            `db:"daily_swap_quota"`
-	CreatedAt           time.Time      `db:"created_at"`
-	UpdatedAt           sql.NullTime   `db:"updated_at"`
+	ID                  string          `db:"id"`
+	UserID              string          `db:"user_id"`
+	Name                sql.NullString  `db:"name"`
+	BirthDate           sql.NullTime    `db:"birth_date"`
+	Gender              sql.NullString  `db:"gender"`
+	Photos              sql.NullString  `db:"photos"`
+	Hobby               sql.NullString  `db:"hobby"`
+	Interest            sql.NullString  `db:"interest"`
+	Latitude            sql.NullFloat64 `db:"latitude"`
+	Longitude           sql.NullFloat64 `db:"longitude"`
+	Geohash             sql.NullString  `db:"geohash"`
+	IsPremium           bool            `db:"is_premium"`
+	IsPremiumValidUntil sql.NullTime    `db:"is_premium_valid_until"`
+	DailySwapQuota      int             `db:"daily_swap_quota"`
+	CreatedAt           time.Time       `db:"created_at"`
+	UpdatedAt           sql.NullTime    `db:"updated_at"`
+	Bio                 sql.NullString  `db:"bio"`
+	JobTitle            sql.NullString  `db:"job_title"`
+	Company             sql.NullString  `db:"company"`
+	Education           sql.NullString  `db:"education"`
+	HeightCm            sql.NullInt32   `db:"height_cm"`
+	Languages           sql.NullString  `db:"languages"`
+	Prompts             sql.NullString  `db:"prompts"`
+	GenderCustom        sql.NullString  `db:"gender_custom"`
+	Orientation         sql.NullString  `db:"orientation"`
+	ShowMe              sql.NullString  `db:"show_me"`
+	IsVerified          bool            `db:"is_verified"`
+
+	// feed queries only, from the swiper
+	DistanceKm sql.NullFloat64 `db:"distance_km"`
 }
 
 type UpdateProfileInfo struct {
@@ -27,6 +43,10 @@ type UpdateProfileInfo struct {
 	Name      string       `db:"name"`
 	BirthDate sql.NullTime `db:"birth_date"`
 	Gender    string       `db:"gender"`
+
+	GenderCustom sql.NullString `db:"gender_custom"`
+	Orientation  sql.NullString `db:"orientation"`
+	ShowMe       sql.NullString `db:"show_me"`
 }
 
 func (u *UpdateProfileInfo) RowData() []interface{} {
@@ -35,19 +55,9 @@ func (u *UpdateProfileInfo) RowData() []interface{} {
 		u.Name,
 		u.BirthDate,
 		u.Gender,
-	}
-	return data
-}
-
-type UpdatePhotos struct {
-	ID     string `db:"id"`
-	Photos string `db:"photos"`
-}
-
-func (u *UpdatePhotos) RowData() []interface{} {
-	var data = []interface{}{
-		u.ID,
-		u.Photos,
+		u.GenderCustom,
+		u.Orientation,
+		u.ShowMe,
 	}
 	return data
 }
@@ -68,14 +78,18 @@ func (u *UpdateHobbyAndInterest) RowData() []interface{} {
 }
 
 type UpdateLocation struct {
-	ID       string `db:"id"`
-	Location string `db:"location"`
+	ID        string  `db:"id"`
+	Latitude  float64 `db:"latitude"`
+	Longitude float64 `db:"longitude"`
+	Geohash   string  `db:"geohash"`
 }
 
 func (u *UpdateLocation) RowData() []interface{} {
 	var data = []interface{}{
 		u.ID,
-		u.Location,
+		u.Latitude,
+		u.Longitude,
+		u.Geohash,
 	}
 	return data
 }
@@ -97,6 +111,53 @@ func (u *UpdatePremiumStatus) RowData() []interface{} {
 	return data
 }
 
+// PatchProfile null field keep the stored value, UpdatedAt is the version the
+// patch was built from
+type PatchProfile struct {
+	ID        string         `db:"id"`
+	Name      sql.NullString `db:"name"`
+	BirthDate sql.NullTime   `db:"birth_date"`
+	Gender    sql.NullString `db:"gender"`
+	Hobby     sql.NullString `db:"hobby"`
+	Interest  sql.NullString `db:"interest"`
+	Bio       sql.NullString `db:"bio"`
+	JobTitle  sql.NullString `db:"job_title"`
+	Company   sql.NullString `db:"company"`
+	Education sql.NullString `db:"education"`
+	HeightCm  sql.NullInt32  `db:"height_cm"`
+	Languages sql.NullString `db:"languages"`
+	Prompts   sql.NullString `db:"prompts"`
+
+	GenderCustom sql.NullString `db:"gender_custom"`
+	Orientation  sql.NullString `db:"orientation"`
+	ShowMe       sql.NullString `db:"show_me"`
+
+	UpdatedAt sql.NullTime `db:"updated_at"`
+}
+
+func (u *PatchProfile) RowData() []interface{} {
+	var data = []interface{}{
+		u.ID,
+		u.Name,
+		u.BirthDate,
+		u.Gender,
+		u.Hobby,
+		u.Interest,
+		u.Bio,
+		u.JobTitle,
+		u.Company,
+		u.Education,
+		u.HeightCm,
+		u.Languages,
+		u.Prompts,
+		u.GenderCustom,
+		u.Orientation,
+		u.ShowMe,
+		u.UpdatedAt,
+	}
+	return data
+}
+
 func (p *Profile) GetBirthDate() string {
 	if !p.BirthDate.Valid {
 		return ""
@@ -0,0 +1,13 @@
+package repository
+
+import "time"
+
+// ProfilePhoto one photo of the profile, at most one is primary
+type ProfilePhoto struct {
+	ID        string    `db:"id"`
+	ProfileID string    `db:"profile_id"`
+	BlobKey   string    `db:"blob_key"`
+	Position  int       `db:"position"`
+	IsPrimary bool      `db:"is_primary"`
+	CreatedAt time.Time `db:"created_at"`
+}
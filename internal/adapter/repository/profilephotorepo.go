@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const getProfilePhotosQuery = `SELECT id, profile_id, blob_key, position, is_primary, created_at FROM profile_photos WHERE profile_id = $1 ORDER BY position, created_at`
+
+func (r *repo) GetProfilePhotos(
+	ctx context.Context,
+	profileID string,
+) ([]*ProfilePhoto, error) {
+	var data []*ProfilePhoto
+	err := r.conn.SelectContext(
+		ctx,
+		&data,
+		getProfilePhotosQuery,
+		profileID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+const (
+	deleteProfilePhotosQuery = `DELETE FROM profile_photos WHERE profile_id = $1`
+	// photo is appended after the last one, photo already on the profile is
+	// kept where it is
+	insertProfilePhotoQuery = `INSERT INTO profile_photos (profile_id, blob_key, position, created_at) SELECT $1, $2, COALESCE(MAX(position) + 1, 0), CURRENT_TIMESTAMP FROM profile_photos WHERE profile_id = $1 ON CONFLICT (profile_id, blob_key) DO NOTHING`
+	// first photo become primary when the profile has none
+	ensurePrimaryProfilePhotoQuery = `UPDATE profile_photos SET is_primary = true WHERE id = (SELECT id FROM profile_photos WHERE profile_id = $1 ORDER BY position, created_at LIMIT 1) AND NOT EXISTS (SELECT 1 FROM profile_photos WHERE profile_id = $1 AND is_primary)`
+	// profiles.photos is read by profile and feeds, primary photo first
+	syncProfilePhotosQuery = `UPDATE profiles SET photos = (SELECT STRING_AGG(blob_key, ',' ORDER BY is_primary DESC, position) FROM profile_photos WHERE profile_id = $1), updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	// verified badge vouch for the photos the selfie was compared to, a photo
+	// added or deleted need a new verification
+	unverifyProfileQuery = `UPDATE profiles SET is_verified = false WHERE id = $1 AND is_verified`
+)
+
+// ReplaceProfilePhotos keys replace every photo of the profile, in order
+func (r *repo) ReplaceProfilePhotos(
+	ctx context.Context,
+	profileID string,
+	keys []string,
+) (err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer txAction(tx, &err)
+
+	if _, err = tx.ExecContext(ctx, deleteProfilePhotosQuery, profileID); err != nil {
+		return err
+	}
+
+	return addProfilePhotos(ctx, tx, profileID, keys)
+}
+
+const (
+	// concurrent upload, delete and reorder of the profile wait for each
+	// other, so photos read after it stay valid until commit
+	lockProfileQuery         = `SELECT id FROM profiles WHERE id = $1 FOR UPDATE`
+	getProfilePhotoKeysQuery = `SELECT blob_key FROM profile_photos WHERE profile_id = $1`
+	getProfilePhotoIDsQuery  = `SELECT id FROM profile_photos WHERE profile_id = $1`
+)
+
+// AddProfilePhotos keys are appended to the photos of the profile, return
+// false and add nothing when the profile would have more than maxPhotos
+func (r *repo) AddProfilePhotos(
+	ctx context.Context,
+	profileID string,
+	keys []string,
+	maxPhotos int,
+) (added bool, err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer txAction(tx, &err)
+
+	var lockedID string
+	if err = tx.GetContext(ctx, &lockedID, lockProfileQuery, profileID); err != nil {
+		return false, err
+	}
+
+	var existing []string
+	if err = tx.SelectContext(ctx, &existing, getProfilePhotoKeysQuery, profileID); err != nil {
+		return false, err
+	}
+	count := len(existing)
+	stored := make(map[string]bool, len(existing))
+	for _, key := range existing {
+		stored[key] = true
+	}
+	for _, key := range keys {
+		if !stored[key] {
+			stored[key] = true
+			count++
+		}
+	}
+	if count > maxPhotos {
+		return false, nil
+	}
+
+	if err = addProfilePhotos(ctx, tx, profileID, keys); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func addProfilePhotos(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	profileID string,
+	keys []string,
+) error {
+	var inserted int64
+	for _, key := range keys {
+		result, err := tx.ExecContext(ctx, insertProfilePhotoQuery, profileID, key)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		inserted += affected
+	}
+	if _, err := tx.ExecContext(ctx, ensurePrimaryProfilePhotoQuery, profileID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, syncProfilePhotosQuery, profileID); err != nil {
+		return err
+	}
+
+	// photo already on the profile is not a new photo
+	if inserted > 0 {
+		if _, err := tx.ExecContext(ctx, unverifyProfileQuery, profileID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const deleteProfilePhotoQuery = `DELETE FROM profile_photos WHERE profile_id = $1 AND id = $2 RETURNING id`
+
+// DeleteProfilePhoto return false when the profile has no such photo, next
+// photo become primary when the primary one is deleted
+func (r *repo) DeleteProfilePhoto(
+	ctx context.Context,
+	profileID string,
+	id string,
+) (deleted bool, err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer txAction(tx, &err)
+
+	var lockedID string
+	if err = tx.GetContext(ctx, &lockedID, lockProfileQuery, profileID); err != nil {
+		return false, err
+	}
+
+	var deletedID string
+	if err = tx.GetContext(ctx, &deletedID, deleteProfilePhotoQuery, profileID, id); err != nil {
+		if err == sql.ErrNoRows {
+			err = nil
+			return false, nil
+		}
+		return false, err
+	}
+
+	if _, err = tx.ExecContext(ctx, ensurePrimaryProfilePhotoQuery, profileID); err != nil {
+		return false, err
+	}
+	if _, err = tx.ExecContext(ctx, syncProfilePhotosQuery, profileID); err != nil {
+		return false, err
+	}
+	if _, err = tx.ExecContext(ctx, unverifyProfileQuery, profileID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+const updateProfilePhotoPositionQuery = `UPDATE profile_photos SET position = $3 WHERE profile_id = $1 AND id = $2`
+
+// ReorderProfilePhotos ids are every photo of the profile in the new order,
+// return false and change nothing when ids no longer match the photos
+func (r *repo) ReorderProfilePhotos(
+	ctx context.Context,
+	profileID string,
+	ids []string,
+) (reordered bool, err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer txAction(tx, &err)
+
+	var lockedID string
+	if err = tx.GetContext(ctx, &lockedID, lockProfileQuery, profileID); err != nil {
+		return false, err
+	}
+
+	// photo added or deleted since ids were read
+	var existing []string
+	if err = tx.SelectContext(ctx, &existing, getProfilePhotoIDsQuery, profileID); err != nil {
+		return false, err
+	}
+	if len(existing) != len(ids) {
+		return false, nil
+	}
+	listed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		listed[id] = true
+	}
+	for _, id := range existing {
+		if !listed[id] {
+			return false, nil
+		}
+	}
+
+	for position, id := range ids {
+		if _, err = tx.ExecContext(ctx, updateProfilePhotoPositionQuery, profileID, id, position); err != nil {
+			return false, err
+		}
+	}
+	if _, err = tx.ExecContext(ctx, syncProfilePhotosQuery, profileID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+const (
+	// previous primary is cleared first, only one primary is allowed at a time
+	clearPrimaryProfilePhotoQuery = `UPDATE profile_photos SET is_primary = false WHERE profile_id = $1 AND is_primary AND id <> $2 AND EXISTS (SELECT 1 FROM profile_photos WHERE profile_id = $1 AND id = $2)`
+	setPrimaryProfilePhotoQuery   = `UPDATE profile_photos SET is_primary = true WHERE profile_id = $1 AND id = $2`
+)
+
+// SetPrimaryProfilePhoto return false when the profile has no such photo
+func (r *repo) SetPrimaryProfilePhoto(
+	ctx context.Context,
+	profileID string,
+	id string,
+) (updated bool, err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer txAction(tx, &err)
+
+	if _, err = tx.ExecContext(ctx, clearPrimaryProfilePhotoQuery, profileID, id); err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, setPrimaryProfilePhotoQuery, profileID, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	if _, err = tx.ExecContext(ctx, syncProfilePhotosQuery, profileID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
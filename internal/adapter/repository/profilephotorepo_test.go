@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var profilePhotoColumns = []string{"id", "profile_id", "blob_key", "position", "is_primary", "created_at"}
+
+const (
+	insertProfilePhotoQueryMock        = "INSERT INTO profile_photos \\(profile_id, blob_key, position, created_at\\) SELECT \\$1, \\$2, COALESCE\\(MAX\\(position\\) \\+ 1, 0\\), CURRENT_TIMESTAMP FROM profile_photos WHERE profile_id = \\$1 ON CONFLICT \\(profile_id, blob_key\\) DO NOTHING"
+	ensurePrimaryProfilePhotoQueryMock = "UPDATE profile_photos SET is_primary = true WHERE id = \\(SELECT id FROM profile_photos WHERE profile_id = \\$1 ORDER BY position, created_at LIMIT 1\\) AND NOT EXISTS"
+	syncProfilePhotosQueryMock         = "UPDATE profiles SET photos = \\(SELECT STRING_AGG\\(blob_key, ',' ORDER BY is_primary DESC, position\\) FROM profile_photos WHERE profile_id = \\$1\\), updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
+	unverifyProfileQueryMock           = "UPDATE profiles SET is_verified = false WHERE id = \\$1 AND is_verified"
+	lockProfileQueryMock               = "SELECT id FROM profiles WHERE id = \\$1 FOR UPDATE"
+	getProfilePhotoKeysQueryMock       = "SELECT blob_key FROM profile_photos WHERE profile_id = \\$1"
+	getProfilePhotoIDsQueryMock        = "SELECT id FROM profile_photos WHERE profile_id = \\$1"
+)
+
+func expectLockProfilePhotos(mock sqlmock.Sqlmock, profileID string, keys ...string) {
+	mock.ExpectQuery(lockProfileQueryMock).WithArgs(profileID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(profileID))
+	rows := sqlmock.NewRows([]string{"blob_key"})
+	for _, key := range keys {
+		rows.AddRow(key)
+	}
+	mock.ExpectQuery(getProfilePhotoKeysQueryMock).WithArgs(profileID).WillReturnRows(rows)
+}
+
+func TestGetProfilePhotos(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	getProfilePhotosQueryMock := "SELECT id, profile_id, blob_key, position, is_primary, created_at FROM profile_photos WHERE profile_id = \\$1 ORDER BY position, created_at"
+	mock.ExpectQuery(getProfilePhotosQueryMock).WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows(profilePhotoColumns).
+			AddRow("photo_id_1", "profile_id_1", "photos/profile_id_1/a.jpg", 0, false, time.Now()).
+			AddRow("photo_id_2", "profile_id_1", "photos/profile_id_1/b.jpg", 1, true, time.Now()))
+
+	data, err := repo.GetProfilePhotos(context.Background(), "profile_id_1")
+	assert.NoError(t, err)
+	assert.Len(t, data, 2)
+	assert.True(t, data[1].IsPrimary)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReplaceProfilePhotos(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	keys := []string{"photos/profile_id_1/a.jpg", "photos/profile_id_1/b.jpg"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM profile_photos WHERE profile_id = \\$1").WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	for _, key := range keys {
+		mock.ExpectExec(insertProfilePhotoQueryMock).WithArgs("profile_id_1", key).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec(ensurePrimaryProfilePhotoQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(syncProfilePhotosQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(unverifyProfileQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.ReplaceProfilePhotos(context.Background(), "profile_id_1", keys)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddProfilePhotosUnverify(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	// new photo clear the verified badge in the same transaction
+	mock.ExpectBegin()
+	expectLockProfilePhotos(mock, "profile_id_1", "photos/profile_id_1/a.jpg")
+	mock.ExpectExec(insertProfilePhotoQueryMock).WithArgs("profile_id_1", "photos/profile_id_1/c.jpg").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(ensurePrimaryProfilePhotoQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(syncProfilePhotosQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(unverifyProfileQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	added, err := repo.AddProfilePhotos(context.Background(), "profile_id_1", []string{"photos/profile_id_1/c.jpg"}, 5)
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	// photo already on the profile keep the badge
+	mock.ExpectBegin()
+	expectLockProfilePhotos(mock, "profile_id_1", "photos/profile_id_1/a.jpg", "photos/profile_id_1/c.jpg")
+	mock.ExpectExec(insertProfilePhotoQueryMock).WithArgs("profile_id_1", "photos/profile_id_1/c.jpg").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(ensurePrimaryProfilePhotoQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(syncProfilePhotosQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	added, err = repo.AddProfilePhotos(context.Background(), "profile_id_1", []string{"photos/profile_id_1/c.jpg"}, 5)
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	// photo is not added when the badge could not be cleared
+	mock.ExpectBegin()
+	expectLockProfilePhotos(mock, "profile_id_1", "photos/profile_id_1/a.jpg", "photos/profile_id_1/c.jpg")
+	mock.ExpectExec(insertProfilePhotoQueryMock).WithArgs("profile_id_1", "photos/profile_id_1/d.jpg").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(ensurePrimaryProfilePhotoQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(syncProfilePhotosQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(unverifyProfileQueryMock).WithArgs("profile_id_1").
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	_, err = repo.AddProfilePhotos(context.Background(), "profile_id_1", []string{"photos/profile_id_1/d.jpg"}, 5)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddProfilePhotosLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	// concurrent upload used the last slot while the photo was stored
+	mock.ExpectBegin()
+	expectLockProfilePhotos(mock, "profile_id_1", "photos/profile_id_1/a.jpg", "photos/profile_id_1/b.jpg")
+	mock.ExpectCommit()
+
+	added, err := repo.AddProfilePhotos(context.Background(), "profile_id_1", []string{"photos/profile_id_1/c.jpg"}, 2)
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	// photo already on the profile does not count twice
+	mock.ExpectBegin()
+	expectLockProfilePhotos(mock, "profile_id_1", "photos/profile_id_1/a.jpg", "photos/profile_id_1/b.jpg")
+	mock.ExpectExec(insertProfilePhotoQueryMock).WithArgs("profile_id_1", "photos/profile_id_1/b.jpg").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(ensurePrimaryProfilePhotoQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(syncProfilePhotosQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	added, err = repo.AddProfilePhotos(context.Background(), "profile_id_1", []string{"photos/profile_id_1/b.jpg"}, 2)
+	assert.NoError(t, err)
+	assert.True(t, added)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteProfilePhoto(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	deleteProfilePhotoQueryMock := "DELETE FROM profile_photos WHERE profile_id = \\$1 AND id = \\$2 RETURNING id"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockProfileQueryMock).WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("profile_id_1"))
+	mock.ExpectQuery(deleteProfilePhotoQueryMock).WithArgs("profile_id_1", "photo_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("photo_id_1"))
+	mock.ExpectExec(ensurePrimaryProfilePhotoQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(syncProfilePhotosQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// verified badge is cleared with the photo
+	mock.ExpectExec(unverifyProfileQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	deleted, err := repo.DeleteProfilePhoto(context.Background(), "profile_id_1", "photo_id_1")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	// photo of another profile is not deleted
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockProfileQueryMock).WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("profile_id_1"))
+	mock.ExpectQuery(deleteProfilePhotoQueryMock).WithArgs("profile_id_1", "photo_id_2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	deleted, err = repo.DeleteProfilePhoto(context.Background(), "profile_id_1", "photo_id_2")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReorderProfilePhotos(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	expectLockPhotoIDs := func(ids ...string) {
+		mock.ExpectQuery(lockProfileQueryMock).WithArgs("profile_id_1").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("profile_id_1"))
+		rows := sqlmock.NewRows([]string{"id"})
+		for _, id := range ids {
+			rows.AddRow(id)
+		}
+		mock.ExpectQuery(getProfilePhotoIDsQueryMock).WithArgs("profile_id_1").WillReturnRows(rows)
+	}
+
+	mock.ExpectBegin()
+	expectLockPhotoIDs("photo_id_1", "photo_id_2")
+	updateProfilePhotoPositionQueryMock := "UPDATE profile_photos SET position = \\$3 WHERE profile_id = \\$1 AND id = \\$2"
+	mock.ExpectExec(updateProfilePhotoPositionQueryMock).WithArgs("profile_id_1", "photo_id_2", 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(updateProfilePhotoPositionQueryMock).WithArgs("profile_id_1", "photo_id_1", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(syncProfilePhotosQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	reordered, err := repo.ReorderProfilePhotos(context.Background(), "profile_id_1", []string{"photo_id_2", "photo_id_1"})
+	assert.NoError(t, err)
+	assert.True(t, reordered)
+
+	// photo added while the list was read
+	mock.ExpectBegin()
+	expectLockPhotoIDs("photo_id_1", "photo_id_2", "photo_id_3")
+	mock.ExpectCommit()
+
+	reordered, err = repo.ReorderProfilePhotos(context.Background(), "profile_id_1", []string{"photo_id_2", "photo_id_1"})
+	assert.NoError(t, err)
+	assert.False(t, reordered)
+
+	// photo deleted and replaced while the list was read
+	mock.ExpectBegin()
+	expectLockPhotoIDs("photo_id_1", "photo_id_3")
+	mock.ExpectCommit()
+
+	reordered, err = repo.ReorderProfilePhotos(context.Background(), "profile_id_1", []string{"photo_id_2", "photo_id_1"})
+	assert.NoError(t, err)
+	assert.False(t, reordered)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetPrimaryProfilePhoto(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	clearPrimaryProfilePhotoQueryMock := "UPDATE profile_photos SET is_primary = false WHERE profile_id = \\$1 AND is_primary AND id <> \\$2"
+	setPrimaryProfilePhotoQueryMock := "UPDATE profile_photos SET is_primary = true WHERE profile_id = \\$1 AND id = \\$2"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(clearPrimaryProfilePhotoQueryMock).WithArgs("profile_id_1", "photo_id_2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(setPrimaryProfilePhotoQueryMock).WithArgs("profile_id_1", "photo_id_2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(syncProfilePhotosQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	updated, err := repo.SetPrimaryProfilePhoto(context.Background(), "profile_id_1", "photo_id_2")
+	assert.NoError(t, err)
+	assert.True(t, updated)
+
+	// unknown photo leave the primary untouched
+	mock.ExpectBegin()
+	mock.ExpectExec(clearPrimaryProfilePhotoQueryMock).WithArgs("profile_id_1", "photo_id_3").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(setPrimaryProfilePhotoQueryMock).WithArgs("profile_id_1", "photo_id_3").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	updated, err = repo.SetPrimaryProfilePhoto(context.Background(), "profile_id_1", "photo_id_3")
+	assert.NoError(t, err)
+	assert.False(t, updated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
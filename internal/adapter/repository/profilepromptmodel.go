@@ -0,0 +1,13 @@
+package repository
+
+import "time"
+
+// ProfilePrompt question of the prompt catalogue, inactive question is kept
+// for answers already given
+type ProfilePrompt struct {
+	ID        string    `db:"id"`
+	Question  string    `db:"question"`
+	IsActive  bool      `db:"is_active"`
+	Position  int       `db:"position"`
+	CreatedAt time.Time `db:"created_at"`
+}
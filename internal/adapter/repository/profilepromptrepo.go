@@ -0,0 +1,21 @@
+package repository
+
+import "context"
+
+const getActiveProfilePromptsQuery = `SELECT id, question, is_active, position, created_at FROM profile_prompts WHERE is_active = true ORDER BY position, id`
+
+func (r *repo) GetActiveProfilePrompts(
+	ctx context.Context,
+) ([]*ProfilePrompt, error) {
+	var data []*ProfilePrompt
+	err := r.conn.SelectContext(
+		ctx,
+		&data,
+		getActiveProfilePromptsQuery,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetActiveProfilePrompts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := NewUserRepo(dbx)
+
+	getActiveProfilePromptsQueryMock := "SELECT id, question, is_active, position, created_at FROM profile_prompts WHERE is_active = true ORDER BY position, id"
+	mock.ExpectQuery(getActiveProfilePromptsQueryMock).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "question", "is_active", "position", "created_at"}).
+			AddRow("typical-sunday", "A typical Sunday", true, 1, time.Now()).
+			AddRow("simple-pleasures", "My simple pleasures", true, 2, time.Now()))
+
+	ctx := context.Background()
+	prompts, err := repo.GetActiveProfilePrompts(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, prompts, 2)
+	assert.Equal(t, "typical-sunday", prompts[0].ID)
+	assert.Equal(t, "A typical Sunday", prompts[0].Question)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
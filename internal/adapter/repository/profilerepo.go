@@ -30,7 +30,9 @@ func (r *repo) CreateProfile(
 		Photos:              sql.NullString{},
 		Hobby:               sql.NullString{},
 		Interest:            sql.NullString{},
-		Location:            sql.NullString{},
+		Latitude:            sql.NullFloat64{},
+		Longitude:           sql.NullFloat64{},
+		Geohash:             sql.NullString{},
 		IsPremium:           false,
 		IsPremiumValidUntil: sql.NullTime{},
 		DailySwapQuota:      10,
@@ -39,7 +41,7 @@ func (r *repo) CreateProfile(
 	}, nil
 }
 
-const getProfileByUserIDQuery = `SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE user_id = $1 LIMIT 1`
+const getProfileByUserIDQuery = `SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, latitude, longitude, geohash, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at, bio, job_title, company, education, height_cm, languages, prompts, gender_custom, orientation, show_me, is_verified FROM profiles WHERE user_id = $1 LIMIT 1`
 
 func (r *repo) GetProfileByUserID(
 	ctx context.Context,
@@ -63,7 +65,7 @@ func (r *repo) GetProfileByUserID(
 	return &data, nil
 }
 
-const updateBasicInfoProfileQuery = `UPDATE profiles SET name = $2, birth_date = $3, gender = $4 WHERE id = $1`
+const updateBasicInfoProfileQuery = `UPDATE profiles SET name = $2, birth_date = $3, gender = $4, gender_custom = $5, orientation = $6, show_me = $7, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
 
 func (r *repo) UpdateBasicInfoProfile(
 	ctx context.Context,
@@ -80,15 +82,15 @@ func (r *repo) UpdateBasicInfoProfile(
 	return nil
 }
 
-const updatePhotosProfileQuery = `UPDATE profiles SET photos = $2 WHERE id = $1`
+const updateHobbyAndInterestProfileQuery = `UPDATE profiles SET hobby = $2, interest = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
 
-func (r *repo) UpdatePhotosProfile(
+func (r *repo) UpdateHobbyAndInterestProfile(
 	ctx context.Context,
-	req *UpdatePhotos,
+	req *UpdateHobbyAndInterest,
 ) error {
 	if _, err := r.conn.ExecContext(
 		ctx,
-		updatePhotosProfileQuery,
+		updateHobbyAndInterestProfileQuery,
 		req.RowData()...,
 	); err != nil {
 		return err
@@ -97,15 +99,15 @@ func (r *repo) UpdatePhotosProfile(
 	return nil
 }
 
-const updateHobbyAndInterestProfileQuery = `UPDATE profiles SET hobby = $2, interest = $3 WHERE id = $1`
+const updateLocationProfileQuery = `UPDATE profiles SET latitude = $2, longitude = $3, geohash = $4, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
 
-func (r *repo) UpdateHobbyAndInterestProfile(
+func (r *repo) UpdateLocationProfile(
 	ctx context.Context,
-	req *UpdateHobbyAndInterest,
+	req *UpdateLocation,
 ) error {
 	if _, err := r.conn.ExecContext(
 		ctx,
-		updateHobbyAndInterestProfileQuery,
+		updateLocationProfileQuery,
 		req.RowData()...,
 	); err != nil {
 		return err
@@ -114,24 +116,31 @@ func (r *repo) UpdateHobbyAndInterestProfile(
 	return nil
 }
 
-const updateLocationProfileQuery = `UPDATE profiles SET location = $2 WHERE id = $1`
+const patchProfileQuery = `UPDATE profiles SET name = COALESCE($2, name), birth_date = COALESCE($3, birth_date), gender = COALESCE($4, gender), hobby = COALESCE($5, hobby), interest = COALESCE($6, interest), bio = COALESCE($7, bio), job_title = COALESCE($8, job_title), company = COALESCE($9, company), education = COALESCE($10, education), height_cm = COALESCE($11, height_cm), languages = COALESCE($12, languages), prompts = COALESCE($13, prompts), gender_custom = COALESCE($14, gender_custom), orientation = COALESCE($15, orientation), show_me = COALESCE($16, show_me), updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND updated_at IS NOT DISTINCT FROM $17`
 
-func (r *repo) UpdateLocationProfile(
+// PatchProfile return false when the profile was modified after UpdatedAt
+func (r *repo) PatchProfile(
 	ctx context.Context,
-	req *UpdateLocation,
-) error {
-	if _, err := r.conn.ExecContext(
+	req *PatchProfile,
+) (bool, error) {
+	result, err := r.conn.ExecContext(
 		ctx,
-		updateLocationProfileQuery,
+		patchProfileQuery,
 		req.RowData()...,
-	); err != nil {
-		return err
+	)
+	if err != nil {
+		return false, err
 	}
 
-	return nil
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
 }
 
-const updatePremiumStatusProfileQuery = `UPDATE profiles SET is_premium = $2, is_premium_valid_until = $3, daily_swap_quota = $4 WHERE id = $1`
+const updatePremiumStatusProfileQuery = `UPDATE profiles SET is_premium = $2, is_premium_valid_until = $3, daily_swap_quota = $4, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
 
 func (r *repo) UpdatePremiumStatusProfile(
 	ctx context.Context,
@@ -55,7 +55,8 @@ func TestGetProfileByUserID(t *testing.T) {
 		Photos:              sql.NullString{String: "photo1.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "swimming", Valid: true},
 		Interest:            sql.NullString{String: "cooking", Valid: true},
-		Location:            sql.NullString{String: "San Francisco", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: 37.7749, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: -122.4194, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -63,9 +64,9 @@ func TestGetProfileByUserID(t *testing.T) {
 		UpdatedAt:           sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	getProfileByUserIDQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE user_id = \\$1 LIMIT 1"
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "location", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
-		AddRow(expectedProfile.ID, expectedProfile.UserID, expectedProfile.Name, expectedProfile.BirthDate, expectedProfile.Gender, expectedProfile.Photos, expectedProfile.Hobby, expectedProfile.Interest, expectedProfile.Location, expectedProfile.IsPremium, expectedProfile.IsPremiumValidUntil, expectedProfile.DailySwapQuota, expectedProfile.CreatedAt, expectedProfile.UpdatedAt)
+	getProfileByUserIDQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, latitude, longitude, geohash, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at, bio, job_title, company, education, height_cm, languages, prompts, gender_custom, orientation, show_me, is_verified FROM profiles WHERE user_id = \\$1 LIMIT 1"
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "latitude", "longitude", "geohash", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
+		AddRow(expectedProfile.ID, expectedProfile.UserID, expectedProfile.Name, expectedProfile.BirthDate, expectedProfile.Gender, expectedProfile.Photos, expectedProfile.Hobby, expectedProfile.Interest, expectedProfile.Latitude, expectedProfile.Longitude, expectedProfile.Geohash, expectedProfile.IsPremium, expectedProfile.IsPremiumValidUntil, expectedProfile.DailySwapQuota, expectedProfile.CreatedAt, expectedProfile.UpdatedAt)
 	mock.ExpectQuery(getProfileByUserIDQueryMock).WithArgs(UserID).WillReturnRows(rows)
 
 	// Call the GetProfileByUserID function
@@ -91,9 +92,9 @@ func TestUpdateBasicInfoProfile(t *testing.T) {
 	newGender := "Male"
 
 	// Set up the expected query and result for UpdateBasicInfoProfile
-	updateBasicInfoProfileQueryMock := "UPDATE profiles SET name = \\$2, birth_date = \\$3, gender = \\$4 WHERE id = \\$1"
+	updateBasicInfoProfileQueryMock := "UPDATE profiles SET name = \\$2, birth_date = \\$3, gender = \\$4, gender_custom = \\$5, orientation = \\$6, show_me = \\$7, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
 	mock.ExpectExec(updateBasicInfoProfileQueryMock).
-		WithArgs(profileID, newName, newBirthDate, newGender).
+		WithArgs(profileID, newName, newBirthDate, newGender, nil, nil, nil).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Call the UpdateBasicInfoProfile function
@@ -108,34 +109,6 @@ func TestUpdateBasicInfoProfile(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-func TestUpdatePhotosProfile(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	assert.NoError(t, err)
-	defer db.Close()
-
-	dbx := sqlx.NewDb(db, "postgres")
-	repo := NewUserRepo(dbx)
-
-	// Set up the input data for updating photos
-	profileID := "profile_id_1"
-	newPhotos := "photo1.jpg,photo2.jpg,photo3.jpg"
-
-	// Set up the expected query and result for UpdatePhotosProfile
-	updatePhotosProfileQueryMock := "UPDATE profiles SET photos = \\$2 WHERE id = \\$1"
-	mock.ExpectExec(updatePhotosProfileQueryMock).
-		WithArgs(profileID, newPhotos).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	// Call the UpdatePhotosProfile function
-	ctx := context.Background()
-	req := &UpdatePhotos{
-		ID:     profileID,
-		Photos: newPhotos,
-	}
-	err = repo.UpdatePhotosProfile(ctx, req)
-	assert.NoError(t, err)
-}
-
 func TestUpdateHobbyAndInterestProfile(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -150,7 +123,7 @@ func TestUpdateHobbyAndInterestProfile(t *testing.T) {
 	newInterest := "cooking"
 
 	// Set up the expected query and result for UpdateHobbyAndInterestProfile
-	updateHobbyAndInterestProfileQueryMock := "UPDATE profiles SET hobby = \\$2, interest = \\$3 WHERE id = \\$1"
+	updateHobbyAndInterestProfileQueryMock := "UPDATE profiles SET hobby = \\$2, interest = \\$3, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
 	mock.ExpectExec(updateHobbyAndInterestProfileQueryMock).
 		WithArgs(profileID, newHobby, newInterest).
 		WillReturnResult(sqlmock.NewResult(1, 1))
@@ -176,19 +149,21 @@ func TestUpdateLocationProfile(t *testing.T) {
 
 	// Set up the input data for updating location
 	profileID := "profile_id_1"
-	newLocation := "New York"
+	latitude, longitude, hash := 40.7128, -74.006, "dr5regw3p"
 
 	// Set up the expected query and result for UpdateLocationProfile
-	updateLocationProfileQueryMock := "UPDATE profiles SET location = \\$2 WHERE id = \\$1"
+	updateLocationProfileQueryMock := "UPDATE profiles SET latitude = \\$2, longitude = \\$3, geohash = \\$4, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
 	mock.ExpectExec(updateLocationProfileQueryMock).
-		WithArgs(profileID, newLocation).
+		WithArgs(profileID, latitude, longitude, hash).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Call the UpdateLocationProfile function
 	ctx := context.Background()
 	req := &UpdateLocation{
-		ID:       profileID,
-		Location: newLocation,
+		ID:        profileID,
+		Latitude:  latitude,
+		Longitude: longitude,
+		Geohash:   hash,
 	}
 	err = repo.UpdateLocationProfile(ctx, req)
 	assert.NoError(t, err)
@@ -209,7 +184,7 @@ func TestUpdatePremiumStatusProfile(t *testing.T) {
 	dailySwapQuota := 20
 
 	// Set up the expected query and result for UpdatePremiumStatusProfile
-	updatePremiumStatusProfileQueryMock := "UPDATE profiles SET is_premium = \\$2, is_premium_valid_until = \\$3, daily_swap_quota = \\$4 WHERE id = \\$1"
+	updatePremiumStatusProfileQueryMock := "UPDATE profiles SET is_premium = \\$2, is_premium_valid_until = \\$3, daily_swap_quota = \\$4, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
 	mock.ExpectExec(updatePremiumStatusProfileQueryMock).
 		WithArgs(profileID, isPremium, premiumValidUntil, dailySwapQuota).
 		WillReturnResult(sqlmock.NewResult(1, 1))
@@ -225,3 +200,42 @@ func TestUpdatePremiumStatusProfile(t *testing.T) {
 	err = repo.UpdatePremiumStatusProfile(ctx, req)
 	assert.NoError(t, err)
 }
+
+func TestPatchProfile(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := NewUserRepo(dbx)
+
+	profileID := "profile_id_1"
+	version := sql.NullTime{Time: time.Now(), Valid: true}
+	patchProfileQueryMock := "UPDATE profiles SET name = COALESCE\\(\\$2, name\\), birth_date = COALESCE\\(\\$3, birth_date\\), gender = COALESCE\\(\\$4, gender\\), hobby = COALESCE\\(\\$5, hobby\\), interest = COALESCE\\(\\$6, interest\\), bio = COALESCE\\(\\$7, bio\\), job_title = COALESCE\\(\\$8, job_title\\), company = COALESCE\\(\\$9, company\\), education = COALESCE\\(\\$10, education\\), height_cm = COALESCE\\(\\$11, height_cm\\), languages = COALESCE\\(\\$12, languages\\), prompts = COALESCE\\(\\$13, prompts\\), gender_custom = COALESCE\\(\\$14, gender_custom\\), orientation = COALESCE\\(\\$15, orientation\\), show_me = COALESCE\\(\\$16, show_me\\), updated_at = CURRENT_TIMESTAMP WHERE id = \\$1 AND updated_at IS NOT DISTINCT FROM \\$17"
+
+	req := &PatchProfile{
+		ID:        profileID,
+		Name:      sql.NullString{String: "Jane", Valid: true},
+		UpdatedAt: version,
+	}
+
+	// version still match
+	mock.ExpectExec(patchProfileQueryMock).
+		WithArgs(profileID, req.Name, req.BirthDate, req.Gender, req.Hobby, req.Interest, req.Bio, req.JobTitle, req.Company, req.Education, req.HeightCm, req.Languages, req.Prompts, req.GenderCustom, req.Orientation, req.ShowMe, version).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := context.Background()
+	ok, err := repo.PatchProfile(ctx, req)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// profile was modified by another request
+	mock.ExpectExec(patchProfileQueryMock).
+		WithArgs(profileID, req.Name, req.BirthDate, req.Gender, req.Hobby, req.Interest, req.Bio, req.JobTitle, req.Company, req.Education, req.HeightCm, req.Languages, req.Prompts, req.GenderCustom, req.Orientation, req.ShowMe, version).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err = repo.PatchProfile(ctx, req)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
+
+// ProfileVerification selfie compared to the profile photos, Similarity and
+// PoseMatched are null when the face matcher was not available
+type ProfileVerification struct {
+	ID          string                      `db:"id"`
+	ProfileID   string                      `db:"profile_id"`
+	Pose        string                      `db:"pose"`
+	SelfieKey   string                      `db:"selfie_key"`
+	Similarity  sql.NullFloat64             `db:"similarity"`
+	PoseMatched sql.NullBool                `db:"pose_matched"`
+	Status      constant.VerificationStatus `db:"status"`
+	ReviewerID  sql.NullString              `db:"reviewer_id"`
+	CreatedAt   time.Time                   `db:"created_at"`
+	ReviewedAt  sql.NullTime                `db:"reviewed_at"`
+}
+
+type CreateProfileVerification struct {
+	ProfileID   string                      `db:"profile_id"`
+	Pose        string                      `db:"pose"`
+	SelfieKey   string                      `db:"selfie_key"`
+	Similarity  sql.NullFloat64             `db:"similarity"`
+	PoseMatched sql.NullBool                `db:"pose_matched"`
+	Status      constant.VerificationStatus `db:"status"`
+}
+
+func (v *CreateProfileVerification) RowData() []interface{} {
+	var data = []interface{}{
+		v.ProfileID,
+		v.Pose,
+		v.SelfieKey,
+		v.Similarity,
+		v.PoseMatched,
+		v.Status,
+	}
+	return data
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
+
+const profileVerificationColumns = `id, profile_id, pose, selfie_key, similarity, pose_matched, status, reviewer_id, created_at, reviewed_at`
+
+// verifyProfileQuery updated_at is left untouched, the badge is not an edit
+// of the profile and must not fail a patch in progress
+const verifyProfileQuery = `UPDATE profiles SET is_verified = true WHERE id = $1`
+
+const createProfileVerificationQuery = `INSERT INTO profile_verifications (profile_id, pose, selfie_key, similarity, pose_matched, status, created_at, reviewed_at) VALUES ($1, $2, $3, $4, $5, $6::VARCHAR, CURRENT_TIMESTAMP, CASE WHEN $6::VARCHAR = 'PENDING' THEN NULL ELSE CURRENT_TIMESTAMP END) RETURNING id`
+
+// CreateProfileVerification approved verification give the profile its badge
+func (r *repo) CreateProfileVerification(
+	ctx context.Context,
+	req *CreateProfileVerification,
+) (id string, err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer txAction(tx, &err)
+
+	if err = tx.GetContext(ctx, &id, createProfileVerificationQuery, req.RowData()...); err != nil {
+		return "", err
+	}
+
+	if req.Status == constant.VERIFICATION_STATUS_APPROVED {
+		if _, err = tx.ExecContext(ctx, verifyProfileQuery, req.ProfileID); err != nil {
+			return "", err
+		}
+	}
+
+	return id, nil
+}
+
+const getLatestProfileVerificationQuery = `SELECT ` + profileVerificationColumns + ` FROM profile_verifications WHERE profile_id = $1 ORDER BY created_at DESC LIMIT 1`
+
+func (r *repo) GetLatestProfileVerification(
+	ctx context.Context,
+	profileID string,
+) (*ProfileVerification, error) {
+	var data ProfileVerification
+	err := r.conn.GetContext(
+		ctx,
+		&data,
+		getLatestProfileVerificationQuery,
+		profileID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+const getProfileVerificationsQuery = `SELECT ` + profileVerificationColumns + ` FROM profile_verifications WHERE profile_id = $1 ORDER BY created_at`
+
+func (r *repo) GetProfileVerifications(
+	ctx context.Context,
+	profileID string,
+) ([]*ProfileVerification, error) {
+	var data []*ProfileVerification
+	if err := r.conn.SelectContext(
+		ctx,
+		&data,
+		getProfileVerificationsQuery,
+		profileID,
+	); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// getPendingProfileVerificationsQuery review queue, oldest first
+const getPendingProfileVerificationsQuery = `SELECT ` + profileVerificationColumns + ` FROM profile_verifications WHERE status = 'PENDING' ORDER BY created_at LIMIT $1`
+
+func (r *repo) GetPendingProfileVerifications(
+	ctx context.Context,
+	limit int,
+) ([]*ProfileVerification, error) {
+	var data []*ProfileVerification
+	if err := r.conn.SelectContext(
+		ctx,
+		&data,
+		getPendingProfileVerificationsQuery,
+		limit,
+	); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+const reviewProfileVerificationQuery = `UPDATE profile_verifications SET status = $2, reviewer_id = $3, reviewed_at = CURRENT_TIMESTAMP WHERE id = $1 AND status = 'PENDING' RETURNING profile_id`
+
+// ReviewProfileVerification return false when the verification is not
+// pending anymore, e.g. reviewed by another admin
+func (r *repo) ReviewProfileVerification(
+	ctx context.Context,
+	id string,
+	status constant.VerificationStatus,
+	reviewerID string,
+) (reviewed bool, err error) {
+	tx, err := r.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer txAction(tx, &err)
+
+	var profileID string
+	if err = tx.GetContext(ctx, &profileID, reviewProfileVerificationQuery, id, status, reviewerID); err != nil {
+		if err == sql.ErrNoRows {
+			err = nil
+			return false, nil
+		}
+		return false, err
+	}
+
+	if status == constant.VERIFICATION_STATUS_APPROVED {
+		if _, err = tx.ExecContext(ctx, verifyProfileQuery, profileID); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
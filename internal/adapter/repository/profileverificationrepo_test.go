@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/pkg/constant"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var profileVerificationColumnsMock = []string{"id", "profile_id", "pose", "selfie_key", "similarity", "pose_matched", "status", "reviewer_id", "created_at", "reviewed_at"}
+
+const (
+	createProfileVerificationQueryMock = "INSERT INTO profile_verifications \\(profile_id, pose, selfie_key, similarity, pose_matched, status, created_at, reviewed_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6::VARCHAR, CURRENT_TIMESTAMP, CASE WHEN \\$6::VARCHAR = 'PENDING' THEN NULL ELSE CURRENT_TIMESTAMP END\\) RETURNING id"
+	reviewProfileVerificationQueryMock = "UPDATE profile_verifications SET status = \\$2, reviewer_id = \\$3, reviewed_at = CURRENT_TIMESTAMP WHERE id = \\$1 AND status = 'PENDING' RETURNING profile_id"
+	verifyProfileQueryMock             = "UPDATE profiles SET is_verified = true WHERE id = \\$1"
+)
+
+func TestCreateProfileVerification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	ctx := context.Background()
+
+	// approved verification give the badge in the same transaction
+	req := &CreateProfileVerification{
+		ProfileID:   "profile_id_1",
+		Pose:        "wave",
+		SelfieKey:   "verifications/profile_id_1/a.jpg",
+		Similarity:  sql.NullFloat64{Float64: 0.95, Valid: true},
+		PoseMatched: sql.NullBool{Bool: true, Valid: true},
+		Status:      constant.VERIFICATION_STATUS_APPROVED,
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery(createProfileVerificationQueryMock).
+		WithArgs(req.ProfileID, req.Pose, req.SelfieKey, req.Similarity, req.PoseMatched, req.Status).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("verification_id_1"))
+	mock.ExpectExec(verifyProfileQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	id, err := repo.CreateProfileVerification(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "verification_id_1", id)
+
+	// pending verification leave the profile untouched
+	req.Status = constant.VERIFICATION_STATUS_PENDING
+	mock.ExpectBegin()
+	mock.ExpectQuery(createProfileVerificationQueryMock).
+		WithArgs(req.ProfileID, req.Pose, req.SelfieKey, req.Similarity, req.PoseMatched, req.Status).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("verification_id_2"))
+	mock.ExpectCommit()
+
+	id, err = repo.CreateProfileVerification(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "verification_id_2", id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLatestProfileVerification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	ctx := context.Background()
+
+	getLatestProfileVerificationQueryMock := "SELECT id, profile_id, pose, selfie_key, similarity, pose_matched, status, reviewer_id, created_at, reviewed_at FROM profile_verifications WHERE profile_id = \\$1 ORDER BY created_at DESC LIMIT 1"
+	mock.ExpectQuery(getLatestProfileVerificationQueryMock).WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows(profileVerificationColumnsMock).
+			AddRow("verification_id_1", "profile_id_1", "wave", "verifications/profile_id_1/a.jpg", nil, nil, "PENDING", nil, time.Now(), nil))
+	mock.ExpectQuery(getLatestProfileVerificationQueryMock).WithArgs("profile_id_2").
+		WillReturnRows(sqlmock.NewRows(profileVerificationColumnsMock))
+
+	data, err := repo.GetLatestProfileVerification(ctx, "profile_id_1")
+	assert.NoError(t, err)
+	assert.Equal(t, constant.VERIFICATION_STATUS_PENDING, data.Status)
+	assert.False(t, data.Similarity.Valid)
+
+	// profile never verified
+	data, err = repo.GetLatestProfileVerification(ctx, "profile_id_2")
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReviewProfileVerification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(reviewProfileVerificationQueryMock).
+		WithArgs("verification_id_1", constant.VERIFICATION_STATUS_APPROVED, "admin_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"profile_id"}).AddRow("profile_id_1"))
+	mock.ExpectExec(verifyProfileQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	reviewed, err := repo.ReviewProfileVerification(ctx, "verification_id_1", constant.VERIFICATION_STATUS_APPROVED, "admin_id_1")
+	assert.NoError(t, err)
+	assert.True(t, reviewed)
+
+	// not pending anymore
+	mock.ExpectBegin()
+	mock.ExpectQuery(reviewProfileVerificationQueryMock).
+		WithArgs("verification_id_1", constant.VERIFICATION_STATUS_REJECTED, "admin_id_2").
+		WillReturnRows(sqlmock.NewRows([]string{"profile_id"}))
+	mock.ExpectCommit()
+
+	reviewed, err = repo.ReviewProfileVerification(ctx, "verification_id_1", constant.VERIFICATION_STATUS_REJECTED, "admin_id_2")
+	assert.NoError(t, err)
+	assert.False(t, reviewed)
+
+	// badge failure roll the review back
+	mock.ExpectBegin()
+	mock.ExpectQuery(reviewProfileVerificationQueryMock).
+		WithArgs("verification_id_2", constant.VERIFICATION_STATUS_APPROVED, "admin_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"profile_id"}).AddRow("profile_id_2"))
+	mock.ExpectExec(verifyProfileQueryMock).WithArgs("profile_id_2").
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	_, err = repo.ReviewProfileVerification(ctx, "verification_id_2", constant.VERIFICATION_STATUS_APPROVED, "admin_id_1")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -9,26 +9,60 @@ import (
 type UserRepository interface {
 	UserRepo
 	ProfileRepo
+	ProfilePromptRepo
+	ProfilePhotoRepo
+	ProfileVerificationRepo
 	OneTimePasswordRepo
+	LoginFailureRepo
+	EmailChangeRepo
+	OnboardingStepRepo
+	DiscoveryPreferenceRepo
 	SwipesRepo
 	PaymentRepo
 }
 
 type UserRepo interface {
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	GetUserByPhone(ctx context.Context, phone string) (*User, error)
 	GetUserById(ctx context.Context, UserID string) (*User, error)
 	CreateUser(ctx context.Context, req *CreateUser) (*User, error)
-	UpdateOnboardingStepsUser(ctx context.Context, req *UpdateOnboardingSteps) error
 	UpdateStatusUser(ctx context.Context, req *UpdateStatus) error
+	UpdatePhoneUser(ctx context.Context, req *UpdatePhone) error
+	DeactivateUser(ctx context.Context, UserID string) error
+	ScheduleDeleteUser(ctx context.Context, UserID string, deleteAt time.Time) error
+	ReactivateUser(ctx context.Context, UserID string) error
+	GetUserIDsScheduledForDeletion(ctx context.Context, before time.Time, limit int) ([]string, error)
+	DeleteUser(ctx context.Context, UserID string) error
+}
+
+type ProfilePromptRepo interface {
+	GetActiveProfilePrompts(ctx context.Context) ([]*ProfilePrompt, error)
+}
+
+type ProfilePhotoRepo interface {
+	GetProfilePhotos(ctx context.Context, profileID string) ([]*ProfilePhoto, error)
+	ReplaceProfilePhotos(ctx context.Context, profileID string, keys []string) error
+	AddProfilePhotos(ctx context.Context, profileID string, keys []string, maxPhotos int) (bool, error)
+	DeleteProfilePhoto(ctx context.Context, profileID, id string) (bool, error)
+	ReorderProfilePhotos(ctx context.Context, profileID string, ids []string) (bool, error)
+	SetPrimaryProfilePhoto(ctx context.Context, profileID, id string) (bool, error)
+}
+
+type ProfileVerificationRepo interface {
+	CreateProfileVerification(ctx context.Context, req *CreateProfileVerification) (string, error)
+	GetLatestProfileVerification(ctx context.Context, profileID string) (*ProfileVerification, error)
+	GetProfileVerifications(ctx context.Context, profileID string) ([]*ProfileVerification, error)
+	GetPendingProfileVerifications(ctx context.Context, limit int) ([]*ProfileVerification, error)
+	ReviewProfileVerification(ctx context.Context, id string, status constant.VerificationStatus, reviewerID string) (bool, error)
 }
 
 type ProfileRepo interface {
 	CreateProfile(ctx context.Context, UserID string) (*Profile, error)
 	GetProfileByUserID(ctx context.Context, UserID string) (*Profile, error)
 	UpdateBasicInfoProfile(ctx context.Context, req *UpdateProfileInfo) error
-	UpdatePhotosProfile(ctx context.Context, req *UpdatePhotos) error
 	UpdateHobbyAndInterestProfile(ctx context.Context, req *UpdateHobbyAndInterest) error
 	UpdateLocationProfile(ctx context.Context, req *UpdateLocation) error
+	PatchProfile(ctx context.Context, req *PatchProfile) (bool, error)
 	UpdatePremiumStatusProfile(ctx context.Context, req *UpdatePremiumStatus) error
 }
 
@@ -37,15 +71,51 @@ type OneTimePasswordRepo interface {
 	GetOneTimePasswordLogByUserAndType(ctx context.Context, UserID, otpType string) (*OneTimePasswordLog, error)
 	UpdateStatusOneTimePasswordLog(ctx context.Context, status constant.OneTimeLogStatus, id string, otpLimit int) error
 	GetCountOneTimePasswordByTime(ctx context.Context, UserID, otpType string, startDate, endDate time.Time) (int, error)
+	GetOneTimePasswordLogByCode(ctx context.Context, otpType, code string) (*OneTimePasswordLog, error)
+	UseOneTimePasswordLog(ctx context.Context, id string) (bool, error)
+	GetOneTimePasswordLogsByUserID(ctx context.Context, UserID string) ([]*OneTimePasswordLog, error)
+}
+
+type LoginFailureRepo interface {
+	GetLoginFailure(ctx context.Context, UserID string) (*LoginFailure, error)
+	RecordLoginFailure(ctx context.Context, UserID string) (*LoginFailure, error)
+	LockUser(ctx context.Context, req *LockUser) error
+	ReleaseLockUser(ctx context.Context, req *UpdateStatus) error
+	DeleteLoginFailure(ctx context.Context, UserID string) error
+}
+
+type EmailChangeRepo interface {
+	CreateEmailChange(ctx context.Context, req *CreateEmailChange) (string, error)
+	GetPendingEmailChange(ctx context.Context, UserID string) (*EmailChange, error)
+	GetEmailChangeByCancelToken(ctx context.Context, cancelToken string) (*EmailChange, error)
+	GetEmailChangesByUserID(ctx context.Context, UserID string) ([]*EmailChange, error)
+	ConfirmEmailChange(ctx context.Context, change *EmailChange) error
+	RevertEmailChange(ctx context.Context, change *EmailChange) error
+	CancelEmailChange(ctx context.Context, id string) error
+}
+
+type OnboardingStepRepo interface {
+	GetOnboardingStepsByUserID(ctx context.Context, UserID string) ([]*OnboardingStep, error)
+	CompleteOnboardingStep(ctx context.Context, UserID string, step constant.OnboardingStep) (bool, error)
+}
+
+type DiscoveryPreferenceRepo interface {
+	GetDiscoveryPreferences(ctx context.Context, UserID string) (*DiscoveryPreferences, error)
+	UpsertDiscoveryPreferences(ctx context.Context, req *DiscoveryPreferences) error
+	CreateDefaultDiscoveryPreferences(ctx context.Context, req *DiscoveryPreferences) error
 }
 
 type SwipesRepo interface {
 	GetProfileBySwiperId(ctx context.Context, swiperId string) ([]*Profile, error)
 	GetProfileBySwiperIdWithProfileId(ctx context.Context, swiperId, profileId string) ([]*Profile, error)
+	IsMutualInterest(ctx context.Context, swiperId, swipedId string) (bool, error)
 	CreateSwipes(ctx context.Context, req *Swipe) error
 	GetSwipesCount(ctx context.Context, swiperId string) (int, error)
+	GetSwipesBySwiperId(ctx context.Context, swiperId string) ([]*Swipe, error)
+	GetSwipesBySwipedId(ctx context.Context, swipedId string) ([]*Swipe, error)
 }
 
 type PaymentRepo interface {
 	CreatePayment(ctx context.Context, payment *Payment) error
+	GetPaymentsByUserID(ctx context.Context, UserID string) ([]*Payment, error)
 }
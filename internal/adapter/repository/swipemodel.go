@@ -1,11 +1,16 @@
 package repository
 
-import "database/sql"
+import (
+	"database/sql"
+	"time"
+)
 
 type Swipe struct {
-	SwiperId string       `db:"swiper_id"`
-	SwipedId string       `db:"swiped_id"`
-	IsLike   sql.NullBool `db:"is_like"`
+	ID        string       `db:"id"`
+	SwiperId  string       `db:"swiper_id"`
+	SwipedId  string       `db:"swiped_id"`
+	IsLike    sql.NullBool `db:"is_like"`
+	CreatedAt time.Time    `db:"created_at"`
 }
 
 func (s *Swipe) RowData() []interface{} {
@@ -16,3 +21,10 @@ func (s *Swipe) RowData() []interface{} {
 	}
 	return data
 }
+
+// FeedOrigin where the swiper is and how far they look for profiles
+type FeedOrigin struct {
+	Latitude      sql.NullFloat64 `db:"latitude"`
+	Longitude     sql.NullFloat64 `db:"longitude"`
+	MaxDistanceKm int             `db:"max_distance_km"`
+}
@@ -4,13 +4,21 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
+
+	"github.com/ijlik/dating-user/pkg/geohash"
 )
 
 func (r *repo) GetProfileBySwiperId(
 	ctx context.Context,
 	swiperId string,
 ) ([]*Profile, error) {
-	randomProfile1, err := r.getRandomProfile(ctx, swiperId)
+	cells, err := r.getFeedCells(ctx, swiperId)
+	if err != nil {
+		return nil, err
+	}
+
+	randomProfile1, err := r.getRandomProfile(ctx, swiperId, cells)
 	if err != nil {
 		return nil, err
 	}
@@ -18,7 +26,7 @@ func (r *repo) GetProfileBySwiperId(
 		return nil, errors.New("no more profile to show")
 	}
 
-	randomProfile2, err := r.getProfileWithoutId(ctx, swiperId, randomProfile1.ID)
+	randomProfile2, err := r.getProfileWithoutId(ctx, swiperId, cells, randomProfile1.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +51,12 @@ func (r *repo) GetProfileBySwiperIdWithProfileId(
 	swiperId,
 	profileId string,
 ) ([]*Profile, error) {
-	currentProfile, err := r.getProfileById(ctx, swiperId, profileId)
+	cells, err := r.getFeedCells(ctx, swiperId)
+	if err != nil {
+		return nil, err
+	}
+
+	currentProfile, err := r.getProfileById(ctx, swiperId, cells, profileId)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +64,7 @@ func (r *repo) GetProfileBySwiperIdWithProfileId(
 		return nil, errors.New("not found")
 	}
 
-	randomProfile, err := r.getProfileWithoutId(ctx, swiperId, currentProfile.ID)
+	randomProfile, err := r.getProfileWithoutId(ctx, swiperId, cells, currentProfile.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -73,11 +86,76 @@ func (r *repo) GetProfileBySwiperIdWithProfileId(
 	return result, nil
 }
 
-const getRandomProfileQuery = `SELECT id, user_id, name, birth_date, gender, photos, hobby,	interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND location <> '' AND id <> $1 AND id NOT IN (SELECT swiped_id FROM swipes WHERE swiper_id = $1 AND DATE(created_at) = CURRENT_DATE) ORDER BY RANDOM() LIMIT 1`
+// mutualInterestCondition gender of the candidate is on show me of the swiper
+// ($1) and the other way around, empty show me is everyone
+const mutualInterestCondition = `(COALESCE((SELECT me.show_me FROM profiles AS me WHERE me.id = $1), '') = '' OR gender = ANY(STRING_TO_ARRAY((SELECT me.show_me FROM profiles AS me WHERE me.id = $1), ','))) AND (COALESCE(show_me, '') = '' OR (SELECT me.gender FROM profiles AS me WHERE me.id = $1) = ANY(STRING_TO_ARRAY(show_me, ',')))`
 
-func (r *repo) getRandomProfile(
+const getFeedOriginQuery = `SELECT profiles.latitude, profiles.longitude, COALESCE(pref.max_distance_km, 0) AS max_distance_km FROM profiles LEFT JOIN discovery_preferences AS pref ON pref.user_id = profiles.user_id WHERE profiles.id = $1`
+
+// getFeedCells geohash prefixes, joined by comma, around the swiper covering
+// their maximum distance. Empty when the swiper look anywhere or has no
+// location yet
+func (r *repo) getFeedCells(
 	ctx context.Context,
 	swiperId string,
+) (string, error) {
+	var data FeedOrigin
+	err := r.conn.GetContext(
+		ctx,
+		&data,
+		getFeedOriginQuery,
+		swiperId,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	if !data.Latitude.Valid || !data.Longitude.Valid || data.MaxDistanceKm <= 0 {
+		return "", nil
+	}
+
+	var patterns []string
+	for _, cell := range geohash.Cover(data.Latitude.Float64, data.Longitude.Float64, float64(data.MaxDistanceKm)) {
+		patterns = append(patterns, cell+"%")
+	}
+	return strings.Join(patterns, ","), nil
+}
+
+// distanceKmExpression great-circle distance between the swiper (me) and the
+// candidate
+const distanceKmExpression = `6371 * 2 * ASIN(LEAST(1, SQRT(POWER(SIN(RADIANS(profiles.latitude - me.latitude) / 2), 2) + COS(RADIANS(me.latitude)) * COS(RADIANS(profiles.latitude)) * POWER(SIN(RADIANS(profiles.longitude - me.longitude) / 2), 2))))`
+
+// distanceKmSelect distance between the swiper ($1) and the candidate
+const distanceKmSelect = `(SELECT ` + distanceKmExpression + ` FROM profiles AS me WHERE me.id = $1)`
+
+// nearbyCondition candidate geohash is on one of the cells ($2) around the
+// swiper, it narrows the feed down through the geohash index before the exact
+// distance of discoveryPreferencesCondition
+const nearbyCondition = `($2 = '' OR geohash LIKE ANY(STRING_TO_ARRAY($2, ',')))`
+
+// discoveryPreferencesCondition candidate fit the age range, distance and
+// verified only of the swiper ($1) preferences, swiper without preferences see
+// everyone
+const discoveryPreferencesCondition = `NOT EXISTS (SELECT 1 FROM discovery_preferences AS pref JOIN profiles AS me ON me.user_id = pref.user_id WHERE me.id = $1 AND (DATE_PART('year', AGE(profiles.birth_date)) NOT BETWEEN pref.min_age AND pref.max_age OR (pref.max_distance_km > 0 AND ` + distanceKmExpression + ` > pref.max_distance_km) OR (pref.verified_only AND NOT profiles.is_verified)))`
+
+// feedCondition candidates the swiper ($1) can discover today, $2 is the
+// geohash cells of getFeedCells
+const feedCondition = `name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND latitude IS NOT NULL AND ` + nearbyCondition + ` AND ` + mutualInterestCondition + ` AND ` + discoveryPreferencesCondition + ` AND user_id NOT IN (SELECT id FROM users WHERE status = 'DEACTIVE') AND id <> $1 AND id NOT IN (SELECT swiped_id FROM swipes WHERE swiper_id = $1 AND DATE(created_at) = CURRENT_DATE)`
+
+// feedRankingOrder nearest profiles first, by bands of 5 km so the feed is not
+// always the same, random inside a band
+const feedRankingOrder = `FLOOR(` + distanceKmSelect + ` / 5) NULLS LAST, RANDOM()`
+
+const feedProfileColumns = `id, user_id, name, birth_date, gender, photos, hobby, interest, latitude, longitude, geohash, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at, bio, job_title, company, education, height_cm, languages, prompts, gender_custom, orientation, show_me, is_verified, ` + distanceKmSelect + ` AS distance_km`
+
+const getRandomProfileQuery = `SELECT ` + feedProfileColumns + ` FROM profiles WHERE ` + feedCondition + ` ORDER BY ` + feedRankingOrder + ` LIMIT 1`
+
+func (r *repo) getRandomProfile(
+	ctx context.Context,
+	swiperId,
+	cells string,
 ) (*Profile, error) {
 	var data Profile
 	err := r.conn.GetContext(
@@ -85,6 +163,7 @@ func (r *repo) getRandomProfile(
 		&data,
 		getRandomProfileQuery,
 		swiperId,
+		cells,
 	)
 
 	if err != nil {
@@ -97,11 +176,12 @@ func (r *repo) getRandomProfile(
 	return &data, nil
 }
 
-const getProfileByIdQuery = `SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND location <> '' AND id <> $1 AND id = $2 AND id NOT IN (SELECT swiped_id FROM swipes WHERE swiper_id = $1 AND DATE(created_at) = CURRENT_DATE) LIMIT 1`
+const getProfileByIdQuery = `SELECT ` + feedProfileColumns + ` FROM profiles WHERE ` + feedCondition + ` AND id = $3 LIMIT 1`
 
 func (r *repo) getProfileById(
 	ctx context.Context,
 	swiperId,
+	cells,
 	profileId string,
 ) (*Profile, error) {
 	var data Profile
@@ -110,6 +190,7 @@ func (r *repo) getProfileById(
 		&data,
 		getProfileByIdQuery,
 		swiperId,
+		cells,
 		profileId,
 	)
 
@@ -123,11 +204,12 @@ func (r *repo) getProfileById(
 	return &data, nil
 }
 
-const getProfileWithoutIdQuery = `SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND location <> '' AND id <> $1 AND id <> $2 AND id NOT IN (SELECT swiped_id FROM swipes WHERE swiper_id = $1 AND DATE(created_at) = CURRENT_DATE) LIMIT 1`
+const getProfileWithoutIdQuery = `SELECT ` + feedProfileColumns + ` FROM profiles WHERE ` + feedCondition + ` AND id <> $3 ORDER BY ` + feedRankingOrder + ` LIMIT 1`
 
 func (r *repo) getProfileWithoutId(
 	ctx context.Context,
 	swiperId,
+	cells,
 	profileId string,
 ) (*Profile, error) {
 	var data Profile
@@ -136,6 +218,7 @@ func (r *repo) getProfileWithoutId(
 		&data,
 		getProfileWithoutIdQuery,
 		swiperId,
+		cells,
 		profileId,
 	)
 
@@ -149,6 +232,29 @@ func (r *repo) getProfileWithoutId(
 	return &data, nil
 }
 
+const isMutualInterestQuery = `SELECT count(*) FROM profiles WHERE id = $2 AND ` + mutualInterestCondition
+
+// IsMutualInterest whether swiper and swiped are on each other show me
+func (r *repo) IsMutualInterest(
+	ctx context.Context,
+	swiperId,
+	swipedId string,
+) (bool, error) {
+	var count int
+	err := r.conn.GetContext(
+		ctx,
+		&count,
+		isMutualInterestQuery,
+		swiperId,
+		swipedId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
 const createSwipesQuery = `INSERT INTO swipes (swiper_id, swiped_id, is_like, created_at) VALUES ($1, $2, $3, CURRENT_TIMESTAMP)`
 
 const checkIfHasSwipeQuery = `SELECT count(*) FROM swipes WHERE swiper_id = $1 AND swiped_id = $2 AND is_like IN (true, false) AND DATE(created_at) = CURRENT_DATE`
@@ -221,3 +327,43 @@ func (r *repo) GetSwipesCount(
 
 	return count, nil
 }
+
+const getSwipesBySwiperIdQuery = `SELECT id, swiper_id, swiped_id, is_like, created_at FROM swipes WHERE swiper_id = $1 ORDER BY created_at`
+
+func (r *repo) GetSwipesBySwiperId(
+	ctx context.Context,
+	swiperId string,
+) ([]*Swipe, error) {
+	var data []*Swipe
+	err := r.conn.SelectContext(
+		ctx,
+		&data,
+		getSwipesBySwiperIdQuery,
+		swiperId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+const getSwipesBySwipedIdQuery = `SELECT id, swiper_id, swiped_id, is_like, created_at FROM swipes WHERE swiped_id = $1 ORDER BY created_at`
+
+func (r *repo) GetSwipesBySwipedId(
+	ctx context.Context,
+	swipedId string,
+) ([]*Swipe, error) {
+	var data []*Swipe
+	err := r.conn.SelectContext(
+		ctx,
+		&data,
+		getSwipesBySwipedIdQuery,
+		swipedId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
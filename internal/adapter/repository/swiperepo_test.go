@@ -4,12 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/pkg/geohash"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 	"time"
 )
 
+const (
+	getFeedOriginQueryMock       = "SELECT profiles\\.latitude, profiles\\.longitude, COALESCE\\(pref\\.max_distance_km, 0\\) AS max_distance_km FROM profiles LEFT JOIN discovery_preferences AS pref ON pref\\.user_id = profiles\\.user_id WHERE profiles\\.id = \\$1"
+	getRandomProfileQueryMock    = "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, latitude, longitude, geohash, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at, bio, job_title, company, education, height_cm, languages, prompts, gender_custom, orientation, show_me, is_verified, \\(SELECT 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) FROM profiles AS me WHERE me\\.id = \\$1\\) AS distance_km FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND latitude IS NOT NULL AND \\(\\$2 = '' OR geohash LIKE ANY\\(STRING_TO_ARRAY\\(\\$2, ','\\)\\)\\) AND \\(COALESCE\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ''\\) = '' OR gender = ANY\\(STRING_TO_ARRAY\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ','\\)\\)\\) AND \\(COALESCE\\(show_me, ''\\) = '' OR \\(SELECT me\\.gender FROM profiles AS me WHERE me\\.id = \\$1\\) = ANY\\(STRING_TO_ARRAY\\(show_me, ','\\)\\)\\) AND NOT EXISTS \\(SELECT 1 FROM discovery_preferences AS pref JOIN profiles AS me ON me\\.user_id = pref\\.user_id WHERE me\\.id = \\$1 AND \\(DATE_PART\\('year', AGE\\(profiles\\.birth_date\\)\\) NOT BETWEEN pref\\.min_age AND pref\\.max_age OR \\(pref\\.max_distance_km > 0 AND 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) > pref\\.max_distance_km\\) OR \\(pref\\.verified_only AND NOT profiles\\.is_verified\\)\\)\\) AND user_id NOT IN \\(SELECT id FROM users WHERE status = 'DEACTIVE'\\) AND id <> \\$1 AND id NOT IN \\(SELECT swiped_id FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE\\) ORDER BY FLOOR\\(\\(SELECT 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) FROM profiles AS me WHERE me\\.id = \\$1\\) / 5\\) NULLS LAST, RANDOM\\(\\) LIMIT 1"
+	getProfileByIdQueryMock      = "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, latitude, longitude, geohash, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at, bio, job_title, company, education, height_cm, languages, prompts, gender_custom, orientation, show_me, is_verified, \\(SELECT 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) FROM profiles AS me WHERE me\\.id = \\$1\\) AS distance_km FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND latitude IS NOT NULL AND \\(\\$2 = '' OR geohash LIKE ANY\\(STRING_TO_ARRAY\\(\\$2, ','\\)\\)\\) AND \\(COALESCE\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ''\\) = '' OR gender = ANY\\(STRING_TO_ARRAY\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ','\\)\\)\\) AND \\(COALESCE\\(show_me, ''\\) = '' OR \\(SELECT me\\.gender FROM profiles AS me WHERE me\\.id = \\$1\\) = ANY\\(STRING_TO_ARRAY\\(show_me, ','\\)\\)\\) AND NOT EXISTS \\(SELECT 1 FROM discovery_preferences AS pref JOIN profiles AS me ON me\\.user_id = pref\\.user_id WHERE me\\.id = \\$1 AND \\(DATE_PART\\('year', AGE\\(profiles\\.birth_date\\)\\) NOT BETWEEN pref\\.min_age AND pref\\.max_age OR \\(pref\\.max_distance_km > 0 AND 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) > pref\\.max_distance_km\\) OR \\(pref\\.verified_only AND NOT profiles\\.is_verified\\)\\)\\) AND user_id NOT IN \\(SELECT id FROM users WHERE status = 'DEACTIVE'\\) AND id <> \\$1 AND id NOT IN \\(SELECT swiped_id FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE\\) AND id = \\$3 LIMIT 1"
+	getProfileWithoutIdQueryMock = "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, latitude, longitude, geohash, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at, bio, job_title, company, education, height_cm, languages, prompts, gender_custom, orientation, show_me, is_verified, \\(SELECT 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) FROM profiles AS me WHERE me\\.id = \\$1\\) AS distance_km FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND latitude IS NOT NULL AND \\(\\$2 = '' OR geohash LIKE ANY\\(STRING_TO_ARRAY\\(\\$2, ','\\)\\)\\) AND \\(COALESCE\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ''\\) = '' OR gender = ANY\\(STRING_TO_ARRAY\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ','\\)\\)\\) AND \\(COALESCE\\(show_me, ''\\) = '' OR \\(SELECT me\\.gender FROM profiles AS me WHERE me\\.id = \\$1\\) = ANY\\(STRING_TO_ARRAY\\(show_me, ','\\)\\)\\) AND NOT EXISTS \\(SELECT 1 FROM discovery_preferences AS pref JOIN profiles AS me ON me\\.user_id = pref\\.user_id WHERE me\\.id = \\$1 AND \\(DATE_PART\\('year', AGE\\(profiles\\.birth_date\\)\\) NOT BETWEEN pref\\.min_age AND pref\\.max_age OR \\(pref\\.max_distance_km > 0 AND 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) > pref\\.max_distance_km\\) OR \\(pref\\.verified_only AND NOT profiles\\.is_verified\\)\\)\\) AND user_id NOT IN \\(SELECT id FROM users WHERE status = 'DEACTIVE'\\) AND id <> \\$1 AND id NOT IN \\(SELECT swiped_id FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE\\) AND id <> \\$3 ORDER BY FLOOR\\(\\(SELECT 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) FROM profiles AS me WHERE me\\.id = \\$1\\) / 5\\) NULLS LAST, RANDOM\\(\\) LIMIT 1"
+	isMutualInterestQueryMock    = "SELECT count\\(\\*\\) FROM profiles WHERE id = \\$2 AND \\(COALESCE\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ''\\) = '' OR gender = ANY\\(STRING_TO_ARRAY\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ','\\)\\)\\) AND \\(COALESCE\\(show_me, ''\\) = '' OR \\(SELECT me\\.gender FROM profiles AS me WHERE me\\.id = \\$1\\) = ANY\\(STRING_TO_ARRAY\\(show_me, ','\\)\\)\\)"
+)
+
 func TestGetProfileBySwiperId(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -29,7 +39,8 @@ func TestGetProfileBySwiperId(t *testing.T) {
 		Photos:              sql.NullString{String: "photo1.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "swimming", Valid: true},
 		Interest:            sql.NullString{String: "cooking", Valid: true},
-		Location:            sql.NullString{String: "San Francisco", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: 37.7749, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: -122.4194, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -45,7 +56,8 @@ func TestGetProfileBySwiperId(t *testing.T) {
 		Photos:              sql.NullString{String: "photo2.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "slot", Valid: true},
 		Interest:            sql.NullString{String: "money", Valid: true},
-		Location:            sql.NullString{String: "Los Angles", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: 34.0522, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: -118.2437, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -53,16 +65,17 @@ func TestGetProfileBySwiperId(t *testing.T) {
 		UpdatedAt:           sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	getRandomProfileQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND location <> '' AND id <> \\$1 AND id NOT IN \\(SELECT swiped_id FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE\\) ORDER BY RANDOM\\(\\) LIMIT 1"
+	// swiper without a maximum distance look anywhere
+	mock.ExpectQuery(getFeedOriginQueryMock).WithArgs(swiperId).
+		WillReturnRows(sqlmock.NewRows([]string{"latitude", "longitude", "max_distance_km"}).AddRow(37.7, -122.4, 0))
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "location", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
-		AddRow(randomProfile1.ID, "user_id_1", randomProfile1.Name, randomProfile1.BirthDate, randomProfile1.Gender, randomProfile1.Photos, randomProfile1.Hobby, randomProfile1.Interest, randomProfile1.Location, randomProfile1.IsPremium, randomProfile1.IsPremiumValidUntil, randomProfile1.DailySwapQuota, randomProfile1.CreatedAt, randomProfile1.UpdatedAt).
-		AddRow(randomProfile2.ID, "user_id_2", randomProfile2.Name, randomProfile2.BirthDate, randomProfile2.Gender, randomProfile2.Photos, randomProfile2.Hobby, randomProfile2.Interest, randomProfile2.Location, randomProfile2.IsPremium, randomProfile2.IsPremiumValidUntil, randomProfile2.DailySwapQuota, randomProfile2.CreatedAt, randomProfile2.UpdatedAt)
-	mock.ExpectQuery(getRandomProfileQueryMock).WithArgs(swiperId).WillReturnRows(rows)
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "latitude", "longitude", "geohash", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
+		AddRow(randomProfile1.ID, "user_id_1", randomProfile1.Name, randomProfile1.BirthDate, randomProfile1.Gender, randomProfile1.Photos, randomProfile1.Hobby, randomProfile1.Interest, randomProfile1.Latitude, randomProfile1.Longitude, randomProfile1.Geohash, randomProfile1.IsPremium, randomProfile1.IsPremiumValidUntil, randomProfile1.DailySwapQuota, randomProfile1.CreatedAt, randomProfile1.UpdatedAt).
+		AddRow(randomProfile2.ID, "user_id_2", randomProfile2.Name, randomProfile2.BirthDate, randomProfile2.Gender, randomProfile2.Photos, randomProfile2.Hobby, randomProfile2.Interest, randomProfile2.Latitude, randomProfile2.Longitude, randomProfile2.Geohash, randomProfile2.IsPremium, randomProfile2.IsPremiumValidUntil, randomProfile2.DailySwapQuota, randomProfile2.CreatedAt, randomProfile2.UpdatedAt)
+	mock.ExpectQuery(getRandomProfileQueryMock).WithArgs(swiperId, "").WillReturnRows(rows)
 
 	// Set up the expected query and result for getProfileWithoutId
-	getProfileWithoutIdQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND location <> '' AND id <> \\$1 AND id <> \\$2 AND id NOT IN \\(SELECT swiped_id FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE\\) LIMIT 1"
-	mock.ExpectQuery(getProfileWithoutIdQueryMock).WithArgs(swiperId, randomProfile1.ID).WillReturnRows(rows)
+	mock.ExpectQuery(getProfileWithoutIdQueryMock).WithArgs(swiperId, "", randomProfile1.ID).WillReturnRows(rows)
 
 	deleteSwipesShowOnlyQueryMock := "DELETE FROM swipes WHERE swiper_id = \\$1 AND swiped_id = \\$2 AND DATE\\(created_at\\) = CURRENT_DATE"
 	mock.ExpectExec(deleteSwipesShowOnlyQueryMock).WithArgs(swiperId, randomProfile1.ID).WillReturnResult(sqlmock.NewResult(1, 1))
@@ -98,7 +111,8 @@ func TestGetProfileBySwiperIdWithProfileId(t *testing.T) {
 		Photos:              sql.NullString{String: "photo1.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "swimming", Valid: true},
 		Interest:            sql.NullString{String: "cooking", Valid: true},
-		Location:            sql.NullString{String: "San Francisco", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: 37.7749, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: -122.4194, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -106,10 +120,13 @@ func TestGetProfileBySwiperIdWithProfileId(t *testing.T) {
 		UpdatedAt:           sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	getProfileByIdQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND location <> '' AND id <> \\$1 AND id = \\$2 AND id NOT IN \\(SELECT swiped_id FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE\\) LIMIT 1"
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "location", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
-		AddRow(currentProfile.ID, "user_id_1", currentProfile.Name, currentProfile.BirthDate, currentProfile.Gender, currentProfile.Photos, currentProfile.Hobby, currentProfile.Interest, currentProfile.Location, currentProfile.IsPremium, currentProfile.IsPremiumValidUntil, currentProfile.DailySwapQuota, currentProfile.CreatedAt, currentProfile.UpdatedAt)
-	mock.ExpectQuery(getProfileByIdQueryMock).WithArgs(swiperId, profileId).WillReturnRows(rows)
+	// swiper without location yet
+	mock.ExpectQuery(getFeedOriginQueryMock).WithArgs(swiperId).
+		WillReturnRows(sqlmock.NewRows([]string{"latitude", "longitude", "max_distance_km"}).AddRow(nil, nil, 25))
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "latitude", "longitude", "geohash", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
+		AddRow(currentProfile.ID, "user_id_1", currentProfile.Name, currentProfile.BirthDate, currentProfile.Gender, currentProfile.Photos, currentProfile.Hobby, currentProfile.Interest, currentProfile.Latitude, currentProfile.Longitude, currentProfile.Geohash, currentProfile.IsPremium, currentProfile.IsPremiumValidUntil, currentProfile.DailySwapQuota, currentProfile.CreatedAt, currentProfile.UpdatedAt)
+	mock.ExpectQuery(getProfileByIdQueryMock).WithArgs(swiperId, "", profileId).WillReturnRows(rows)
 
 	// Set up the expected query and result for getProfileWithoutId
 	randomProfile := &Profile{
@@ -121,7 +138,8 @@ func TestGetProfileBySwiperIdWithProfileId(t *testing.T) {
 		Photos:              sql.NullString{String: "photo2.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "slot", Valid: true},
 		Interest:            sql.NullString{String: "money", Valid: true},
-		Location:            sql.NullString{String: "Los Angles", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: 34.0522, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: -118.2437, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -129,10 +147,9 @@ func TestGetProfileBySwiperIdWithProfileId(t *testing.T) {
 		UpdatedAt:           sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	getProfileWithoutIdQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND location <> '' AND id <> \\$1 AND id <> \\$2 AND id NOT IN \\(SELECT swiped_id FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE\\) LIMIT 1"
-	rows = sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "location", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
-		AddRow(randomProfile.ID, "user_id_2", randomProfile.Name, randomProfile.BirthDate, randomProfile.Gender, randomProfile.Photos, randomProfile.Hobby, randomProfile.Interest, randomProfile.Location, randomProfile.IsPremium, randomProfile.IsPremiumValidUntil, randomProfile.DailySwapQuota, randomProfile.CreatedAt, randomProfile.UpdatedAt)
-	mock.ExpectQuery(getProfileWithoutIdQueryMock).WithArgs(swiperId, currentProfile.ID).WillReturnRows(rows)
+	rows = sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "latitude", "longitude", "geohash", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
+		AddRow(randomProfile.ID, "user_id_2", randomProfile.Name, randomProfile.BirthDate, randomProfile.Gender, randomProfile.Photos, randomProfile.Hobby, randomProfile.Interest, randomProfile.Latitude, randomProfile.Longitude, randomProfile.Geohash, randomProfile.IsPremium, randomProfile.IsPremiumValidUntil, randomProfile.DailySwapQuota, randomProfile.CreatedAt, randomProfile.UpdatedAt)
+	mock.ExpectQuery(getProfileWithoutIdQueryMock).WithArgs(swiperId, "", currentProfile.ID).WillReturnRows(rows)
 
 	deleteSwipesShowOnlyQueryMock := "DELETE FROM swipes WHERE swiper_id = \\$1 AND swiped_id = \\$2 AND DATE\\(created_at\\) = CURRENT_DATE"
 	mock.ExpectExec(deleteSwipesShowOnlyQueryMock).WithArgs(swiperId, currentProfile.ID).WillReturnResult(sqlmock.NewResult(1, 1))
@@ -148,6 +165,60 @@ func TestGetProfileBySwiperIdWithProfileId(t *testing.T) {
 	assert.Equal(t, randomProfile, profiles[1])
 }
 
+func TestGetFeedCells(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	r := &repo{conn: dbx}
+	ctx := context.Background()
+
+	mock.ExpectQuery(getFeedOriginQueryMock).WithArgs("swiper_1").
+		WillReturnRows(sqlmock.NewRows([]string{"latitude", "longitude", "max_distance_km"}).AddRow(-6.2088, 106.8456, 10))
+	cells, err := r.getFeedCells(ctx, "swiper_1")
+	assert.NoError(t, err)
+	patterns := strings.Split(cells, ",")
+	assert.Len(t, patterns, 9)
+	assert.Equal(t, geohash.Encode(-6.2088, 106.8456, 4)+"%", patterns[0])
+
+	// no preferences yet
+	mock.ExpectQuery(getFeedOriginQueryMock).WithArgs("swiper_2").
+		WillReturnError(sql.ErrNoRows)
+	cells, err = r.getFeedCells(ctx, "swiper_2")
+	assert.NoError(t, err)
+	assert.Empty(t, cells)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsMutualInterest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := NewUserRepo(dbx)
+
+	swiperID := "swiper_id_1"
+	swipedID := "swiped_id_1"
+	mock.ExpectQuery(isMutualInterestQueryMock).WithArgs(swiperID, swipedID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(isMutualInterestQueryMock).WithArgs(swiperID, swipedID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	ctx := context.Background()
+	mutual, err := repo.IsMutualInterest(ctx, swiperID, swipedID)
+	assert.NoError(t, err)
+	assert.True(t, mutual)
+
+	// one of them is not on the other show me
+	mutual, err = repo.IsMutualInterest(ctx, swiperID, swipedID)
+	assert.NoError(t, err)
+	assert.False(t, mutual)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestCreateSwipes(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -202,3 +273,51 @@ func TestGetSwipesCount(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, count, result)
 }
+
+func TestGetSwipesBySwiperId(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := NewUserRepo(dbx)
+
+	swiperId := "swiper_id_1"
+	createdAt := time.Now().UTC()
+
+	getSwipesBySwiperIdQueryMock := "SELECT id, swiper_id, swiped_id, is_like, created_at FROM swipes WHERE swiper_id = \\$1 ORDER BY created_at"
+	mock.ExpectQuery(getSwipesBySwiperIdQueryMock).
+		WithArgs(swiperId).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "swiper_id", "swiped_id", "is_like", "created_at"}).
+			AddRow("swipe_id_1", swiperId, "swiped_id_1", true, createdAt).
+			AddRow("swipe_id_2", swiperId, "swiped_id_2", false, createdAt))
+
+	ctx := context.Background()
+	swipes, err := repo.GetSwipesBySwiperId(ctx, swiperId)
+	assert.NoError(t, err)
+	assert.Len(t, swipes, 2)
+	assert.Equal(t, "swiped_id_1", swipes[0].SwipedId)
+	assert.True(t, swipes[0].IsLike.Bool)
+	assert.False(t, swipes[1].IsLike.Bool)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSwipesBySwipedId(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(sqlx.NewDb(db, "postgres"))
+
+	getSwipesBySwipedIdQueryMock := "SELECT id, swiper_id, swiped_id, is_like, created_at FROM swipes WHERE swiped_id = \\$1 ORDER BY created_at"
+	mock.ExpectQuery(getSwipesBySwipedIdQueryMock).
+		WithArgs("swiped_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "swiper_id", "swiped_id", "is_like", "created_at"}).
+			AddRow("swipe_id_1", "swiper_id_1", "swiped_id_1", true, time.Now().UTC()))
+
+	swipes, err := repo.GetSwipesBySwipedId(context.Background(), "swiped_id_1")
+	assert.NoError(t, err)
+	assert.Len(t, swipes, 1)
+	assert.Equal(t, "swiper_id_1", swipes[0].SwiperId)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
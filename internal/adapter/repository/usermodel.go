@@ -2,76 +2,64 @@ package repository
 
 import (
 	"database/sql"
-	"github.com/ijlik/dating-user/internal/business/domain"
 	"github.com/ijlik/dating-user/pkg/constant"
-	"strings"
 	"time"
 )
 
 type User struct {
-	ID              string              `db:"id"`
-	Phone           sql.NullString      `db:"phone"`
-	Email           string              `db:"email"`
-	Status          constant.UserStatus `db:"status"`
-	OnboardingSteps string              `db:"onboarding_steps"`
-	CreatedAt       time.Time           `db:"created_at"`
-	UpdatedAt       sql.NullTime        `db:"updated_at"`
+	ID        string              `db:"id"`
+	Phone     sql.NullString      `db:"phone"`
+	Email     sql.NullString      `db:"email"`
+	Status    constant.UserStatus `db:"status"`
+	CreatedAt time.Time           `db:"created_at"`
+	UpdatedAt sql.NullTime        `db:"updated_at"`
 }
 
-func (u *User) GetOnboardingSteps() []domain.OnboardingSteps {
-	parts := strings.Split(u.OnboardingSteps, ",")
-	var onboardingSteps []domain.OnboardingSteps
-
-	for _, part := range parts {
-		subParts := strings.Split(part, ":")
-		if len(subParts) == 2 {
-			step := strings.TrimSpace(subParts[0])
-			status := strings.TrimSpace(subParts[1])
-
-			onboardingSteps = append(onboardingSteps, domain.OnboardingSteps{
-				Step:   step,
-				Status: status,
-			})
-		}
+// VerifiedPhone phone is only verified once the owner confirmed an otp sent
+// to it, registration through phone channel keep the user unverified until
+// the first login
+func (u *User) VerifiedPhone() string {
+	if !u.Phone.Valid || u.Status == constant.USER_STATUS_UNVERIFIED {
+		return ""
 	}
-	return onboardingSteps
+	return u.Phone.String
 }
 
 type CreateUser struct {
-	Email           string `db:"email"`
-	OnboardingSteps string `db:"onboarding_steps"`
+	Email sql.NullString `db:"email"`
+	Phone sql.NullString `db:"phone"`
 }
 
 func (u *CreateUser) RowData() []interface{} {
 	var data = []interface{}{
 		u.Email,
-		u.OnboardingSteps,
+		u.Phone,
 	}
 	return data
 }
 
-type UpdateOnboardingSteps struct {
-	ID              string `db:"id"`
-	OnboardingSteps string `db:"onboarding_steps"`
+type UpdateStatus struct {
+	ID     string              `db:"id"`
+	Status constant.UserStatus `db:"status"`
 }
 
-func (u *UpdateOnboardingSteps) RowData() []interface{} {
+func (u *UpdateStatus) RowData() []interface{} {
 	var data = []interface{}{
 		u.ID,
-		u.OnboardingSteps,
+		u.Status,
 	}
 	return data
 }
 
-type UpdateStatus struct {
-	ID     string              `db:"id"`
-	Status constant.UserStatus `db:"status"`
+type UpdatePhone struct {
+	ID    string `db:"id"`
+	Phone string `db:"phone"`
 }
 
-func (u *UpdateStatus) RowData() []interface{} {
+func (u *UpdatePhone) RowData() []interface{} {
 	var data = []interface{}{
 		u.ID,
-		u.Status,
+		u.Phone,
 	}
 	return data
 }
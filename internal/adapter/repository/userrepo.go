@@ -6,7 +6,7 @@ import (
 	"github.com/ijlik/dating-user/pkg/constant"
 )
 
-const getUserByEmailQuery = `SELECT id, phone, email, status, onboarding_steps, created_at, updated_at FROM users WHERE email = $1 LIMIT 1`
+const getUserByEmailQuery = `SELECT id, phone, email, status, created_at, updated_at FROM users WHERE email = $1 LIMIT 1`
 
 func (r *repo) GetUserByEmail(
 	ctx context.Context,
@@ -30,7 +30,31 @@ func (r *repo) GetUserByEmail(
 	return &data, nil
 }
 
-const getUserByIdQuery = `SELECT id, phone, email, status, onboarding_steps, created_at, updated_at FROM users WHERE id = $1 LIMIT 1`
+const getUserByPhoneQuery = `SELECT id, phone, email, status, created_at, updated_at FROM users WHERE phone = $1 LIMIT 1`
+
+func (r *repo) GetUserByPhone(
+	ctx context.Context,
+	phone string,
+) (*User, error) {
+	var data User
+	err := r.conn.GetContext(
+		ctx,
+		&data,
+		getUserByPhoneQuery,
+		phone,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+const getUserByIdQuery = `SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = $1 LIMIT 1`
 
 func (r *repo) GetUserById(
 	ctx context.Context,
@@ -54,7 +78,7 @@ func (r *repo) GetUserById(
 	return &data, nil
 }
 
-const createUserQuery = `INSERT INTO users (email, onboarding_steps, created_at) VALUES ($1, $2, CURRENT_TIMESTAMP) RETURNING id`
+const createUserQuery = `INSERT INTO users (email, phone, created_at) VALUES ($1, $2, CURRENT_TIMESTAMP) RETURNING id`
 
 func (r *repo) CreateUser(
 	ctx context.Context,
@@ -70,23 +94,22 @@ func (r *repo) CreateUser(
 	}
 
 	return &User{
-		ID:              id,
-		Phone:           sql.NullString{},
-		Email:           req.Email,
-		Status:          constant.USER_STATUS_UNVERIFIED,
-		OnboardingSteps: req.OnboardingSteps,
+		ID:     id,
+		Phone:  req.Phone,
+		Email:  req.Email,
+		Status: constant.USER_STATUS_UNVERIFIED,
 	}, nil
 }
 
-const updateOnboardingStepsUserQuery = `UPDATE users SET onboarding_steps = $2 WHERE id = $1`
+const updateStatusUserQuery = `UPDATE users SET status = $2 WHERE id = $1`
 
-func (r *repo) UpdateOnboardingStepsUser(
+func (r *repo) UpdateStatusUser(
 	ctx context.Context,
-	req *UpdateOnboardingSteps,
+	req *UpdateStatus,
 ) error {
 	if _, err := r.conn.ExecContext(
 		ctx,
-		updateOnboardingStepsUserQuery,
+		updateStatusUserQuery,
 		req.RowData()...,
 	); err != nil {
 		return err
@@ -95,15 +118,15 @@ func (r *repo) UpdateOnboardingStepsUser(
 	return nil
 }
 
-const updateStatusUserQuery = `UPDATE users SET status = $2 WHERE id = $1`
+const updatePhoneUserQuery = `UPDATE users SET phone = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
 
-func (r *repo) UpdateStatusUser(
+func (r *repo) UpdatePhoneUser(
 	ctx context.Context,
-	req *UpdateStatus,
+	req *UpdatePhone,
 ) error {
 	if _, err := r.conn.ExecContext(
 		ctx,
-		updateStatusUserQuery,
+		updatePhoneUserQuery,
 		req.RowData()...,
 	); err != nil {
 		return err
@@ -22,18 +22,17 @@ func TestGetUserByEmail(t *testing.T) {
 	// Set up the expected query and result
 	email := "test@example.com"
 	expectedData := &User{
-		ID:              "test_user_id",
-		Phone:           sql.NullString{},
-		Email:           email,
-		Status:          constant.USER_STATUS_UNVERIFIED,
-		OnboardingSteps: "pending",
-		CreatedAt:       time.Now(),
+		ID:        "test_user_id",
+		Phone:     sql.NullString{},
+		Email:     sql.NullString{String: email, Valid: true},
+		Status:    constant.USER_STATUS_UNVERIFIED,
+		CreatedAt: time.Now(),
 	}
 
-	getUserByEmailQueryMock := "SELECT id, phone, email, status, onboarding_steps, created_at, updated_at FROM users WHERE email = \\$1 LIMIT 1"
+	getUserByEmailQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE email = \\$1 LIMIT 1"
 
-	rows := sqlmock.NewRows([]string{"id", "phone", "email", "status", "onboarding_steps", "created_at", "updated_at"}).
-		AddRow(expectedData.ID, expectedData.Phone, expectedData.Email, expectedData.Status, expectedData.OnboardingSteps, expectedData.CreatedAt, nil)
+	rows := sqlmock.NewRows([]string{"id", "phone", "email", "status", "created_at", "updated_at"}).
+		AddRow(expectedData.ID, expectedData.Phone, expectedData.Email, expectedData.Status, expectedData.CreatedAt, nil)
 
 	mock.ExpectQuery(getUserByEmailQueryMock).WithArgs(email).WillReturnRows(rows)
 
@@ -43,6 +42,37 @@ func TestGetUserByEmail(t *testing.T) {
 	assert.Equal(t, expectedData, data)
 }
 
+func TestGetUserByPhone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "postgres")
+	repo := NewUserRepo(dbx)
+
+	// Set up the expected query and result
+	phone := "+6281234567890"
+	expectedData := &User{
+		ID:        "test_user_id",
+		Phone:     sql.NullString{String: phone, Valid: true},
+		Email:     sql.NullString{},
+		Status:    constant.USER_STATUS_UNVERIFIED,
+		CreatedAt: time.Now(),
+	}
+
+	getUserByPhoneQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE phone = \\$1 LIMIT 1"
+
+	rows := sqlmock.NewRows([]string{"id", "phone", "email", "status", "created_at", "updated_at"}).
+		AddRow(expectedData.ID, expectedData.Phone, expectedData.Email, expectedData.Status, expectedData.CreatedAt, nil)
+
+	mock.ExpectQuery(getUserByPhoneQueryMock).WithArgs(phone).WillReturnRows(rows)
+
+	ctx := context.Background()
+	data, err := repo.GetUserByPhone(ctx, phone)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedData, data)
+}
+
 func TestGetUserById(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -54,18 +84,17 @@ func TestGetUserById(t *testing.T) {
 	// Set up the expected query and result
 	UserID := "test_user_id"
 	expectedData := &User{
-		ID:              UserID,
-		Phone:           sql.NullString{},
-		Email:           "test@example.com",
-		Status:          constant.USER_STATUS_UNVERIFIED,
-		OnboardingSteps: "pending",
-		CreatedAt:       time.Now(),
+		ID:        UserID,
+		Phone:     sql.NullString{},
+		Email:     sql.NullString{String: "test@example.com", Valid: true},
+		Status:    constant.USER_STATUS_UNVERIFIED,
+		CreatedAt: time.Now(),
 	}
 
-	getUserByIdQueryMock := "SELECT id, phone, email, status, onboarding_steps, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
+	getUserByIdQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
 
-	rows := sqlmock.NewRows([]string{"id", "phone", "email", "status", "onboarding_steps", "created_at", "updated_at"}).
-		AddRow(expectedData.ID, expectedData.Phone, expectedData.Email, expectedData.Status, expectedData.OnboardingSteps, expectedData.CreatedAt, nil)
+	rows := sqlmock.NewRows([]string{"id", "phone", "email", "status", "created_at", "updated_at"}).
+		AddRow(expectedData.ID, expectedData.Phone, expectedData.Email, expectedData.Status, expectedData.CreatedAt, nil)
 
 	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).WillReturnRows(rows)
 
@@ -85,33 +114,30 @@ func TestCreateUser(t *testing.T) {
 
 	// Set up the expected query and result
 	email := "test@example.com"
-	onboardingSteps := "pending"
 	expectedData := &User{
-		ID:              "test_user_id",
-		Phone:           sql.NullString{},
-		Email:           email,
-		Status:          constant.USER_STATUS_UNVERIFIED,
-		OnboardingSteps: onboardingSteps,
-		CreatedAt:       time.Now(),
+		ID:        "test_user_id",
+		Phone:     sql.NullString{},
+		Email:     sql.NullString{String: email, Valid: true},
+		Status:    constant.USER_STATUS_UNVERIFIED,
+		CreatedAt: time.Now(),
 	}
 
-	createUserQueryMock := "INSERT INTO users \\(email, onboarding_steps, created_at\\) VALUES \\(\\$1, \\$2, CURRENT_TIMESTAMP\\) RETURNING id"
+	createUserQueryMock := "INSERT INTO users \\(email, phone, created_at\\) VALUES \\(\\$1, \\$2, CURRENT_TIMESTAMP\\) RETURNING id"
 
 	rows := sqlmock.NewRows([]string{"id"}).AddRow(expectedData.ID)
 
-	mock.ExpectQuery(createUserQueryMock).WithArgs(email, onboardingSteps).WillReturnRows(rows)
+	mock.ExpectQuery(createUserQueryMock).WithArgs(expectedData.Email, expectedData.Phone).WillReturnRows(rows)
 
 	ctx := context.Background()
 	req := &CreateUser{
-		Email:           email,
-		OnboardingSteps: onboardingSteps,
+		Email: sql.NullString{String: email, Valid: true},
 	}
 	data, err := repo.CreateUser(ctx, req)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedData.ID, data.ID)
 }
 
-func TestUpdateOnboardingStepsUser(t *testing.T) {
+func TestUpdateStatusUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
@@ -121,22 +147,21 @@ func TestUpdateOnboardingStepsUser(t *testing.T) {
 
 	// Set up the expected query and parameters
 	UserID := "test_user_id"
-	onboardingSteps := "completed"
-
-	updateOnboardingStepsUserQueryMock := "UPDATE users SET onboarding_steps = \\$2 WHERE id = \\$1"
+	status := constant.USER_STATUS_ACTIVE
 
-	mock.ExpectExec(updateOnboardingStepsUserQueryMock).WithArgs(UserID, onboardingSteps).WillReturnResult(sqlmock.NewResult(0, 1))
+	updateStatusUserQueryMock := "UPDATE users SET status = \\$2 WHERE id = \\$1"
+	mock.ExpectExec(updateStatusUserQueryMock).WithArgs(UserID, status).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	ctx := context.Background()
-	req := &UpdateOnboardingSteps{
-		ID:              UserID,
-		OnboardingSteps: onboardingSteps,
+	req := &UpdateStatus{
+		ID:     UserID,
+		Status: status,
 	}
-	err = repo.UpdateOnboardingStepsUser(ctx, req)
+	err = repo.UpdateStatusUser(ctx, req)
 	assert.NoError(t, err)
 }
 
-func TestUpdateStatusUser(t *testing.T) {
+func TestUpdatePhoneUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
@@ -144,18 +169,17 @@ func TestUpdateStatusUser(t *testing.T) {
 	dbx := sqlx.NewDb(db, "postgres")
 	repo := NewUserRepo(dbx)
 
-	// Set up the expected query and parameters
 	UserID := "test_user_id"
-	status := constant.USER_STATUS_ACTIVE
+	phone := "+6281234567890"
 
-	updateStatusUserQueryMock := "UPDATE users SET status = \\$2 WHERE id = \\$1"
-	mock.ExpectExec(updateStatusUserQueryMock).WithArgs(UserID, status).WillReturnResult(sqlmock.NewResult(0, 1))
+	updatePhoneUserQueryMock := "UPDATE users SET phone = \\$2, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
+	mock.ExpectExec(updatePhoneUserQueryMock).WithArgs(UserID, phone).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	ctx := context.Background()
-	req := &UpdateStatus{
-		ID:     UserID,
-		Status: status,
-	}
-	err = repo.UpdateStatusUser(ctx, req)
+	err = repo.UpdatePhoneUser(ctx, &UpdatePhone{
+		ID:    UserID,
+		Phone: phone,
+	})
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+	pkgdisposable "github.com/ijlik/dating-user/pkg/disposable"
+)
+
+type DeleteAccountResponse struct {
+	DeleteScheduledAt time.Time `json:"delete_scheduled_at"`
+}
+
+type AttachPhoneRequest struct {
+	Phone string `json:"phone"`
+}
+
+func (req *AttachPhoneRequest) Validate() error {
+	req.Phone = NormalizePhone(req.Phone)
+
+	return validatePhone(req.Phone)
+}
+
+type VerifyPhoneRequest struct {
+	Otp string `json:"otp"`
+}
+
+func (req *VerifyPhoneRequest) Validate() error {
+	req.Otp = strings.ToUpper(strings.TrimSpace(req.Otp))
+	if req.Otp == "" {
+		return errors.New("otp is required")
+	}
+
+	return nil
+}
+
+type ChangeEmailRequest struct {
+	Email string `json:"email"`
+}
+
+func (req *ChangeEmailRequest) Validate(allowedDisposableEmail bool) error {
+	req.Email = strings.TrimSpace(req.Email)
+	if err := validateIdentity(req.Email, "", constant.OTP_TYPE_EMAIL); err != nil {
+		return err
+	}
+
+	if !allowedDisposableEmail && pkgdisposable.ValidateIsDisposable(req.Email) {
+		return errors.New("free and disposable email isnt allowed")
+	}
+
+	return nil
+}
+
+type ConfirmEmailChangeRequest struct {
+	Otp string `json:"otp"`
+}
+
+func (req *ConfirmEmailChangeRequest) Validate() error {
+	req.Otp = strings.ToUpper(strings.TrimSpace(req.Otp))
+	if req.Otp == "" {
+		return errors.New("otp is required")
+	}
+
+	return nil
+}
+
+// EmailChange audit of email change shown to support
+type EmailChange struct {
+	ID          string     `json:"id"`
+	OldEmail    string     `json:"old_email"`
+	NewEmail    string     `json:"new_email"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	CancelledAt *time.Time `json:"cancelled_at"`
+}
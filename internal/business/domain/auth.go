@@ -3,33 +3,101 @@ package domain
 import (
 	"errors"
 	"regexp"
+	"strings"
 
+	"github.com/ijlik/dating-user/pkg/constant"
 	pkgdisposable "github.com/ijlik/dating-user/pkg/disposable"
 )
 
 var (
 	rgxEmail     = regexp.MustCompile(`^[\w-\.]+@([\w-]+\.)+[\w-]{2,4}$`)
+	rgxPhone     = regexp.MustCompile(`^\+[1-9]\d{7,14}$`)
+	rgxPhoneSign = regexp.MustCompile(`[\s\-().]`)
 	rgxLatitude  = regexp.MustCompile(`^(-?\d+(\.\d+)?)$`)
 	rgxLongitude = regexp.MustCompile(`^(-?\d+(\.\d+)?)$`)
 )
 
+// parseOtpChannel default channel is email when not provided
+func parseOtpChannel(channel string) (constant.OneTimePasswordType, error) {
+	if channel == "" {
+		return constant.OTP_TYPE_EMAIL, nil
+	}
+
+	otpType := constant.GetOtpType(strings.ToUpper(channel))
+	if otpType == "unknown" {
+		return "", errors.New("channel not allowed")
+	}
+
+	return otpType, nil
+}
+
+// NormalizePhone strip formatting characters and turn international 00 prefix
+// into +, result still need to be validated as E.164
+func NormalizePhone(phone string) string {
+	phone = rgxPhoneSign.ReplaceAllString(strings.TrimSpace(phone), "")
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + strings.TrimPrefix(phone, "00")
+	}
+
+	return phone
+}
+
+func validatePhone(phone string) error {
+	if phone == "" {
+		return errors.New("phone is required")
+	}
+
+	if !rgxPhone.Match([]byte(phone)) {
+		return errors.New("invalid phone, use E.164 format e.g. +6281234567890")
+	}
+
+	return nil
+}
+
+func validateIdentity(email, phone string, otpType constant.OneTimePasswordType) error {
+	if otpType == constant.OTP_TYPE_EMAIL {
+		if email == "" {
+			return errors.New("email is required")
+		}
+
+		if !rgxEmail.Match([]byte(email)) {
+			return errors.New("invalid email")
+		}
+
+		return nil
+	}
+
+	return validatePhone(phone)
+}
+
 type ResendOtpRequest struct {
-	Email string `json:"email"`
+	Email     string                       `json:"email"`
+	Phone     string                       `json:"phone"`
+	Channel   string                       `json:"channel"`
+	MagicLink bool                         `json:"magic_link"`
+	OtpType   constant.OneTimePasswordType `json:"-"`
 }
 
 func (req *ResendOtpRequest) Validate(allowedDisposableEmail bool) error {
-	if req.Email == "" {
-		return errors.New("email is required")
+	otpType, err := parseOtpChannel(req.Channel)
+	if err != nil {
+		return err
 	}
+	req.OtpType = otpType
+	req.Phone = NormalizePhone(req.Phone)
 
-	if !rgxEmail.Match([]byte(req.Email)) {
-		return errors.New("invalid email")
+	if err := validateIdentity(req.Email, req.Phone, otpType); err != nil {
+		return err
 	}
 
-	if !allowedDisposableEmail && pkgdisposable.ValidateIsDisposable(req.Email) {
+	if otpType == constant.OTP_TYPE_EMAIL && !allowedDisposableEmail && pkgdisposable.ValidateIsDisposable(req.Email) {
 		return errors.New("free and disposable email isnt allowed")
 	}
 
+	if req.MagicLink && otpType != constant.OTP_TYPE_EMAIL {
+		return errors.New("magic_link only available for email channel")
+	}
+
 	return nil
 }
 
@@ -38,27 +106,78 @@ type ResendOtpResponse struct {
 	ResendOTPInterval int    `json:"resendOTPInterval"`
 }
 
+// DeviceInfo describe the device which own a session, user agent and ip are
+// filled by handler from request
+type DeviceInfo struct {
+	DeviceName string `json:"device_name"`
+	UserAgent  string `json:"-"`
+	IP         string `json:"-"`
+}
+
 type AuthRequest struct {
-	Email string `json:"email"`
-	Otp   string `json:"otp"`
+	Email   string                       `json:"email"`
+	Phone   string                       `json:"phone"`
+	Channel string                       `json:"channel"`
+	Otp     string                       `json:"otp"`
+	OtpType constant.OneTimePasswordType `json:"-"`
+	DeviceInfo
 }
 
 func (req *AuthRequest) Validate() error {
-	if req.Email == "" {
-		return errors.New("email is required")
+	otpType, err := parseOtpChannel(req.Channel)
+	if err != nil {
+		return err
 	}
+	req.OtpType = otpType
+	req.Phone = NormalizePhone(req.Phone)
 
-	if !rgxEmail.Match([]byte(req.Email)) {
-		return errors.New("invalid email")
+	if err := validateIdentity(req.Email, req.Phone, otpType); err != nil {
+		return err
 	}
 
 	if req.Otp == "" {
 		return errors.New("otp is required")
 	}
 
+	if len(req.DeviceName) > 100 {
+		return errors.New("device_name max 100 characters")
+	}
+
 	return nil
 }
 
 type AuthResponse struct {
-	AccessToken string `json:"access_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (req *RefreshTokenRequest) Validate() error {
+	if req.RefreshToken == "" {
+		return errors.New("refresh_token is required")
+	}
+
+	return nil
+}
+
+type MagicLinkRequest struct {
+	Token string
+	DeviceInfo
+}
+
+func (req *MagicLinkRequest) Validate() error {
+	if req.Token == "" {
+		return errors.New("token is required")
+	}
+
+	if len(req.DeviceName) > 100 {
+		return errors.New("device_name max 100 characters")
+	}
+
+	return nil
 }
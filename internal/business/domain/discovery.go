@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+)
+
+const (
+	MinDiscoveryAge        = 18
+	MaxDiscoveryAge        = 99
+	MaxDiscoveryDistanceKm = 500
+
+	MinLatitude  = -90
+	MaxLatitude  = 90
+	MinLongitude = -180
+	MaxLongitude = 180
+)
+
+// ApproximateDistanceKm distance shown on the feed, rounded so the exact
+// location of the other user can not be worked out: at least 1 km, whole
+// kilometers up to 10 km then steps of 5 km
+func ApproximateDistanceKm(distanceKm float64) int {
+	if distanceKm < 1 {
+		return 1
+	}
+	if distanceKm < 10 {
+		return int(math.Round(distanceKm))
+	}
+	return int(math.Round(distanceKm/5) * 5)
+}
+
+// DiscoveryPreferences zero max distance is anywhere and empty genders is
+// everyone, genders are the "show me" of the profile. VerifiedOnly show only
+// profiles with the verified badge
+type DiscoveryPreferences struct {
+	MinAge        int      `json:"min_age"`
+	MaxAge        int      `json:"max_age"`
+	MaxDistanceKm int      `json:"max_distance_km"`
+	Genders       []string `json:"genders"`
+	VerifiedOnly  bool     `json:"verified_only"`
+}
+
+type UpdateDiscoveryPreferencesRequest struct {
+	MinAge        int      `json:"min_age"`
+	MaxAge        int      `json:"max_age"`
+	MaxDistanceKm int      `json:"max_distance_km"`
+	Genders       []string `json:"genders"`
+	VerifiedOnly  bool     `json:"verified_only"`
+}
+
+func (p *UpdateDiscoveryPreferencesRequest) Validate() errpkg.ErrorService {
+	if p.MinAge < MinDiscoveryAge || p.MaxAge > MaxDiscoveryAge {
+		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("age must be between %d and %d", MinDiscoveryAge, MaxDiscoveryAge))
+	}
+	if p.MinAge > p.MaxAge {
+		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "min age must not be greater than max age")
+	}
+	if p.MaxDistanceKm < 0 || p.MaxDistanceKm > MaxDiscoveryDistanceKm {
+		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("max distance must be between 0 (anywhere) and %d km", MaxDiscoveryDistanceKm))
+	}
+
+	return normalizeShowMe(p.Genders)
+}
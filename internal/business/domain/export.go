@@ -0,0 +1,110 @@
+package domain
+
+import "time"
+
+type DataExportResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+type DownloadDataExportRequest struct {
+	ID        string `json:"-"`
+	Expires   int64  `form:"expires" binding:"required"`
+	Signature string `form:"signature" binding:"required"`
+}
+
+// ExportUser content of user.json inside the archive
+type ExportUser struct {
+	ID              string            `json:"id"`
+	Email           string            `json:"email"`
+	Phone           string            `json:"phone"`
+	Status          string            `json:"status"`
+	OnboardingSteps []OnboardingSteps `json:"onboarding_steps"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       *time.Time        `json:"updated_at"`
+}
+
+// ExportProfile content of profile.json inside the archive
+type ExportProfile struct {
+	ID                  string     `json:"id"`
+	Name                string     `json:"name"`
+	BirthDate           *time.Time `json:"birth_date"`
+	Gender              string     `json:"gender"`
+	Photos              []string   `json:"photos"`
+	Hobby               []string   `json:"hobby"`
+	Interest            []string   `json:"interest"`
+	Location            *Location  `json:"location"`
+	IsPremium           bool       `json:"is_premium"`
+	IsPremiumValidUntil *time.Time `json:"is_premium_valid_until"`
+	DailySwapQuota      int        `json:"daily_swap_quota"`
+	IsVerified          bool       `json:"is_verified"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           *time.Time `json:"updated_at"`
+
+	Bio       string                `json:"bio"`
+	JobTitle  string                `json:"job_title"`
+	Company   string                `json:"company"`
+	Education string                `json:"education"`
+	HeightCm  int                   `json:"height_cm,omitempty"`
+	Languages []string              `json:"languages"`
+	Prompts   []ProfilePromptAnswer `json:"prompts"`
+
+	GenderCustom string   `json:"gender_custom"`
+	Orientation  string   `json:"orientation"`
+	ShowMe       []string `json:"show_me"`
+}
+
+// ExportVerification content of verifications.json, selfies are under
+// verifications/ inside the archive
+type ExportVerification struct {
+	ID          string     `json:"id"`
+	Pose        string     `json:"pose"`
+	Selfie      string     `json:"selfie"`
+	Similarity  *float64   `json:"similarity"`
+	PoseMatched *bool      `json:"pose_matched"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+}
+
+type ExportSwipe struct {
+	ID        string    `json:"id"`
+	SwipedID  string    `json:"swiped_id"`
+	IsLike    bool      `json:"is_like"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportReceivedSwipe swiper is another user, only the swipe itself is
+// exported
+type ExportReceivedSwipe struct {
+	ID        string    `json:"id"`
+	IsLike    bool      `json:"is_like"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportOneTimePasswordLog code is never exported
+type ExportOneTimePasswordLog struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+type ExportPayment struct {
+	ID            string     `json:"id"`
+	Amount        float32    `json:"amount"`
+	Identifier    string     `json:"identifier"`
+	PaymentMethod string     `json:"payment_method"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+}
+
+// ExportLoginFailure failed login attempts and lockout of the account
+type ExportLoginFailure struct {
+	FailedAttempts int        `json:"failed_attempts"`
+	LockoutLevel   int        `json:"lockout_level"`
+	LockedUntil    *time.Time `json:"locked_until"`
+	LastFailedAt   *time.Time `json:"last_failed_at"`
+}
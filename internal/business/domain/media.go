@@ -0,0 +1,9 @@
+package domain
+
+// GetMediaRequest media URL is signed for the key until expires, see
+// MEDIA_URL_SECRET
+type GetMediaRequest struct {
+	Key       string `json:"-"`
+	Expires   int64  `form:"expires" binding:"required"`
+	Signature string `form:"signature" binding:"required"`
+}
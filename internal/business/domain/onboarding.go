@@ -1,10 +1,14 @@
 package domain
 
 import (
+	"fmt"
+	"github.com/ijlik/dating-user/pkg/constant"
 	errpkg "github.com/ijlik/dating-user/pkg/error"
 	"mime/multipart"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type UpdatePersonalInfo struct {
@@ -12,16 +16,33 @@ type UpdatePersonalInfo struct {
 	BirthDates string    `json:"birth_date"`
 	BirthDate  time.Time `json:"-"`
 	Gender     string    `json:"gender"`
+
+	// optional, empty show me is everyone
+	GenderCustom string   `json:"gender_custom"`
+	Orientation  string   `json:"orientation"`
+	ShowMe       []string `json:"show_me"`
 }
 
 func (p *UpdatePersonalInfo) Validate() errpkg.ErrorService {
 	if p.Name == "" {
 		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "missing name")
 	}
-	if strings.ToLower(p.Gender) != "male" && strings.ToLower(p.Gender) != "female" {
-		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "allowed gender Male or Female")
+	gender, errs := normalizeGender(p.Gender)
+	if errs != nil {
+		return errs
+	}
+	p.Gender = gender
+	if p.GenderCustom, errs = normalizeGenderCustom(p.Gender, p.GenderCustom); errs != nil {
+		return errs
+	}
+	if p.Orientation != "" {
+		if p.Orientation, errs = normalizeOrientation(p.Orientation); errs != nil {
+			return errs
+		}
+	}
+	if errs = normalizeShowMe(p.ShowMe); errs != nil {
+		return errs
 	}
-	p.Gender = strings.Title(strings.ToLower(p.Gender))
 	date, err := time.Parse(time.RFC3339, p.BirthDates)
 	if err != nil {
 		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "invalid format birth date")
@@ -32,6 +53,66 @@ func (p *UpdatePersonalInfo) Validate() errpkg.ErrorService {
 	return nil
 }
 
+func normalizeGender(gender string) (string, errpkg.ErrorService) {
+	item, ok := constant.ParseGender(gender)
+	if !ok {
+		return "", errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("allowed gender %s", joinGenders(constant.Genders)))
+	}
+
+	return item.String(), nil
+}
+
+func joinGenders(genders []constant.Gender) string {
+	var names []string
+	for _, gender := range genders {
+		names = append(names, gender.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// normalizeGenderCustom custom label only describe gender Other
+func normalizeGenderCustom(gender, custom string) (string, errpkg.ErrorService) {
+	custom = strings.TrimSpace(custom)
+	if custom == "" {
+		return "", nil
+	}
+	if gender != constant.GENDER_OTHER.String() {
+		return "", errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("gender custom is only allowed for gender %s", constant.GENDER_OTHER))
+	}
+	if utf8.RuneCountInString(custom) > MaxGenderCustomLength {
+		return "", errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("gender custom must be at most %d characters", MaxGenderCustomLength))
+	}
+
+	return custom, nil
+}
+
+func normalizeOrientation(orientation string) (string, errpkg.ErrorService) {
+	item, ok := constant.ParseOrientation(orientation)
+	if !ok {
+		return "", errpkg.DefaultServiceError(errpkg.ErrBadRequest, "invalid orientation")
+	}
+
+	return item.String(), nil
+}
+
+// normalizeShowMe every entry must be a gender of the catalogue
+func normalizeShowMe(showMe []string) errpkg.ErrorService {
+	seen := map[string]bool{}
+	for i, item := range showMe {
+		gender, errs := normalizeGender(item)
+		if errs != nil {
+			return errs
+		}
+		if seen[gender] {
+			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "duplicate gender on show me")
+		}
+		seen[gender] = true
+		showMe[i] = gender
+	}
+
+	return nil
+}
+
 type UpdatePhotos struct {
 	Photos []*multipart.FileHeader `form:"photos"`
 }
@@ -47,11 +128,7 @@ func (p *UpdatePhotos) Validate() errpkg.ErrorService {
 			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "invalid file: one of the images is missing")
 		}
 
-		contentType := file.Header.Get("Content-Type")
-		if contentType != "image/jpeg" && contentType != "image/png" {
-			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "invalid file type: only JPEG and PNG images are allowed")
-		}
-
+		// content type is sniffed from the bytes once the photo is decoded
 		if file.Size > maxSize {
 			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "file size too large: maximum allowed size is 5MB")
 		}
@@ -85,6 +162,10 @@ type Location struct {
 	Longitude string `json:"longitude"`
 	Latitude  string `json:"latitude"`
 	Url       string `json:"url"`
+
+	// parsed by Validate
+	Lon float64 `json:"-"`
+	Lat float64 `json:"-"`
 }
 
 func (u *Location) Validate() errpkg.ErrorService {
@@ -94,12 +175,39 @@ func (u *Location) Validate() errpkg.ErrorService {
 	if !rgxLongitude.Match([]byte(u.Longitude)) {
 		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "invalid format longitude")
 	}
+	lon, err := strconv.ParseFloat(u.Longitude, 64)
+	if err != nil || lon < MinLongitude || lon > MaxLongitude {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			fmt.Sprintf("longitude must be between %v and %v", MinLongitude, MaxLongitude),
+		)
+	}
 	if u.Latitude == "" {
 		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "missing latitude")
 	}
 	if !rgxLatitude.Match([]byte(u.Latitude)) {
 		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "invalid format latitude")
 	}
+	lat, err := strconv.ParseFloat(u.Latitude, 64)
+	if err != nil || lat < MinLatitude || lat > MaxLatitude {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			fmt.Sprintf("latitude must be between %v and %v", MinLatitude, MaxLatitude),
+		)
+	}
+	u.Lon = lon
+	u.Lat = lat
 
 	return nil
 }
+
+// OnboardingProgress NextStep is the first pending required step which
+// dependencies are done, empty once onboarding is completed
+type OnboardingProgress struct {
+	Steps          []OnboardingSteps `json:"steps"`
+	CompletedSteps int               `json:"completed_steps"`
+	TotalSteps     int               `json:"total_steps"`
+	IsCompleted    bool              `json:"is_completed"`
+	NextStep       string            `json:"next_step,omitempty"`
+	MissingSteps   []string          `json:"missing_steps,omitempty"`
+}
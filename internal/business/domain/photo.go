@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"regexp"
+	"time"
+
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+)
+
+// ProfilePhoto photo of the profile, the primary one is shown first on the
+// profile and the feeds. URL is the full size rendition
+type ProfilePhoto struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	CardURL   string    `json:"card_url"`
+	ThumbURL  string    `json:"thumb_url"`
+	Position  int       `json:"position"`
+	IsPrimary bool      `json:"is_primary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var photoIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidPhotoID photo id is an uuid
+func ValidPhotoID(id string) bool {
+	return photoIDPattern.MatchString(id)
+}
+
+// ReorderProfilePhotosRequest every photo of the profile in the new order
+type ReorderProfilePhotosRequest struct {
+	PhotoIDs []string `json:"photo_ids"`
+}
+
+func (r *ReorderProfilePhotosRequest) Validate() errpkg.ErrorService {
+	if len(r.PhotoIDs) == 0 {
+		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "photo_ids is required")
+	}
+
+	seen := make(map[string]bool, len(r.PhotoIDs))
+	for _, id := range r.PhotoIDs {
+		if !ValidPhotoID(id) {
+			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "invalid photo id")
+		}
+		if seen[id] {
+			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "duplicate photo id")
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
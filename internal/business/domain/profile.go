@@ -1,7 +1,13 @@
 package domain
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	errpkg "github.com/ijlik/dating-user/pkg/error"
 )
 
 type Profile struct {
@@ -17,7 +23,315 @@ type Profile struct {
 	IsPremium           bool      `json:"is_premium"`
 	IsPremiumValidUntil time.Time `json:"is_premium_valid_until"`
 	DailySwapQuota      int       `json:"daily_swap_quota"`
+	IsVerified          bool      `json:"is_verified"`
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
 	User                *User     `json:"user"`
+
+	Bio       string                `json:"bio"`
+	JobTitle  string                `json:"job_title"`
+	Company   string                `json:"company"`
+	Education string                `json:"education"`
+	HeightCm  int                   `json:"height_cm,omitempty"`
+	Languages []string              `json:"languages"`
+	Prompts   []ProfilePromptAnswer `json:"prompts"`
+
+	// orientation and show me are only shown to the owner
+	GenderCustom string   `json:"gender_custom,omitempty"`
+	Orientation  string   `json:"orientation,omitempty"`
+	ShowMe       []string `json:"show_me,omitempty"`
+
+	// feed only, approximate distance from the viewer instead of the location
+	DistanceKm int `json:"distance_km,omitempty"`
+}
+
+const (
+	MaxGenderCustomLength = 30
+
+	MaxBioLength          = 500
+	MaxProfileTextLength  = 100
+	MaxProfilePrompts     = 3
+	MaxPromptAnswerLength = 150
+	MinHeightCm           = 100
+	MaxHeightCm           = 250
+	MaxLanguages          = 5
+)
+
+// languageCodePattern ISO 639-1 code, e.g. "en"
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// ProfilePrompt question of the prompt catalogue
+type ProfilePrompt struct {
+	ID       string `json:"id"`
+	Question string `json:"question"`
+}
+
+// ProfilePromptAnswer question is copied when answered, so later rewording of
+// the catalogue does not change the meaning of the answer
+type ProfilePromptAnswer struct {
+	PromptID string `json:"prompt_id"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+type PromptAnswerRequest struct {
+	PromptID string `json:"prompt_id"`
+	Answer   string `json:"answer"`
+}
+
+// ETag version of the profile, sent back on If-Match to update it
+func (p *Profile) ETag() string {
+	return ProfileETag(p.UpdatedAt, p.CreatedAt)
+}
+
+// ProfileETag profile never updated is versioned by its creation time
+func ProfileETag(updatedAt, createdAt time.Time) string {
+	version := updatedAt
+	if version.IsZero() {
+		version = createdAt
+	}
+
+	return fmt.Sprintf(`"%x"`, version.UnixNano())
+}
+
+// ListPatch entries to remove from and add to a list field, removal is
+// applied first and entries are compared case insensitive
+type ListPatch struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+func (l *ListPatch) validate(field string) errpkg.ErrorService {
+	for _, items := range [][]string{l.Add, l.Remove} {
+		for i, item := range items {
+			items[i] = strings.TrimSpace(item)
+			if items[i] == "" {
+				return errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("missing %s", field))
+			}
+			if strings.Contains(items[i], ",") {
+				return errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("%s must not contain comma", field))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *ListPatch) Apply(current []string) []string {
+	var result []string
+	has := func(list []string, value string) bool {
+		for _, item := range list {
+			if strings.EqualFold(item, value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, item := range current {
+		if !has(l.Remove, item) {
+			result = append(result, item)
+		}
+	}
+	for _, item := range l.Add {
+		if !has(result, item) {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// PatchProfileRequest only given field is changed, IfMatch is the ETag the
+// client read the profile with
+type PatchProfileRequest struct {
+	Name       *string    `json:"name"`
+	BirthDates *string    `json:"birth_date"`
+	BirthDate  *time.Time `json:"-"`
+	Gender     *string    `json:"gender"`
+	Hobby      *ListPatch `json:"hobby"`
+	Interest   *ListPatch `json:"interest"`
+	IfMatch    string     `json:"-"`
+
+	// empty value clear the attribute, so does zero height
+	Bio       *string                `json:"bio"`
+	JobTitle  *string                `json:"job_title"`
+	Company   *string                `json:"company"`
+	Education *string                `json:"education"`
+	HeightCm  *int                   `json:"height_cm"`
+	Languages *[]string              `json:"languages"`
+	Prompts   *[]PromptAnswerRequest `json:"prompts"`
+
+	// empty show me is everyone
+	GenderCustom *string   `json:"gender_custom"`
+	Orientation  *string   `json:"orientation"`
+	ShowMe       *[]string `json:"show_me"`
+}
+
+// validateText trim the value and check its length in character
+func validateText(value *string, field string, max int) errpkg.ErrorService {
+	*value = strings.TrimSpace(*value)
+	if utf8.RuneCountInString(*value) > max {
+		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("%s must be at most %d characters", field, max))
+	}
+
+	return nil
+}
+
+func validateLanguages(languages []string) errpkg.ErrorService {
+	if len(languages) > MaxLanguages {
+		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("at most %d languages", MaxLanguages))
+	}
+
+	seen := map[string]bool{}
+	for i, language := range languages {
+		languages[i] = strings.ToLower(strings.TrimSpace(language))
+		if !languageCodePattern.MatchString(languages[i]) {
+			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "language must be ISO 639-1 code")
+		}
+		if seen[languages[i]] {
+			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "duplicate language")
+		}
+		seen[languages[i]] = true
+	}
+
+	return nil
+}
+
+func validatePrompts(prompts []PromptAnswerRequest) errpkg.ErrorService {
+	if len(prompts) > MaxProfilePrompts {
+		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("at most %d prompts", MaxProfilePrompts))
+	}
+
+	seen := map[string]bool{}
+	for i := range prompts {
+		prompts[i].PromptID = strings.TrimSpace(prompts[i].PromptID)
+		if prompts[i].PromptID == "" {
+			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "missing prompt id")
+		}
+		if seen[prompts[i].PromptID] {
+			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "prompt can only be answered once")
+		}
+		seen[prompts[i].PromptID] = true
+
+		if errs := validateText(&prompts[i].Answer, "answer", MaxPromptAnswerLength); errs != nil {
+			return errs
+		}
+		if prompts[i].Answer == "" {
+			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "missing answer")
+		}
+	}
+
+	return nil
+}
+
+func (p *PatchProfileRequest) Validate() errpkg.ErrorService {
+	if p.Name == nil && p.BirthDates == nil && p.Gender == nil && p.Hobby == nil && p.Interest == nil &&
+		p.Bio == nil && p.JobTitle == nil && p.Company == nil && p.Education == nil &&
+		p.HeightCm == nil && p.Languages == nil && p.Prompts == nil &&
+		p.GenderCustom == nil && p.Orientation == nil && p.ShowMe == nil {
+		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "nothing to update")
+	}
+
+	if p.Name != nil {
+		name := strings.TrimSpace(*p.Name)
+		if name == "" {
+			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "missing name")
+		}
+		p.Name = &name
+	}
+
+	if p.BirthDates != nil {
+		date, err := time.Parse(time.RFC3339, *p.BirthDates)
+		if err != nil {
+			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "invalid format birth date")
+		}
+		date = date.UTC()
+		p.BirthDate = &date
+	}
+
+	if p.Gender != nil {
+		gender, errs := normalizeGender(*p.Gender)
+		if errs != nil {
+			return errs
+		}
+		p.Gender = &gender
+	}
+
+	if p.GenderCustom != nil {
+		custom := strings.TrimSpace(*p.GenderCustom)
+		p.GenderCustom = &custom
+		// pairing with the stored gender is checked by the service
+		if custom != "" && p.Gender != nil {
+			if _, errs := normalizeGenderCustom(*p.Gender, custom); errs != nil {
+				return errs
+			}
+		}
+		if utf8.RuneCountInString(custom) > MaxGenderCustomLength {
+			return errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("gender custom must be at most %d characters", MaxGenderCustomLength))
+		}
+	}
+
+	if p.Orientation != nil && *p.Orientation != "" {
+		orientation, errs := normalizeOrientation(*p.Orientation)
+		if errs != nil {
+			return errs
+		}
+		p.Orientation = &orientation
+	}
+
+	if p.ShowMe != nil {
+		if errs := normalizeShowMe(*p.ShowMe); errs != nil {
+			return errs
+		}
+	}
+
+	if p.Hobby != nil {
+		if errs := p.Hobby.validate("hobby"); errs != nil {
+			return errs
+		}
+	}
+
+	if p.Interest != nil {
+		if errs := p.Interest.validate("interest"); errs != nil {
+			return errs
+		}
+	}
+
+	if p.Bio != nil {
+		if errs := validateText(p.Bio, "bio", MaxBioLength); errs != nil {
+			return errs
+		}
+	}
+
+	for field, value := range map[string]*string{
+		"job title": p.JobTitle,
+		"company":   p.Company,
+		"education": p.Education,
+	} {
+		if value == nil {
+			continue
+		}
+		if errs := validateText(value, field, MaxProfileTextLength); errs != nil {
+			return errs
+		}
+	}
+
+	if p.HeightCm != nil && *p.HeightCm != 0 && (*p.HeightCm < MinHeightCm || *p.HeightCm > MaxHeightCm) {
+		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, fmt.Sprintf("height must be between %d and %d cm", MinHeightCm, MaxHeightCm))
+	}
+
+	if p.Languages != nil {
+		if errs := validateLanguages(*p.Languages); errs != nil {
+			return errs
+		}
+	}
+
+	if p.Prompts != nil {
+		if errs := validatePrompts(*p.Prompts); errs != nil {
+			return errs
+		}
+	}
+
+	return nil
 }
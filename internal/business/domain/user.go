@@ -1,12 +1,18 @@
 package domain
 
+import "time"
+
 type User struct {
 	Email           string            `json:"email"`
+	Phone           string            `json:"phone,omitempty"`
 	Status          string            `json:"status"`
 	OnboardingSteps []OnboardingSteps `json:"onboarding_steps"`
 }
 
 type OnboardingSteps struct {
-	Step   string `json:"step"`
-	Status string `json:"status"`
+	Step        string     `json:"step"`
+	Status      string     `json:"status"`
+	Required    bool       `json:"required"`
+	DependsOn   []string   `json:"depends_on,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }
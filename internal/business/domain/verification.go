@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"mime/multipart"
+	"time"
+
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+)
+
+// VerificationChallenge pose to do on the selfie, the selfie must be sent
+// before ExpiresAt
+type VerificationChallenge struct {
+	Pose      string    `json:"pose"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Verification PENDING is waiting for a manual review
+type Verification struct {
+	ID         string     `json:"id"`
+	Pose       string     `json:"pose"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+}
+
+// ValidVerificationID verification id is an uuid
+func ValidVerificationID(id string) bool {
+	return photoIDPattern.MatchString(id)
+}
+
+type SubmitVerificationRequest struct {
+	Selfie *multipart.FileHeader `form:"selfie"`
+}
+
+func (r *SubmitVerificationRequest) Validate() errpkg.ErrorService {
+	if r.Selfie == nil {
+		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "missing selfie: an image file is required")
+	}
+
+	// content type is sniffed from the bytes once the selfie is decoded
+	if r.Selfie.Size > 5*1024*1024 {
+		return errpkg.DefaultServiceError(errpkg.ErrBadRequest, "file size too large: maximum allowed size is 5MB")
+	}
+
+	return nil
+}
+
+// VerificationReview item of the review queue, selfie is shown next to the
+// profile photos. Similarity and PoseMatched are null when the face matcher
+// was not available
+type VerificationReview struct {
+	ID          string    `json:"id"`
+	ProfileID   string    `json:"profile_id"`
+	Pose        string    `json:"pose"`
+	SelfieURL   string    `json:"selfie_url"`
+	Photos      []string  `json:"photos"`
+	Similarity  *float64  `json:"similarity"`
+	PoseMatched *bool     `json:"pose_matched"`
+	CreatedAt   time.Time `json:"created_at"`
+}
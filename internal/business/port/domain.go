@@ -2,6 +2,8 @@ package port
 
 import (
 	"context"
+	"io"
+
 	"github.com/ijlik/dating-user/internal/business/domain"
 	errpkg "github.com/ijlik/dating-user/pkg/error"
 )
@@ -9,16 +11,52 @@ import (
 type UserDomainService interface {
 	ResendOtp(ctx context.Context, req *domain.ResendOtpRequest) (*domain.ResendOtpResponse, errpkg.ErrorService)
 	LoginOrRegister(ctx context.Context, req *domain.AuthRequest) (*domain.AuthResponse, errpkg.ErrorService)
-	Logout(ctx context.Context, UserID string) errpkg.ErrorService
+	LoginWithMagicLink(ctx context.Context, req *domain.MagicLinkRequest) (*domain.AuthResponse, errpkg.ErrorService)
+	RefreshToken(ctx context.Context, req *domain.RefreshTokenRequest) (*domain.AuthResponse, errpkg.ErrorService)
+	Logout(ctx context.Context, UserID, sessionID string) errpkg.ErrorService
+	ListSessions(ctx context.Context, UserID, currentSessionID string) ([]*domain.Session, errpkg.ErrorService)
+	RevokeSession(ctx context.Context, UserID, sessionID string) errpkg.ErrorService
+	RevokeOtherSessions(ctx context.Context, UserID, currentSessionID string) errpkg.ErrorService
 	ShowProfile(ctx context.Context, UserID string) (*domain.Profile, errpkg.ErrorService)
+	PatchProfile(ctx context.Context, UserID string, req *domain.PatchProfileRequest) (*domain.Profile, errpkg.ErrorService)
+	GetProfilePrompts(ctx context.Context) ([]*domain.ProfilePrompt, errpkg.ErrorService)
+	GetProfilePhotos(ctx context.Context, UserID string) ([]*domain.ProfilePhoto, errpkg.ErrorService)
+	AddProfilePhotos(ctx context.Context, UserID string, req *domain.UpdatePhotos) ([]*domain.ProfilePhoto, errpkg.ErrorService)
+	DeleteProfilePhoto(ctx context.Context, UserID, photoID string) ([]*domain.ProfilePhoto, errpkg.ErrorService)
+	ReorderProfilePhotos(ctx context.Context, UserID string, req *domain.ReorderProfilePhotosRequest) ([]*domain.ProfilePhoto, errpkg.ErrorService)
+	SetPrimaryProfilePhoto(ctx context.Context, UserID, photoID string) ([]*domain.ProfilePhoto, errpkg.ErrorService)
+	StartVerification(ctx context.Context, UserID string) (*domain.VerificationChallenge, errpkg.ErrorService)
+	SubmitVerification(ctx context.Context, UserID string, req *domain.SubmitVerificationRequest) (*domain.Verification, errpkg.ErrorService)
+	GetVerification(ctx context.Context, UserID string) (*domain.Verification, errpkg.ErrorService)
+
+	DeactivateAccount(ctx context.Context, UserID string) errpkg.ErrorService
+	DeleteAccount(ctx context.Context, UserID string) (*domain.DeleteAccountResponse, errpkg.ErrorService)
+	PurgeDeletedAccounts(ctx context.Context) errpkg.ErrorService
+	RequestDataExport(ctx context.Context, UserID string) (*domain.DataExportResponse, errpkg.ErrorService)
+	GetDataExport(ctx context.Context, req *domain.DownloadDataExportRequest) (io.ReadCloser, errpkg.ErrorService)
+	PurgeExpiredDataExports(ctx context.Context) errpkg.ErrorService
+	GetMedia(ctx context.Context, req *domain.GetMediaRequest) (io.ReadCloser, errpkg.ErrorService)
+	AttachPhone(ctx context.Context, UserID string, req *domain.AttachPhoneRequest) (*domain.ResendOtpResponse, errpkg.ErrorService)
+	VerifyPhone(ctx context.Context, UserID string, req *domain.VerifyPhoneRequest) errpkg.ErrorService
+	RequestEmailChange(ctx context.Context, UserID string, req *domain.ChangeEmailRequest) (*domain.ResendOtpResponse, errpkg.ErrorService)
+	ConfirmEmailChange(ctx context.Context, UserID string, req *domain.ConfirmEmailChangeRequest) errpkg.ErrorService
+	CancelEmailChange(ctx context.Context, token string) errpkg.ErrorService
+	GetEmailChanges(ctx context.Context, UserID string) ([]*domain.EmailChange, errpkg.ErrorService)
 
+	GetOnboardingProgress(ctx context.Context, UserID string) (*domain.OnboardingProgress, errpkg.ErrorService)
 	UpdatePersonalInfo(ctx context.Context, req *domain.UpdatePersonalInfo, UserID string) errpkg.ErrorService
 	UpdatePhotos(ctx context.Context, req *domain.UpdatePhotos, UserID string) errpkg.ErrorService
 	UpdateHobbyAndInterest(ctx context.Context, req *domain.UpdateHobbyAndInterest, UserID string) errpkg.ErrorService
 	UpdateLocation(ctx context.Context, req *domain.Location, UserID string) errpkg.ErrorService
 
 	ShowFeeds(ctx context.Context, UserID, profileId string) ([]*domain.Profile, errpkg.ErrorService)
+	GetDiscoveryPreferences(ctx context.Context, UserID string) (*domain.DiscoveryPreferences, errpkg.ErrorService)
+	UpdateDiscoveryPreferences(ctx context.Context, UserID string, req *domain.UpdateDiscoveryPreferencesRequest) (*domain.DiscoveryPreferences, errpkg.ErrorService)
 	Swipes(ctx context.Context, req *domain.SwipeRequest, UserID string) errpkg.ErrorService
 
 	CreatePayment(ctx context.Context, req *domain.PaymentRequest, UserID string) errpkg.ErrorService
+
+	UnlockUser(ctx context.Context, UserID string) errpkg.ErrorService
+	GetVerificationQueue(ctx context.Context) ([]*domain.VerificationReview, errpkg.ErrorService)
+	ReviewVerification(ctx context.Context, reviewerID, id string, approved bool) errpkg.ErrorService
 }
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ijlik/dating-user/internal/business/domain"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+)
+
+// purgeBatchSize user hard deleted on every purge run
+const purgeBatchSize = 100
+
+func (s *service) accountDeleteGracePeriod() time.Duration {
+	days := s.config.GetInt("ACCOUNT_DELETE_GRACE_PERIOD_IN_DAY")
+	if days == 0 {
+		days = 30
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// DeactivateAccount hide the profile from feeds and log out every device,
+// next successful login reactivate the account
+func (s *service) DeactivateAccount(
+	ctx context.Context,
+	UserID string,
+) errpkg.ErrorService {
+	err := s.repo.DeactivateUser(ctx, UserID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	err = s.revokeAllSessions(ctx, UserID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return nil
+}
+
+// DeleteAccount deactivate the account and schedule hard delete after grace
+// period, login before that cancel the deletion
+func (s *service) DeleteAccount(
+	ctx context.Context,
+	UserID string,
+) (*domain.DeleteAccountResponse, errpkg.ErrorService) {
+	deleteAt := s.time.Now().UTC().Add(s.accountDeleteGracePeriod())
+	err := s.repo.ScheduleDeleteUser(ctx, UserID, deleteAt)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	err = s.revokeAllSessions(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return &domain.DeleteAccountResponse{
+		DeleteScheduledAt: deleteAt,
+	}, nil
+}
+
+// PurgeDeletedAccounts hard delete account which grace period is over,
+// including its photos and verification selfies on the blob store
+func (s *service) PurgeDeletedAccounts(ctx context.Context) errpkg.ErrorService {
+	for {
+		UserIDs, err := s.repo.GetUserIDsScheduledForDeletion(ctx, s.time.Now().UTC(), purgeBatchSize)
+		if err != nil {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+
+		purged := 0
+		for _, UserID := range UserIDs {
+			// failed account stay scheduled and is retried on next run
+			if err = s.purgeAccount(ctx, UserID); err != nil {
+				log.Println("failed to purge account ", UserID, ": ", err)
+				continue
+			}
+			purged++
+		}
+
+		if len(UserIDs) < purgeBatchSize || purged == 0 {
+			return nil
+		}
+	}
+}
+
+// purgeAccount stored photos and selfies are deleted before the rows which
+// point to them, so a failure never leave an object nobody can find
+func (s *service) purgeAccount(ctx context.Context, UserID string) error {
+	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
+	if err != nil {
+		return err
+	}
+
+	if profile != nil {
+		verifications, err := s.repo.GetProfileVerifications(ctx, profile.ID)
+		if err != nil {
+			return err
+		}
+
+		if err = s.deletePhotos(ctx, splitNullString(profile.Photos)); err != nil {
+			return err
+		}
+		for _, verification := range verifications {
+			if err = s.blobStore.Delete(ctx, verification.SelfieKey); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.repo.DeleteUser(ctx, UserID)
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	blobstorepkg "github.com/ijlik/dating-user/pkg/blobstore"
+	"github.com/ijlik/dating-user/pkg/constant"
+	sessionpkg "github.com/ijlik/dating-user/pkg/session"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectDeleteUser every table owning rows of the user is cleaned in one
+// transaction
+func expectDeleteUser(mock sqlmock.Sqlmock, UserID string) {
+	mock.ExpectBegin()
+	for i := 0; i < 11; i++ {
+		mock.ExpectExec("DELETE FROM").WithArgs(UserID).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestDeactivateAccount(t *testing.T) {
+	svc, mock, rdb := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	_, phone := issueTestTokens(t, svc, UserID, "Pixel 7")
+	_, laptop := issueTestTokens(t, svc, UserID, "MacBook")
+
+	deactivateUserQueryMock := "UPDATE users SET status = \\$2, deactivated_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
+	mock.ExpectExec(deactivateUserQueryMock).
+		WithArgs(UserID, constant.USER_STATUS_DEACTIVE).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errs := svc.DeactivateAccount(ctx, UserID)
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// every device is logged out
+	for _, session := range []*sessionpkg.Session{phone, laptop} {
+		_, ok := rdb.values[sessionpkg.Key(session.ID)]
+		assert.False(t, ok)
+	}
+	members, _ := rdb.SMembers(ctx, sessionpkg.UserKey(UserID))
+	assert.Empty(t, members)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	scheduleDeleteUserQueryMock := "UPDATE users SET status = \\$2, deactivated_at = COALESCE\\(deactivated_at, CURRENT_TIMESTAMP\\), delete_scheduled_at = \\$3, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
+	mock.ExpectExec(scheduleDeleteUserQueryMock).
+		WithArgs(UserID, constant.USER_STATUS_DEACTIVE, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	res, errs := svc.DeleteAccount(ctx, UserID)
+	assert.Nil(t, errs)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), res.DeleteScheduledAt, time.Minute)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginReactivateAccount(t *testing.T) {
+	svc, mock, _, _, _ := newFakeSenderTestService(t, magicLinkTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	token, err := svc.buildMagicLinkToken("nonce")
+	assert.NoError(t, err)
+	hashedNonce, err := svc.hasher.Hash("nonce")
+	assert.NoError(t, err)
+
+	getOneTimePasswordLogByCodeMock := "SELECT id, user_id, onetime_password_type, code, status, created_at, otp_limit FROM one_time_password_logs WHERE onetime_password_type = \\$1 AND code = \\$2 LIMIT 1"
+	mock.ExpectQuery(getOneTimePasswordLogByCodeMock).
+		WithArgs(constant.OTP_TYPE_MAGIC_LINK, hashedNonce).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "code", "status", "created_at", "otp_limit"}).
+			AddRow("log_id_1", UserID, constant.OTP_TYPE_MAGIC_LINK, hashedNonce, constant.OTP_STATUS_UNUSED, time.Now().UTC(), 3))
+
+	useOneTimePasswordLogMock := "UPDATE one_time_password_logs SET status = 'USED', updated_at = \\$2 WHERE id = \\$1 AND status = 'UNUSED'"
+	mock.ExpectExec(useOneTimePasswordLogMock).WithArgs("log_id_1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	getUserByIdQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone", "email", "status", "created_at", "updated_at"}).
+			AddRow(UserID, nil, "test@example.com", constant.USER_STATUS_DEACTIVE, time.Now(), nil))
+
+	reactivateUserQueryMock := "UPDATE users SET status = \\$2, deactivated_at = NULL, delete_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
+	mock.ExpectExec(reactivateUserQueryMock).
+		WithArgs(UserID, constant.USER_STATUS_ACTIVE).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	profile := testProfile(UserID)
+	profile.Name = sql.NullString{}
+	profile.Gender = sql.NullString{}
+	expectProfileByUserID(mock, UserID, profile)
+	expectLoginFailureCleared(mock, UserID)
+
+	res, errs := svc.LoginWithMagicLink(ctx, &domain.MagicLinkRequest{Token: token})
+	assert.Nil(t, errs)
+	assert.NotEmpty(t, res.AccessToken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_purge"
+	profileID := "profile_id_purge"
+
+	photoKey := photoKeyPrefix(profileID) + "/photo.png"
+	assert.NoError(t, svc.blobStore.Put(ctx, photoKey, strings.NewReader("png"), 3, "image/png"))
+	selfieKey := verificationKeyPrefix(profileID) + "/selfie.jpg"
+	assert.NoError(t, svc.blobStore.Put(ctx, selfieKey, strings.NewReader("jpg"), 3, "image/jpeg"))
+
+	getUserIDsScheduledForDeletionQueryMock := "SELECT id FROM users WHERE status = \\$1 AND delete_scheduled_at <= \\$2 ORDER BY delete_scheduled_at LIMIT \\$3"
+	mock.ExpectQuery(getUserIDsScheduledForDeletionQueryMock).
+		WithArgs(constant.USER_STATUS_DEACTIVE, sqlmock.AnyArg(), purgeBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(UserID))
+
+	profile := testProfile(UserID)
+	profile.ID = profileID
+	profile.Name = sql.NullString{}
+	profile.Gender = sql.NullString{}
+	profile.Photos = validString(photoKey)
+	expectProfileByUserID(mock, UserID, profile)
+
+	mock.ExpectQuery(getProfileVerificationsQueryMock).WithArgs(profileID).
+		WillReturnRows(sqlmock.NewRows(profileVerificationColumnsMock).
+			AddRow("verification_id_1", profileID, "wave", selfieKey, 0.95, true, constant.VERIFICATION_STATUS_APPROVED.String(), nil, time.Now(), time.Now()))
+
+	expectDeleteUser(mock, UserID)
+
+	errs := svc.PurgeDeletedAccounts(ctx)
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err := svc.blobStore.Get(ctx, photoKey)
+	assert.Equal(t, blobstorepkg.ErrNotFound, err)
+	_, err = svc.blobStore.Get(ctx, selfieKey)
+	assert.Equal(t, blobstorepkg.ErrNotFound, err)
+}
+
+func TestPurgeDeletedAccountsContinueAfterFailure(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+
+	getUserIDsScheduledForDeletionQueryMock := "SELECT id FROM users WHERE status = \\$1 AND delete_scheduled_at <= \\$2 ORDER BY delete_scheduled_at LIMIT \\$3"
+	mock.ExpectQuery(getUserIDsScheduledForDeletionQueryMock).
+		WithArgs(constant.USER_STATUS_DEACTIVE, sqlmock.AnyArg(), purgeBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("user_id_1").AddRow("user_id_2"))
+
+	// first account fail to delete and stay scheduled
+	mock.ExpectQuery(getProfileByUserIDQueryMock).WithArgs("user_id_1").
+		WillReturnError(errors.New("connection reset"))
+
+	profile := testProfile("user_id_2")
+	profile.ID = "profile_id_2"
+	expectProfileByUserID(mock, "user_id_2", profile)
+	mock.ExpectQuery(getProfileVerificationsQueryMock).WithArgs("profile_id_2").
+		WillReturnRows(sqlmock.NewRows(profileVerificationColumnsMock))
+	expectDeleteUser(mock, "user_id_2")
+
+	errs := svc.PurgeDeletedAccounts(ctx)
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
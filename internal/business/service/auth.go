@@ -2,45 +2,99 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"github.com/ijlik/dating-user/internal/adapter/repository"
 	"github.com/ijlik/dating-user/internal/business/domain"
-	"github.com/ijlik/dating-user/pkg/auth"
 	"github.com/ijlik/dating-user/pkg/constant"
-	ctxsdk "github.com/ijlik/dating-user/pkg/context"
 	errpkg "github.com/ijlik/dating-user/pkg/error"
-	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
+	commonmath "github.com/ijlik/dating-user/pkg/math"
 	"strings"
 	"time"
 )
 
-func buildDefaultOnboardingSteps() string {
-	onboardingSteps := [][]string{
-		{"personal-info", "pending"},
-		{"photos", "pending"},
-		{"hobby-and-interest", "pending"},
-		{"location", "pending"},
+// findUser lookup user by phone for phone channel and by email for email channel
+func (s *service) findUser(
+	ctx context.Context,
+	email, phone string,
+	otpType constant.OneTimePasswordType,
+) (*repository.User, error) {
+	if otpType == constant.OTP_TYPE_EMAIL {
+		return s.repo.GetUserByEmail(ctx, email)
+	}
+
+	return s.repo.GetUserByPhone(ctx, phone)
+}
+
+// generateOtpCode fixed length code, OTP_CHARSET is numeric (default) or
+// alphanumeric
+func (s *service) generateOtpCode() *commonmath.MathResponse {
+	length := s.config.GetInt("OTP_LENGTH")
+	if length == 0 {
+		length = 6
 	}
-	var steps string
-	for i := 0; i < len(onboardingSteps); i++ {
-		steps += fmt.Sprintf("%s:%s,", onboardingSteps[i][0], onboardingSteps[i][1])
+
+	if s.config.GetString("OTP_CHARSET") == "alphanumeric" {
+		return s.math.AlphaNumericCode(length)
+	}
+
+	return s.math.NumericCode(length)
+}
+
+// normalizeOtpCode alphanumeric code is generated in upper case, user may
+// type it in lower case or paste it with spaces
+func normalizeOtpCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// boundOtpCode code is hashed together with the phone or email it was sent
+// to, so it only confirm that target even when another one is pending
+// meanwhile
+func boundOtpCode(target, code string) string {
+	return fmt.Sprintf("%s:%s", target, code)
+}
+
+func (s *service) otpExpiry() time.Duration {
+	expiry := s.config.GetInt("OTP_EXPIRY_TIME_IN_MINUTE")
+	if expiry == 0 {
+		expiry = 5
 	}
-	steps = strings.TrimSuffix(steps, ",")
-	return steps
+
+	return time.Duration(expiry) * time.Minute
 }
 
 func (s *service) sendOtpAction(
 	ctx context.Context,
 	user *repository.User,
+	otpType constant.OneTimePasswordType,
 ) (int, error) {
-	var otpNumber = s.math.RandomNumberWithLen(0)
+	sender, ok := s.otpSender.Get(otpType)
+	if !ok {
+		return 0, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			fmt.Sprintf("otp channel %s is not supported", otpType.String()),
+		)
+	}
+
+	recipient := user.Email.String
+	if otpType != constant.OTP_TYPE_EMAIL {
+		recipient = user.Phone.String
+	}
+
+	otpNumber := s.generateOtpCode()
+	if !otpNumber.Valid {
+		return 0, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			"failed to generate otp",
+		)
+	}
 	interval := s.config.GetInt("RESEND_OTP_INTERVAL")
 	if interval == 0 {
 		interval = 60
 	}
 	start := s.time.Now().UTC().Add(time.Duration(-1*interval) * time.Second)
 	end := s.time.Now().UTC()
-	countOtp, err := s.repo.GetCountOneTimePasswordByTime(ctx, user.ID, constant.OTP_TYPE_EMAIL.String(), start, end)
+	countOtp, err := s.repo.GetCountOneTimePasswordByTime(ctx, user.ID, otpType.String(), start, end)
 	if err != nil {
 		return 0, errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
@@ -52,14 +106,15 @@ func (s *service) sendOtpAction(
 		return interval, nil
 	}
 
-	err = s.mailer.Send(
-		mailerpkg.LOGIN,
-		user.Email,
-		map[string]interface{}{
-			"Code": otpNumber.String(),
-		},
-	)
+	hashedCode, err := s.hasher.Hash(otpNumber.String())
+	if err != nil {
+		return 0, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
 
+	err = sender.Send(ctx, recipient, otpNumber.String())
 	if err != nil {
 		return 0, errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
@@ -71,8 +126,8 @@ func (s *service) sendOtpAction(
 
 	err = s.repo.CreateOneTimePasswordLog(ctx, &repository.OneTimePasswordLog{
 		UserID:              user.ID,
-		OneTimePasswordType: constant.OTP_TYPE_EMAIL,
-		Code:                otpNumber.String(),
+		OneTimePasswordType: otpType,
+		Code:                hashedCode,
 		Status:              constant.OTP_STATUS_UNUSED,
 		CreatedAt:           s.time.Now(),
 		OTPLimit:            otpLimit,
@@ -88,6 +143,61 @@ func (s *service) sendOtpAction(
 	return interval, nil
 }
 
+// checkOtpCode validate code of the latest otp log of the type against the
+// target it was sent to, wrong code consume one try. Caller mark the log as used once its action succeed.
+// Caller is already logged in, so failure is bounded by the otp try limit
+// and does not count toward the login lockout
+func (s *service) checkOtpCode(
+	ctx context.Context,
+	UserID string,
+	otpType constant.OneTimePasswordType,
+	target, code string,
+) (*repository.OneTimePasswordLog, errpkg.ErrorService) {
+	otp, err := s.repo.GetOneTimePasswordLogByUserAndType(ctx, UserID, otpType.String())
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if otp == nil || otp.Status != constant.OTP_STATUS_UNUSED {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrUnauthorize,
+			"Invalid OTP",
+		)
+	}
+
+	if otp.OTPLimit <= 0 || s.time.Now().After(otp.CreatedAt.Add(s.otpExpiry())) {
+		err = s.repo.UpdateStatusOneTimePasswordLog(ctx, constant.OTP_STATUS_EXPIRED, otp.ID, 0)
+		if err != nil {
+			return nil, errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrUnauthorize,
+			"OTP Expired",
+		)
+	}
+
+	if !s.hasher.Compare(boundOtpCode(target, normalizeOtpCode(code)), otp.Code) {
+		err = s.repo.UpdateStatusOneTimePasswordLog(ctx, constant.OTP_STATUS_UNUSED, otp.ID, otp.OTPLimit-1)
+		if err != nil {
+			return nil, errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrUnauthorize,
+			"Invalid OTP",
+		)
+	}
+
+	return otp, nil
+}
+
 func (s *service) ResendOtp(
 	ctx context.Context,
 	req *domain.ResendOtpRequest,
@@ -95,7 +205,7 @@ func (s *service) ResendOtp(
 	var interval int
 	var user *repository.User
 	var err error
-	user, err = s.repo.GetUserByEmail(ctx, req.Email)
+	user, err = s.findUser(ctx, req.Email, req.Phone, req.OtpType)
 	if err != nil {
 		return nil, errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
@@ -104,10 +214,14 @@ func (s *service) ResendOtp(
 	}
 
 	if user == nil {
-		user, err = s.repo.CreateUser(ctx, &repository.CreateUser{
-			Email:           req.Email,
-			OnboardingSteps: buildDefaultOnboardingSteps(),
-		})
+		createUser := &repository.CreateUser{}
+		if req.OtpType == constant.OTP_TYPE_EMAIL {
+			createUser.Email = sql.NullString{String: req.Email, Valid: true}
+		} else {
+			createUser.Phone = sql.NullString{String: req.Phone, Valid: true}
+		}
+
+		user, err = s.repo.CreateUser(ctx, createUser)
 		if err != nil {
 			return nil, errpkg.DefaultServiceError(
 				errpkg.ErrInternal,
@@ -117,7 +231,26 @@ func (s *service) ResendOtp(
 
 	}
 
-	interval, err = s.sendOtpAction(ctx, user)
+	if errs := s.checkLockout(ctx, user); errs != nil {
+		return nil, errs
+	}
+
+	if req.MagicLink {
+		interval, err = s.sendMagicLinkAction(ctx, user)
+		if err != nil {
+			return nil, errpkg.DefaultServiceError(
+				errpkg.ErrFailedToSendDeeplink,
+				err.Error(),
+			)
+		}
+
+		return &domain.ResendOtpResponse{
+			Message:           fmt.Sprintf("Login link already sent to your email: %s.", req.Email),
+			ResendOTPInterval: interval,
+		}, nil
+	}
+
+	interval, err = s.sendOtpAction(ctx, user, req.OtpType)
 	if err != nil {
 		return nil, errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
@@ -125,8 +258,13 @@ func (s *service) ResendOtp(
 		)
 	}
 
+	message := fmt.Sprintf("OTP already sent to your email: %s.", req.Email)
+	if req.OtpType != constant.OTP_TYPE_EMAIL {
+		message = fmt.Sprintf("OTP already sent to your phone: %s.", req.Phone)
+	}
+
 	return &domain.ResendOtpResponse{
-		Message:           fmt.Sprintf("OTP already sent to your email: %s.", req.Email),
+		Message:           message,
 		ResendOTPInterval: interval,
 	}, nil
 }
@@ -135,21 +273,37 @@ func (s *service) LoginOrRegister(
 	ctx context.Context,
 	req *domain.AuthRequest,
 ) (*domain.AuthResponse, errpkg.ErrorService) {
-	user, err := s.repo.GetUserByEmail(ctx, req.Email)
+	user, err := s.findUser(ctx, req.Email, req.Phone, req.OtpType)
 	if err != nil {
 		return nil, errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
 			err.Error(),
 		)
 	}
+	if user == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrUnauthorize,
+			"Invalid OTP",
+		)
+	}
+
+	if errs := s.checkLockout(ctx, user); errs != nil {
+		return nil, errs
+	}
 
-	otp, err := s.repo.GetOneTimePasswordLogByUserAndType(ctx, user.ID, constant.OTP_TYPE_EMAIL.String())
+	otp, err := s.repo.GetOneTimePasswordLogByUserAndType(ctx, user.ID, req.OtpType.String())
 	if err != nil {
 		return nil, errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
 			err.Error(),
 		)
 	}
+	if otp == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrUnauthorize,
+			"Invalid OTP",
+		)
+	}
 	// Validate expired OTP by max attempt try
 	if otp.OTPLimit <= 0 {
 		err := s.repo.UpdateStatusOneTimePasswordLog(ctx, constant.OTP_STATUS_EXPIRED, otp.ID, 0)
@@ -165,8 +319,8 @@ func (s *service) LoginOrRegister(
 		)
 	}
 
-	// validate same otp code
-	if req.Otp != otp.Code {
+	// validate same otp code, only hash of the code is stored
+	if !s.hasher.Compare(normalizeOtpCode(req.Otp), otp.Code) {
 		err := s.repo.UpdateStatusOneTimePasswordLog(ctx, constant.OTP_STATUS_UNUSED, otp.ID, otp.OTPLimit-1)
 		if err != nil {
 			return nil, errpkg.DefaultServiceError(
@@ -174,6 +328,19 @@ func (s *service) LoginOrRegister(
 				err.Error(),
 			)
 		}
+
+		// new code does not reset the ledger, only successful login does
+		lockedUntil, err := s.recordLoginFailure(ctx, user)
+		if err != nil {
+			return nil, errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+		if lockedUntil != nil {
+			return nil, temporaryBlockedError(*lockedUntil)
+		}
+
 		return nil, errpkg.DefaultServiceError(
 			errpkg.ErrUnauthorize,
 			"Invalid OTP",
@@ -207,8 +374,32 @@ func (s *service) LoginOrRegister(
 	}
 
 	// OTP VALID
+	res, errs := s.completeLogin(ctx, user, req.DeviceInfo)
+	if errs != nil {
+		return nil, errs
+	}
+	// Update Otp Status
+	err = s.repo.UpdateStatusOneTimePasswordLog(ctx, constant.OTP_STATUS_USED, otp.ID, otp.OTPLimit-1)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return res, nil
+}
+
+// completeLogin activate new user, create the session and issue tokens once
+// user identity is verified (otp code or magic link)
+func (s *service) completeLogin(
+	ctx context.Context,
+	user *repository.User,
+	device domain.DeviceInfo,
+) (*domain.AuthResponse, errpkg.ErrorService) {
 	// Find or Create Profile
 	var profile *repository.Profile
+	var err error
 	if user.Status == constant.USER_STATUS_UNVERIFIED {
 		// Update User Status
 		err = s.repo.UpdateStatusUser(ctx, &repository.UpdateStatus{
@@ -230,6 +421,18 @@ func (s *service) LoginOrRegister(
 			)
 		}
 	} else {
+		// login reactivate deactivated account and cancel scheduled deletion
+		if user.Status == constant.USER_STATUS_DEACTIVE {
+			err = s.repo.ReactivateUser(ctx, user.ID)
+			if err != nil {
+				return nil, errpkg.DefaultServiceError(
+					errpkg.ErrInternal,
+					err.Error(),
+				)
+			}
+			user.Status = constant.USER_STATUS_ACTIVE
+		}
+
 		profile, err = s.repo.GetProfileByUserID(ctx, user.ID)
 		if err != nil {
 			return nil, errpkg.DefaultServiceError(
@@ -238,63 +441,33 @@ func (s *service) LoginOrRegister(
 			)
 		}
 	}
-	// Create token
-	privateKey := s.config.GetString("TOKEN_SECRET_KEY")
-	if privateKey == "" {
-		return nil, errpkg.DefaultServiceError(
-			errpkg.ErrInternal,
-			"internal server error",
-		)
-	}
-	expireTokenLimitConfig := time.Minute * time.Duration(s.config.GetInt("LOGIN_INTERVAL_LIMIT"))
-	var mapData = map[ctxsdk.ContextMetadata]string{
-		ctxsdk.USER_ID:    user.ID,
-		ctxsdk.EMAIL:      user.Email,
-		ctxsdk.PROFILE_ID: profile.ID,
-	}
-	accessToken, err := auth.CreateToken(expireTokenLimitConfig, &mapData, privateKey)
-	if err != nil {
-		return nil, errpkg.DefaultServiceError(
-			errpkg.ErrInternal,
-			"internal server error create token",
-		)
-	}
-	// Set session in redis
-	loginInterval := s.config.GetInt("LOGIN_INTERVAL_LIMIT")
-	err = s.redis.Set(ctx, accessToken, user.ID, loginInterval)
+	// successful login clear the failure ledger, next lockout start from
+	// the first window again
+	err = s.repo.DeleteLoginFailure(ctx, user.ID)
 	if err != nil {
 		return nil, errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
-			"failed to send redis :"+err.Error(),
+			err.Error(),
 		)
 	}
-	// Update Otp Status
-	err = s.repo.UpdateStatusOneTimePasswordLog(ctx, constant.OTP_STATUS_USED, otp.ID, otp.OTPLimit-1)
+
+	// Register new device session and create access and refresh token
+	session, err := s.newSession(user.ID, device)
 	if err != nil {
 		return nil, errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
 			err.Error(),
 		)
 	}
-
-	return &domain.AuthResponse{
-		AccessToken: accessToken,
-	}, nil
-}
-
-func (s *service) Logout(
-	ctx context.Context,
-	UserID string,
-) errpkg.ErrorService {
-	err := s.redis.Del(ctx, UserID)
+	res, err := s.issueTokens(ctx, user, profile, session)
 	if err != nil {
-		return errpkg.DefaultServiceError(
+		return nil, errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
-			"",
+			"internal server error create token",
 		)
 	}
 
-	return nil
+	return res, nil
 }
 
 func (s *service) ShowProfile(
@@ -316,6 +489,14 @@ func (s *service) ShowProfile(
 		)
 	}
 
+	onboardingSteps, err := s.repo.GetOnboardingStepsByUserID(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
 	dailyCount, err := s.repo.GetSwipesCount(ctx, data.ID)
 	if err != nil {
 		return nil, errpkg.DefaultServiceError(
@@ -324,5 +505,9 @@ func (s *service) ShowProfile(
 		)
 	}
 
-	return ProfileRes(data, user, dailyCount), nil
+	profile := ProfileRes(data, user, OnboardingStepsRes(onboardingSteps), dailyCount)
+	if errs := s.withPhotoURLs(photoRenditionFull, profile); errs != nil {
+		return nil, errs
+	}
+	return profile, nil
 }
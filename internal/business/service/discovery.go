@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+)
+
+// profileAge full years since birth date, zero when unknown
+func (s *service) profileAge(profile *repository.Profile) int {
+	if !profile.BirthDate.Valid {
+		return 0
+	}
+
+	return ageAt(profile.BirthDate.Time, s.time.Now())
+}
+
+// ageAt full years from birth to now, birthday is compared by month and day
+// since day of year shift after february on leap year
+func ageAt(birth, now time.Time) int {
+	age := now.Year() - birth.Year()
+	if now.Month() < birth.Month() || (now.Month() == birth.Month() && now.Day() < birth.Day()) {
+		age--
+	}
+
+	return age
+}
+
+// defaultDiscoveryPreferences age range around the age of the user, distance
+// from DISCOVERY_DEFAULT_MAX_DISTANCE_KM
+func (s *service) defaultDiscoveryPreferences(profile *repository.Profile) *repository.DiscoveryPreferences {
+	ageRange := s.config.GetInt("DISCOVERY_DEFAULT_AGE_RANGE")
+	if ageRange == 0 {
+		ageRange = 10
+	}
+	distance := s.config.GetInt("DISCOVERY_DEFAULT_MAX_DISTANCE_KM")
+	if distance == 0 {
+		distance = 50
+	}
+
+	minAge, maxAge := domain.MinDiscoveryAge, domain.MaxDiscoveryAge
+	if age := s.profileAge(profile); age > 0 {
+		if age-ageRange > minAge {
+			minAge = age - ageRange
+		}
+		if age+ageRange < maxAge {
+			maxAge = age + ageRange
+		}
+	}
+
+	return &repository.DiscoveryPreferences{
+		UserID:        profile.UserID,
+		MinAge:        minAge,
+		MaxAge:        maxAge,
+		MaxDistanceKm: distance,
+	}
+}
+
+// createDefaultDiscoveryPreferences called once onboarding is completed
+func (s *service) createDefaultDiscoveryPreferences(
+	ctx context.Context,
+	UserID string,
+) errpkg.ErrorService {
+	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if profile == nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"profile not found",
+		)
+	}
+
+	err = s.repo.CreateDefaultDiscoveryPreferences(ctx, s.defaultDiscoveryPreferences(profile))
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return nil
+}
+
+// GetDiscoveryPreferences default preferences are shown until saved
+func (s *service) GetDiscoveryPreferences(
+	ctx context.Context,
+	UserID string,
+) (*domain.DiscoveryPreferences, errpkg.ErrorService) {
+	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if profile == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"profile not found",
+		)
+	}
+
+	preferences, err := s.repo.GetDiscoveryPreferences(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if preferences == nil {
+		preferences = s.defaultDiscoveryPreferences(profile)
+	}
+
+	return DiscoveryPreferencesRes(preferences, profile), nil
+}
+
+// UpdateDiscoveryPreferences genders are saved as the "show me" of the
+// profile
+func (s *service) UpdateDiscoveryPreferences(
+	ctx context.Context,
+	UserID string,
+	req *domain.UpdateDiscoveryPreferencesRequest,
+) (*domain.DiscoveryPreferences, errpkg.ErrorService) {
+	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if profile == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"profile not found",
+		)
+	}
+
+	ok, err := s.repo.PatchProfile(ctx, &repository.PatchProfile{
+		ID:        profile.ID,
+		ShowMe:    sql.NullString{String: strings.Join(req.Genders, ","), Valid: true},
+		UpdatedAt: profile.UpdatedAt,
+	})
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !ok {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrPreconditionFailed,
+			"profile was modified, reload and retry",
+		)
+	}
+	profile.ShowMe = sql.NullString{String: strings.Join(req.Genders, ","), Valid: true}
+
+	preferences := &repository.DiscoveryPreferences{
+		UserID:        UserID,
+		MinAge:        req.MinAge,
+		MaxAge:        req.MaxAge,
+		MaxDistanceKm: req.MaxDistanceKm,
+		VerifiedOnly:  req.VerifiedOnly,
+	}
+	err = s.repo.UpsertDiscoveryPreferences(ctx, preferences)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return DiscoveryPreferencesRes(preferences, profile), nil
+}
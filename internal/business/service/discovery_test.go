@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	configdata "github.com/ijlik/dating-user/pkg/config/data"
+	"github.com/ijlik/dating-user/pkg/constant"
+	"github.com/ijlik/dating-user/pkg/geohash"
+	"github.com/stretchr/testify/assert"
+)
+
+const getDiscoveryPreferencesQueryMock = "SELECT user_id, min_age, max_age, max_distance_km, verified_only, created_at, updated_at FROM discovery_preferences WHERE user_id = \\$1 LIMIT 1"
+
+var discoveryPreferencesColumns = []string{"user_id", "min_age", "max_age", "max_distance_km", "created_at", "updated_at"}
+
+func TestCompleteOnboardingCreateDefaultDiscoveryPreferences(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	config := svc.config.(*configdata.ConfigData)
+	config.Data["DISCOVERY_DEFAULT_MAX_DISTANCE_KM"] = "30"
+	ctx := context.Background()
+	UserID := "user_id_1"
+	birthDate := svc.time.Now().AddDate(-25, -1, 0)
+
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO, constant.ONBOARDING_STEP_PHOTOS, constant.ONBOARDING_STEP_HOBBY_AND_INTEREST)
+	profile := testProfile(UserID)
+	profile.BirthDate = sql.NullTime{Time: birthDate, Valid: true}
+	profile.Photos = validString("photo.png")
+	expectProfileByUserID(mock, UserID, profile)
+	mock.ExpectExec("UPDATE profiles SET latitude = \\$2, longitude = \\$3, geohash = \\$4, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1").
+		WithArgs("profile_id_1", -6.2, 106.8, geohash.Encode(-6.2, 106.8, profileGeohashPrecision)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO user_onboarding_steps \\(user_id, step, status, completed_at, created_at\\)").
+		WithArgs(UserID, constant.ONBOARDING_STEP_LOCATION, constant.ONBOARDING_STEP_STATUS_DONE).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// location was the last required step
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO, constant.ONBOARDING_STEP_PHOTOS, constant.ONBOARDING_STEP_HOBBY_AND_INTEREST, constant.ONBOARDING_STEP_LOCATION)
+	located := *profile
+	located.Latitude = sql.NullFloat64{Float64: -6.2, Valid: true}
+	located.Longitude = sql.NullFloat64{Float64: 106.8, Valid: true}
+	expectProfileByUserID(mock, UserID, &located)
+	mock.ExpectExec("INSERT INTO discovery_preferences \\(user_id, min_age, max_age, max_distance_km, verified_only, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, CURRENT_TIMESTAMP\\) ON CONFLICT \\(user_id\\) DO NOTHING").
+		WithArgs(UserID, 18, 35, 30, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errs := svc.UpdateLocation(ctx, &domain.Location{Longitude: "106.8", Latitude: "-6.2", Lon: 106.8, Lat: -6.2}, UserID)
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDiscoveryPreferencesDefault(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	birthDate := svc.time.Now().AddDate(-40, -1, 0)
+
+	profile := testProfile(UserID)
+	profile.BirthDate = sql.NullTime{Time: birthDate, Valid: true}
+	profile.ShowMe = validString("Female,Non-binary")
+	expectProfileByUserID(mock, UserID, profile)
+	mock.ExpectQuery(getDiscoveryPreferencesQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(discoveryPreferencesColumns))
+
+	preferences, errs := svc.GetDiscoveryPreferences(ctx, UserID)
+	assert.Nil(t, errs)
+	assert.Equal(t, &domain.DiscoveryPreferences{
+		MinAge:        30,
+		MaxAge:        50,
+		MaxDistanceKm: 50,
+		Genders:       []string{"Female", "Non-binary"},
+	}, preferences)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateDiscoveryPreferences(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	version := time.Now()
+
+	profile := testProfile(UserID)
+	profile.CreatedAt = version
+	profile.UpdatedAt = sql.NullTime{Time: version, Valid: true}
+	expectProfileByUserID(mock, UserID, profile)
+	mock.ExpectExec(patchProfileQueryMock).
+		WithArgs("profile_id_1", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "Female", version).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO discovery_preferences \\(user_id, min_age, max_age, max_distance_km, verified_only, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, CURRENT_TIMESTAMP\\) ON CONFLICT \\(user_id\\) DO UPDATE").
+		WithArgs(UserID, 24, 32, 0, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	req := &domain.UpdateDiscoveryPreferencesRequest{MinAge: 24, MaxAge: 32, MaxDistanceKm: 0, VerifiedOnly: true, Genders: []string{"female"}}
+	assert.Nil(t, req.Validate())
+
+	preferences, errs := svc.UpdateDiscoveryPreferences(ctx, UserID, req)
+	assert.Nil(t, errs)
+	assert.Equal(t, []string{"Female"}, preferences.Genders)
+	assert.True(t, preferences.VerifiedOnly)
+	assert.Equal(t, 0, preferences.MaxDistanceKm)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateDiscoveryPreferencesValidate(t *testing.T) {
+	assert.NotNil(t, (&domain.UpdateDiscoveryPreferencesRequest{MinAge: 17, MaxAge: 30}).Validate())
+	assert.NotNil(t, (&domain.UpdateDiscoveryPreferencesRequest{MinAge: 30, MaxAge: 25}).Validate())
+	assert.NotNil(t, (&domain.UpdateDiscoveryPreferencesRequest{MinAge: 18, MaxAge: 30, MaxDistanceKm: 1000}).Validate())
+	assert.NotNil(t, (&domain.UpdateDiscoveryPreferencesRequest{MinAge: 18, MaxAge: 30, Genders: []string{"Robot"}}).Validate())
+	assert.Nil(t, (&domain.UpdateDiscoveryPreferencesRequest{MinAge: 18, MaxAge: 99}).Validate())
+}
+
+func TestAgeAt(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	// birthday after february of a leap year
+	assert.Equal(t, 26, ageAt(date(2000, time.March, 1), date(2026, time.March, 1)))
+	assert.Equal(t, 25, ageAt(date(2000, time.March, 1), date(2026, time.February, 28)))
+	assert.Equal(t, 24, ageAt(date(2001, time.March, 1), date(2025, time.March, 1)))
+	assert.Equal(t, 27, ageAt(date(1997, time.March, 1), date(2024, time.March, 1)))
+	assert.Equal(t, 26, ageAt(date(1997, time.March, 2), date(2024, time.March, 1)))
+	// born on leap day
+	assert.Equal(t, 25, ageAt(date(2000, time.February, 29), date(2026, time.February, 28)))
+	assert.Equal(t, 26, ageAt(date(2000, time.February, 29), date(2026, time.March, 1)))
+}
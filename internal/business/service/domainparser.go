@@ -2,21 +2,34 @@ package service
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/ijlik/dating-user/internal/adapter/repository"
 	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func ProfilesFeeds(data []*repository.Profile) []*domain.Profile {
 	var result []*domain.Profile
 	for _, item := range data {
-		result = append(result, ProfileRes(item, &repository.User{}, 0))
+		profile := ProfileRes(item, &repository.User{}, nil, 0)
+		// who a user is looking for is private to them
+		profile.Orientation = ""
+		profile.ShowMe = nil
+		// other users only see how far away the profile roughly is
+		profile.Location = nil
+		if item.DistanceKm.Valid {
+			profile.DistanceKm = domain.ApproximateDistanceKm(item.DistanceKm.Float64)
+		}
+		result = append(result, profile)
 	}
 	return result
 }
 
-func ProfileRes(data *repository.Profile, user *repository.User, dailyCount int) *domain.Profile {
+func ProfileRes(data *repository.Profile, user *repository.User, onboardingSteps []domain.OnboardingSteps, dailyCount int) *domain.Profile {
 	var photos []string
 	if data.Photos.String != "" {
 		photos = strings.Split(data.Photos.String, ",")
@@ -31,15 +44,6 @@ func ProfileRes(data *repository.Profile, user *repository.User, dailyCount int)
 	if data.Interest.String != "" {
 		interest = strings.Split(data.Interest.String, ",")
 	}
-	var location *domain.Location
-	if data.Location.String != "" {
-		coordinate := strings.Split(data.Location.String, ":")
-		location = &domain.Location{
-			Longitude: coordinate[0],
-			Latitude:  coordinate[1],
-			Url:       fmt.Sprintf("https://www.google.com/maps?q=%s,%s", coordinate[0], coordinate[1]),
-		}
-	}
 
 	return &domain.Profile{
 		ID:                  data.ID,
@@ -50,18 +54,71 @@ func ProfileRes(data *repository.Profile, user *repository.User, dailyCount int)
 		Photos:              photos,
 		Hobby:               hobby,
 		Interest:            interest,
-		Location:            location,
+		Location:            LocationRes(data),
 		IsPremium:           data.IsPremium,
 		IsPremiumValidUntil: data.GetIsPremiumValidUntil(),
 		DailySwapQuota:      data.DailySwapQuota - dailyCount,
+		IsVerified:          data.IsVerified,
 		CreatedAt:           data.CreatedAt,
 		UpdatedAt:           data.GetUpdatedAt(),
 		User: &domain.User{
-			Email:           user.Email,
+			Email:           user.Email.String,
+			Phone:           user.VerifiedPhone(),
 			Status:          user.Status.String(),
-			OnboardingSteps: user.GetOnboardingSteps(),
+			OnboardingSteps: onboardingSteps,
 		},
+		Bio:       data.Bio.String,
+		JobTitle:  data.JobTitle.String,
+		Company:   data.Company.String,
+		Education: data.Education.String,
+		HeightCm:  int(data.HeightCm.Int32),
+		Languages: splitNullString(data.Languages),
+		Prompts:   profilePromptAnswersRes(data.Prompts),
+
+		GenderCustom: data.GenderCustom.String,
+		Orientation:  data.Orientation.String,
+		ShowMe:       splitNullString(data.ShowMe),
+	}
+}
+
+// profilePromptAnswersRes answers are stored as json, unreadable value is
+// shown as no answer
+func profilePromptAnswersRes(data sql.NullString) []domain.ProfilePromptAnswer {
+	if data.String == "" {
+		return nil
 	}
+
+	var result []domain.ProfilePromptAnswer
+	if err := json.Unmarshal([]byte(data.String), &result); err != nil {
+		return nil
+	}
+	return result
+}
+
+func DiscoveryPreferencesRes(data *repository.DiscoveryPreferences, profile *repository.Profile) *domain.DiscoveryPreferences {
+	genders := splitNullString(profile.ShowMe)
+	if genders == nil {
+		genders = []string{}
+	}
+
+	return &domain.DiscoveryPreferences{
+		MinAge:        data.MinAge,
+		MaxAge:        data.MaxAge,
+		MaxDistanceKm: data.MaxDistanceKm,
+		Genders:       genders,
+		VerifiedOnly:  data.VerifiedOnly,
+	}
+}
+
+func ProfilePromptsRes(data []*repository.ProfilePrompt) []*domain.ProfilePrompt {
+	result := []*domain.ProfilePrompt{}
+	for _, item := range data {
+		result = append(result, &domain.ProfilePrompt{
+			ID:       item.ID,
+			Question: item.Question,
+		})
+	}
+	return result
 }
 
 func UpdateProfileInfoReq(req *domain.UpdatePersonalInfo, profileId string) *repository.UpdateProfileInfo {
@@ -72,6 +129,265 @@ func UpdateProfileInfoReq(req *domain.UpdatePersonalInfo, profileId string) *rep
 			Time:  req.BirthDate,
 			Valid: true,
 		},
-		Gender: req.Gender,
+		Gender:       req.Gender,
+		GenderCustom: sql.NullString{String: req.GenderCustom, Valid: req.GenderCustom != ""},
+		Orientation:  sql.NullString{String: req.Orientation, Valid: req.Orientation != ""},
+		ShowMe:       sql.NullString{String: strings.Join(req.ShowMe, ","), Valid: len(req.ShowMe) > 0},
+	}
+}
+
+func nullTimeRes(data sql.NullTime) *time.Time {
+	if !data.Valid {
+		return nil
+	}
+	return &data.Time
+}
+
+func splitNullString(data sql.NullString) []string {
+	if data.String == "" {
+		return nil
+	}
+	return strings.Split(data.String, ",")
+}
+
+func ExportUserRes(data *repository.User, onboardingSteps []*repository.OnboardingStep) *domain.ExportUser {
+	return &domain.ExportUser{
+		ID:              data.ID,
+		Email:           data.Email.String,
+		Phone:           data.Phone.String,
+		Status:          data.Status.String(),
+		OnboardingSteps: OnboardingStepsRes(onboardingSteps),
+		CreatedAt:       data.CreatedAt,
+		UpdatedAt:       nullTimeRes(data.UpdatedAt),
+	}
+}
+
+func LocationRes(data *repository.Profile) *domain.Location {
+	if !data.Latitude.Valid || !data.Longitude.Valid {
+		return nil
+	}
+	longitude := strconv.FormatFloat(data.Longitude.Float64, 'f', -1, 64)
+	latitude := strconv.FormatFloat(data.Latitude.Float64, 'f', -1, 64)
+	return &domain.Location{
+		Longitude: longitude,
+		Latitude:  latitude,
+		Url:       fmt.Sprintf("https://www.google.com/maps?q=%s,%s", longitude, latitude),
+	}
+}
+
+func ExportProfileRes(data *repository.Profile) *domain.ExportProfile {
+	return &domain.ExportProfile{
+		ID:                  data.ID,
+		Name:                data.Name.String,
+		BirthDate:           nullTimeRes(data.BirthDate),
+		Gender:              data.Gender.String,
+		Photos:              splitNullString(data.Photos),
+		Hobby:               splitNullString(data.Hobby),
+		Interest:            splitNullString(data.Interest),
+		Location:            LocationRes(data),
+		IsPremium:           data.IsPremium,
+		IsPremiumValidUntil: nullTimeRes(data.IsPremiumValidUntil),
+		DailySwapQuota:      data.DailySwapQuota,
+		IsVerified:          data.IsVerified,
+		CreatedAt:           data.CreatedAt,
+		UpdatedAt:           nullTimeRes(data.UpdatedAt),
+		Bio:                 data.Bio.String,
+		JobTitle:            data.JobTitle.String,
+		Company:             data.Company.String,
+		Education:           data.Education.String,
+		HeightCm:            int(data.HeightCm.Int32),
+		Languages:           splitNullString(data.Languages),
+		Prompts:             profilePromptAnswersRes(data.Prompts),
+		GenderCustom:        data.GenderCustom.String,
+		Orientation:         data.Orientation.String,
+		ShowMe:              splitNullString(data.ShowMe),
+	}
+}
+
+func VerificationRes(data *repository.ProfileVerification) *domain.Verification {
+	return &domain.Verification{
+		ID:         data.ID,
+		Pose:       data.Pose,
+		Status:     data.Status.String(),
+		CreatedAt:  data.CreatedAt,
+		ReviewedAt: nullTimeRes(data.ReviewedAt),
+	}
+}
+
+func nullFloatRes(data sql.NullFloat64) *float64 {
+	if !data.Valid {
+		return nil
+	}
+	return &data.Float64
+}
+
+func nullBoolRes(data sql.NullBool) *bool {
+	if !data.Valid {
+		return nil
+	}
+	return &data.Bool
+}
+
+func ExportVerificationsRes(data []*repository.ProfileVerification) []*domain.ExportVerification {
+	result := []*domain.ExportVerification{}
+	for _, item := range data {
+		result = append(result, &domain.ExportVerification{
+			ID:          item.ID,
+			Pose:        item.Pose,
+			Selfie:      verificationExportPath(item.SelfieKey),
+			Similarity:  nullFloatRes(item.Similarity),
+			PoseMatched: nullBoolRes(item.PoseMatched),
+			Status:      item.Status.String(),
+			CreatedAt:   item.CreatedAt,
+			ReviewedAt:  nullTimeRes(item.ReviewedAt),
+		})
+	}
+	return result
+}
+
+func ExportSwipesRes(data []*repository.Swipe) []*domain.ExportSwipe {
+	result := []*domain.ExportSwipe{}
+	for _, item := range data {
+		result = append(result, &domain.ExportSwipe{
+			ID:        item.ID,
+			SwipedID:  item.SwipedId,
+			IsLike:    item.IsLike.Bool,
+			CreatedAt: item.CreatedAt,
+		})
+	}
+	return result
+}
+
+func ExportReceivedSwipesRes(data []*repository.Swipe) []*domain.ExportReceivedSwipe {
+	result := []*domain.ExportReceivedSwipe{}
+	for _, item := range data {
+		result = append(result, &domain.ExportReceivedSwipe{
+			ID:        item.ID,
+			IsLike:    item.IsLike.Bool,
+			CreatedAt: item.CreatedAt,
+		})
 	}
+	return result
+}
+
+func ExportOneTimePasswordLogsRes(data []*repository.OneTimePasswordLog) []*domain.ExportOneTimePasswordLog {
+	result := []*domain.ExportOneTimePasswordLog{}
+	for _, item := range data {
+		result = append(result, &domain.ExportOneTimePasswordLog{
+			ID:        item.ID,
+			Type:      item.OneTimePasswordType.String(),
+			Status:    item.Status.String(),
+			CreatedAt: item.CreatedAt,
+			UpdatedAt: nullTimeRes(item.UpdatedAt),
+		})
+	}
+	return result
+}
+
+func ExportPaymentsRes(data []*repository.Payment) []*domain.ExportPayment {
+	result := []*domain.ExportPayment{}
+	for _, item := range data {
+		result = append(result, &domain.ExportPayment{
+			ID:            item.ID,
+			Amount:        item.Amount,
+			Identifier:    item.Identifier,
+			PaymentMethod: string(item.PaymentMethod),
+			Status:        item.Status.String(),
+			CreatedAt:     item.CreatedAt,
+			UpdatedAt:     nullTimeRes(item.UpdatedAt),
+		})
+	}
+	return result
+}
+
+func ExportLoginFailuresRes(data *repository.LoginFailure) []*domain.ExportLoginFailure {
+	result := []*domain.ExportLoginFailure{}
+	if data == nil {
+		return result
+	}
+
+	return append(result, &domain.ExportLoginFailure{
+		FailedAttempts: data.FailedAttempts,
+		LockoutLevel:   data.LockoutLevel,
+		LockedUntil:    nullTimeRes(data.LockedUntil),
+		LastFailedAt:   nullTimeRes(data.LastFailedAt),
+	})
+}
+
+func EmailChangesRes(data []*repository.EmailChange) []*domain.EmailChange {
+	result := []*domain.EmailChange{}
+	for _, item := range data {
+		result = append(result, &domain.EmailChange{
+			ID:          item.ID,
+			OldEmail:    item.OldEmail,
+			NewEmail:    item.NewEmail,
+			Status:      item.Status.String(),
+			CreatedAt:   item.CreatedAt,
+			ConfirmedAt: nullTimeRes(item.ConfirmedAt),
+			CancelledAt: nullTimeRes(item.CancelledAt),
+		})
+	}
+	return result
+}
+
+// OnboardingStepsRes every step of onboardingFlow with the user progress
+func OnboardingStepsRes(rows []*repository.OnboardingStep) []domain.OnboardingSteps {
+	done := doneOnboardingSteps(rows)
+
+	var result []domain.OnboardingSteps
+	for _, definition := range onboardingFlow {
+		item := domain.OnboardingSteps{
+			Step:     definition.Step.String(),
+			Status:   constant.ONBOARDING_STEP_STATUS_PENDING.String(),
+			Required: definition.Required,
+		}
+		for _, dependency := range definition.DependsOn {
+			item.DependsOn = append(item.DependsOn, dependency.String())
+		}
+		if row, ok := done[definition.Step]; ok {
+			item.Status = row.Status.String()
+			item.CompletedAt = nullTimeRes(row.CompletedAt)
+		}
+		result = append(result, item)
+	}
+
+	return result
+}
+
+func OnboardingProgressRes(rows []*repository.OnboardingStep) *domain.OnboardingProgress {
+	done := doneOnboardingSteps(rows)
+	progress := &domain.OnboardingProgress{
+		Steps:       OnboardingStepsRes(rows),
+		TotalSteps:  len(onboardingFlow),
+		IsCompleted: true,
+	}
+
+	for _, definition := range onboardingFlow {
+		if _, ok := done[definition.Step]; ok {
+			progress.CompletedSteps++
+			continue
+		}
+		if !definition.Required {
+			continue
+		}
+
+		progress.IsCompleted = false
+		progress.MissingSteps = append(progress.MissingSteps, definition.Step.String())
+		if progress.NextStep != "" {
+			continue
+		}
+
+		ready := true
+		for _, dependency := range definition.DependsOn {
+			if _, ok := done[dependency]; !ok {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			progress.NextStep = definition.Step.String()
+		}
+	}
+
+	return progress
 }
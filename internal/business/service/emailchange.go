@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
+)
+
+// emailChangeRevertPeriod cancel link of the old address still restore it
+// for this long after the change is confirmed
+func (s *service) emailChangeRevertPeriod() time.Duration {
+	days := s.config.GetInt("EMAIL_CHANGE_REVERT_IN_DAY")
+	if days == 0 {
+		days = 7
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func (s *service) buildEmailChangeCancelUrl(token string) (string, error) {
+	baseUrl := s.config.GetString("EMAIL_CHANGE_BASE_URL")
+	if baseUrl == "" {
+		return "", errors.New("missing email change base url")
+	}
+
+	return fmt.Sprintf("%s/account/email/cancel/%s", baseUrl, token), nil
+}
+
+// emailHolder return user which already own the email, unverified holder is
+// a registration which never logged in and does not block the email
+func (s *service) emailHolder(
+	ctx context.Context,
+	UserID, email string,
+) (*repository.User, errpkg.ErrorService) {
+	holder, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	if holder == nil || holder.ID == UserID {
+		return nil, nil
+	}
+
+	if holder.Status != constant.USER_STATUS_UNVERIFIED {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrAlreadyRegistered,
+			"email is already registered",
+		)
+	}
+
+	return holder, nil
+}
+
+// sendNewEmailOtp code is mailed directly and bound to the address, resend
+// interval of login otp does not apply since every request target a new
+// address
+func (s *service) sendNewEmailOtp(
+	ctx context.Context,
+	UserID, email string,
+) error {
+	otpNumber := s.generateOtpCode()
+	if !otpNumber.Valid {
+		return errors.New("failed to generate otp")
+	}
+
+	hashedCode, err := s.hasher.Hash(boundOtpCode(email, otpNumber.String()))
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(mailerpkg.EMAIL_CHANGE_VERIFY, email, map[string]interface{}{
+		"Code":           otpNumber.String(),
+		"ExpiryInMinute": int(s.otpExpiry().Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.repo.CreateOneTimePasswordLog(ctx, &repository.OneTimePasswordLog{
+		UserID:              UserID,
+		OneTimePasswordType: constant.OTP_TYPE_NEW_EMAIL,
+		Code:                hashedCode,
+		Status:              constant.OTP_STATUS_UNUSED,
+		CreatedAt:           s.time.Now(),
+		OTPLimit:            s.config.GetInt("OTP_MAX_TRY_LIMIT"),
+	})
+}
+
+// RequestEmailChange send otp to the new address and a notice with cancel
+// link to the current one, email is swapped by ConfirmEmailChange
+func (s *service) RequestEmailChange(
+	ctx context.Context,
+	UserID string,
+	req *domain.ChangeEmailRequest,
+) (*domain.ResendOtpResponse, errpkg.ErrorService) {
+	user, err := s.repo.GetUserById(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if user == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"user not found",
+		)
+	}
+	if !user.Email.Valid {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"account has no email to change",
+		)
+	}
+	if user.Email.String == req.Email {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"new email is the same as current email",
+		)
+	}
+
+	if _, errs := s.emailHolder(ctx, UserID, req.Email); errs != nil {
+		return nil, errs
+	}
+
+	cancel := s.math.AlphaNumericRandom(32)
+	if !cancel.Valid {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			"failed to generate cancel token",
+		)
+	}
+	hashedCancel, err := s.hasher.Hash(cancel.String())
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	link, err := s.buildEmailChangeCancelUrl(cancel.String())
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	// change is only stored once the code reached the new address, earlier
+	// pending change stay in place when sending fail
+	if err = s.sendNewEmailOtp(ctx, UserID, req.Email); err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	_, err = s.repo.CreateEmailChange(ctx, &repository.CreateEmailChange{
+		UserID:      UserID,
+		OldEmail:    user.Email.String,
+		NewEmail:    req.Email,
+		CancelToken: hashedCancel,
+	})
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	err = s.mailer.Send(mailerpkg.EMAIL_CHANGE_NOTICE, user.Email.String, map[string]interface{}{
+		"NewEmail":    req.Email,
+		"Link":        link,
+		"RevertInDay": int(s.emailChangeRevertPeriod().Hours() / 24),
+	})
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	interval := s.config.GetInt("RESEND_OTP_INTERVAL")
+	if interval == 0 {
+		interval = 60
+	}
+
+	return &domain.ResendOtpResponse{
+		Message:           fmt.Sprintf("OTP already sent to your new email: %s.", req.Email),
+		ResendOTPInterval: interval,
+	}, nil
+}
+
+// ConfirmEmailChange swap the email once the otp of new address is verified,
+// every session is revoked so the user login again with the new email
+func (s *service) ConfirmEmailChange(
+	ctx context.Context,
+	UserID string,
+	req *domain.ConfirmEmailChangeRequest,
+) errpkg.ErrorService {
+	change, err := s.repo.GetPendingEmailChange(ctx, UserID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if change == nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"no email change waiting for confirmation",
+		)
+	}
+
+	otp, errs := s.checkOtpCode(ctx, UserID, constant.OTP_TYPE_NEW_EMAIL, change.NewEmail, req.Otp)
+	if errs != nil {
+		return errs
+	}
+
+	// email may be taken while waiting for the code
+	holder, errs := s.emailHolder(ctx, UserID, change.NewEmail)
+	if errs != nil {
+		return errs
+	}
+	if holder != nil {
+		err = s.repo.DeleteUser(ctx, holder.ID)
+		if err != nil {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+	}
+
+	err = s.repo.ConfirmEmailChange(ctx, change)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	err = s.repo.UpdateStatusOneTimePasswordLog(ctx, constant.OTP_STATUS_USED, otp.ID, otp.OTPLimit-1)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	err = s.revokeAllSessions(ctx, UserID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return nil
+}
+
+// CancelEmailChange opened from the link sent to the old address, pending
+// change is cancelled and confirmed change is reverted within revert period
+func (s *service) CancelEmailChange(
+	ctx context.Context,
+	token string,
+) errpkg.ErrorService {
+	hashedCancel, err := s.hasher.Hash(token)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	change, err := s.repo.GetEmailChangeByCancelToken(ctx, hashedCancel)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if change == nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"invalid cancel link",
+		)
+	}
+
+	switch {
+	case change.Status == constant.EMAIL_CHANGE_STATUS_PENDING:
+		err = s.repo.CancelEmailChange(ctx, change.ID)
+		if err != nil {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+
+		return nil
+	case change.Status == constant.EMAIL_CHANGE_STATUS_CONFIRMED &&
+		s.time.Now().Before(change.ConfirmedAt.Time.Add(s.emailChangeRevertPeriod())):
+		holder, errs := s.emailHolder(ctx, change.UserID, change.OldEmail)
+		if errs != nil {
+			return errs
+		}
+		if holder != nil {
+			err = s.repo.DeleteUser(ctx, holder.ID)
+			if err != nil {
+				return errpkg.DefaultServiceError(
+					errpkg.ErrInternal,
+					err.Error(),
+				)
+			}
+		}
+
+		err = s.repo.RevertEmailChange(ctx, change)
+		if err != nil {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+
+		// whoever confirmed the change is logged out
+		err = s.revokeAllSessions(ctx, change.UserID)
+		if err != nil {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+
+		return nil
+	default:
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"cancel link is no longer valid",
+		)
+	}
+}
+
+// GetEmailChanges every email change of the user, used by support to trace
+// account takeover
+func (s *service) GetEmailChanges(
+	ctx context.Context,
+	UserID string,
+) ([]*domain.EmailChange, errpkg.ErrorService) {
+	changes, err := s.repo.GetEmailChangesByUserID(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return EmailChangesRes(changes), nil
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
+	sessionpkg "github.com/ijlik/dating-user/pkg/session"
+	"github.com/stretchr/testify/assert"
+)
+
+var emailChangeTestConfig = map[string]interface{}{
+	"EMAIL_CHANGE_BASE_URL": "https://api.dating.example.com",
+	"OTP_MAX_TRY_LIMIT":     "3",
+}
+
+var emailChangeColumns = []string{"id", "user_id", "old_email", "new_email", "cancel_token", "status", "created_at", "confirmed_at", "cancelled_at"}
+
+func TestRequestEmailChange(t *testing.T) {
+	svc, mock, _, mailer, _ := newFakeSenderTestService(t, emailChangeTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	newEmail := "new@example.com"
+
+	getUserByIdQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(UserID, nil, "old@example.com", constant.USER_STATUS_ACTIVE, time.Now(), nil))
+
+	getUserByEmailQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE email = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByEmailQueryMock).WithArgs(newEmail).
+		WillReturnRows(sqlmock.NewRows(userColumns))
+
+	updatePreviousUnusedOtpToExpiredMock := "UPDATE one_time_password_logs SET status = 'EXPIRED' WHERE status = 'UNUSED' AND user_id = \\$1"
+	mock.ExpectExec(updatePreviousUnusedOtpToExpiredMock).
+		WithArgs(UserID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	createOneTimePasswordLogMock := "INSERT INTO one_time_password_logs \\(user_id, onetime_password_type, code, status, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5\\)"
+	mock.ExpectExec(createOneTimePasswordLogMock).
+		WithArgs(UserID, constant.OTP_TYPE_NEW_EMAIL, hashedCodeArg{hasher: svc.hasher, code: func() string {
+			return boundOtpCode(newEmail, mailer.sent[0].param.(map[string]interface{})["Code"].(string))
+		}}, constant.OTP_STATUS_UNUSED, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE email_changes SET status = 'CANCELLED'").WithArgs(UserID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO email_changes").
+		WithArgs(UserID, "old@example.com", newEmail, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("change_id_1"))
+	mock.ExpectCommit()
+
+	res, errs := svc.RequestEmailChange(ctx, UserID, &domain.ChangeEmailRequest{Email: newEmail})
+	assert.Nil(t, errs)
+	assert.Equal(t, 60, res.ResendOTPInterval)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// code goes to the new address, cancel link to the current one
+	assert.Len(t, mailer.sent, 2)
+	assert.Equal(t, mailerpkg.EMAIL_CHANGE_VERIFY, mailer.sent[0].mail)
+	assert.Equal(t, newEmail, mailer.sent[0].recipient)
+	assert.Equal(t, mailerpkg.EMAIL_CHANGE_NOTICE, mailer.sent[1].mail)
+	assert.Equal(t, "old@example.com", mailer.sent[1].recipient)
+
+	link := mailer.sent[1].param.(map[string]interface{})["Link"].(string)
+	assert.True(t, strings.HasPrefix(link, "https://api.dating.example.com/account/email/cancel/"))
+}
+
+func TestRequestEmailChangeAlreadyRegistered(t *testing.T) {
+	svc, mock, _, mailer, _ := newFakeSenderTestService(t, emailChangeTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	newEmail := "new@example.com"
+
+	getUserByIdQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(UserID, nil, "old@example.com", constant.USER_STATUS_ACTIVE, time.Now(), nil))
+
+	getUserByEmailQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE email = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByEmailQueryMock).WithArgs(newEmail).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow("user_id_2", nil, newEmail, constant.USER_STATUS_ACTIVE, time.Now(), nil))
+
+	_, errs := svc.RequestEmailChange(ctx, UserID, &domain.ChangeEmailRequest{Email: newEmail})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrAlreadyRegistered, errs.GetCode())
+	assert.Empty(t, mailer.sent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	svc, mock, rdb, _, _ := newFakeSenderTestService(t, emailChangeTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	newEmail := "new@example.com"
+	issueTestTokens(t, svc, UserID, "Pixel 7")
+
+	getPendingEmailChangeQueryMock := "SELECT id, user_id, old_email, new_email, cancel_token, status, created_at, confirmed_at, cancelled_at FROM email_changes WHERE user_id = \\$1 AND status = 'PENDING'"
+	mock.ExpectQuery(getPendingEmailChangeQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(emailChangeColumns).
+			AddRow("change_id_1", UserID, "old@example.com", newEmail, "hashed_token", constant.EMAIL_CHANGE_STATUS_PENDING, time.Now(), nil, nil))
+
+	hashedCode, err := svc.hasher.Hash(boundOtpCode(newEmail, "A1B2C3"))
+	assert.NoError(t, err)
+
+	getOneTimePasswordLogByUserAndTypeMock := "SELECT id, user_id, onetime_password_type, code, status, created_at, otp_limit FROM one_time_password_logs WHERE user_id = \\$1 AND onetime_password_type = \\$2 ORDER BY created_at DESC LIMIT 1"
+	mock.ExpectQuery(getOneTimePasswordLogByUserAndTypeMock).
+		WithArgs(UserID, constant.OTP_TYPE_NEW_EMAIL).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "code", "status", "created_at", "otp_limit"}).
+			AddRow("log_id_1", UserID, constant.OTP_TYPE_NEW_EMAIL, hashedCode, constant.OTP_STATUS_UNUSED, time.Now().UTC(), 3))
+
+	getUserByEmailQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE email = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByEmailQueryMock).WithArgs(newEmail).
+		WillReturnRows(sqlmock.NewRows(userColumns))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE email_changes SET status = 'CONFIRMED'").WithArgs("change_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET email = \\$2").WithArgs(UserID, newEmail).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	updateStatusOtpLogQueryMock := "UPDATE one_time_password_logs SET status = \\$1, updated_at = \\$2, otp_limit = \\$3 WHERE id = \\$4"
+	mock.ExpectExec(updateStatusOtpLogQueryMock).
+		WithArgs(constant.OTP_STATUS_USED, sqlmock.AnyArg(), 2, "log_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errs := svc.ConfirmEmailChange(ctx, UserID, &domain.ConfirmEmailChangeRequest{Otp: " a1b2c3 "})
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// every session has to login again with the new email
+	sessions, _ := rdb.SMembers(ctx, sessionpkg.UserKey(UserID))
+	assert.Empty(t, sessions)
+}
+
+func TestConfirmEmailChangeOtherAddress(t *testing.T) {
+	svc, mock, _, _, _ := newFakeSenderTestService(t, emailChangeTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	getPendingEmailChangeQueryMock := "SELECT id, user_id, old_email, new_email, cancel_token, status, created_at, confirmed_at, cancelled_at FROM email_changes WHERE user_id = \\$1 AND status = 'PENDING'"
+	mock.ExpectQuery(getPendingEmailChangeQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(emailChangeColumns).
+			AddRow("change_id_2", UserID, "old@example.com", "other@example.com", "hashed_token", constant.EMAIL_CHANGE_STATUS_PENDING, time.Now(), nil, nil))
+
+	// code was mailed to the address of an earlier request
+	hashedCode, err := svc.hasher.Hash(boundOtpCode("new@example.com", "A1B2C3"))
+	assert.NoError(t, err)
+
+	getOneTimePasswordLogByUserAndTypeMock := "SELECT id, user_id, onetime_password_type, code, status, created_at, otp_limit FROM one_time_password_logs WHERE user_id = \\$1 AND onetime_password_type = \\$2 ORDER BY created_at DESC LIMIT 1"
+	mock.ExpectQuery(getOneTimePasswordLogByUserAndTypeMock).
+		WithArgs(UserID, constant.OTP_TYPE_NEW_EMAIL).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "code", "status", "created_at", "otp_limit"}).
+			AddRow("log_id_1", UserID, constant.OTP_TYPE_NEW_EMAIL, hashedCode, constant.OTP_STATUS_UNUSED, time.Now().UTC(), 3))
+
+	updateStatusOtpLogQueryMock := "UPDATE one_time_password_logs SET status = \\$1, updated_at = \\$2, otp_limit = \\$3 WHERE id = \\$4"
+	mock.ExpectExec(updateStatusOtpLogQueryMock).
+		WithArgs(constant.OTP_STATUS_UNUSED, sqlmock.AnyArg(), 2, "log_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errs := svc.ConfirmEmailChange(ctx, UserID, &domain.ConfirmEmailChangeRequest{Otp: "A1B2C3"})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrUnauthorize, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelEmailChange(t *testing.T) {
+	svc, mock, _, _, _ := newFakeSenderTestService(t, emailChangeTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	hashedCancel, err := svc.hasher.Hash("cancel_token")
+	assert.NoError(t, err)
+
+	getEmailChangeByCancelTokenQueryMock := "SELECT id, user_id, old_email, new_email, cancel_token, status, created_at, confirmed_at, cancelled_at FROM email_changes WHERE cancel_token = \\$1 LIMIT 1"
+	mock.ExpectQuery(getEmailChangeByCancelTokenQueryMock).WithArgs(hashedCancel).
+		WillReturnRows(sqlmock.NewRows(emailChangeColumns).
+			AddRow("change_id_1", UserID, "old@example.com", "new@example.com", hashedCancel, constant.EMAIL_CHANGE_STATUS_PENDING, time.Now(), nil, nil))
+
+	mock.ExpectExec("UPDATE email_changes SET status = 'CANCELLED'").WithArgs("change_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errs := svc.CancelEmailChange(ctx, "cancel_token")
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelEmailChangeRevert(t *testing.T) {
+	svc, mock, rdb, _, _ := newFakeSenderTestService(t, emailChangeTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	issueTestTokens(t, svc, UserID, "Pixel 7")
+
+	hashedCancel, err := svc.hasher.Hash("cancel_token")
+	assert.NoError(t, err)
+
+	getEmailChangeByCancelTokenQueryMock := "SELECT id, user_id, old_email, new_email, cancel_token, status, created_at, confirmed_at, cancelled_at FROM email_changes WHERE cancel_token = \\$1 LIMIT 1"
+	mock.ExpectQuery(getEmailChangeByCancelTokenQueryMock).WithArgs(hashedCancel).
+		WillReturnRows(sqlmock.NewRows(emailChangeColumns).
+			AddRow("change_id_1", UserID, "old@example.com", "new@example.com", hashedCancel, constant.EMAIL_CHANGE_STATUS_CONFIRMED, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour), nil))
+
+	getUserByEmailQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE email = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByEmailQueryMock).WithArgs("old@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE email_changes SET status = 'REVERTED'").WithArgs("change_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET email = \\$2").WithArgs(UserID, "old@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	errs := svc.CancelEmailChange(ctx, "cancel_token")
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// whoever confirmed the change lose access
+	sessions, _ := rdb.SMembers(ctx, sessionpkg.UserKey(UserID))
+	assert.Empty(t, sessions)
+}
+
+func TestCancelEmailChangeRevertPeriodOver(t *testing.T) {
+	svc, mock, _, _, _ := newFakeSenderTestService(t, emailChangeTestConfig)
+	ctx := context.Background()
+
+	hashedCancel, err := svc.hasher.Hash("cancel_token")
+	assert.NoError(t, err)
+
+	confirmedAt := time.Now().Add(-8 * 24 * time.Hour)
+	getEmailChangeByCancelTokenQueryMock := "SELECT id, user_id, old_email, new_email, cancel_token, status, created_at, confirmed_at, cancelled_at FROM email_changes WHERE cancel_token = \\$1 LIMIT 1"
+	mock.ExpectQuery(getEmailChangeByCancelTokenQueryMock).WithArgs(hashedCancel).
+		WillReturnRows(sqlmock.NewRows(emailChangeColumns).
+			AddRow("change_id_1", "user_id_1", "old@example.com", "new@example.com", hashedCancel, constant.EMAIL_CHANGE_STATUS_CONFIRMED, confirmedAt, confirmedAt, nil))
+
+	errs := svc.CancelEmailChange(ctx, "cancel_token")
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrInvalidToken, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
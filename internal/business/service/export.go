@@ -0,0 +1,455 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"time"
+
+	"github.com/ijlik/dating-user/internal/adapter/redis"
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	blobstorepkg "github.com/ijlik/dating-user/pkg/blobstore"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
+)
+
+const (
+	dataExportStatusPending = "PENDING"
+
+	// dataExportPendingTimeout release the pending flag when the export job
+	// died without cleaning it up
+	dataExportPendingTimeout = time.Hour
+)
+
+func (s *service) dataExportExpiry() time.Duration {
+	expiry := s.config.GetInt("DATA_EXPORT_EXPIRY_IN_HOUR")
+	if expiry == 0 {
+		expiry = 24
+	}
+
+	return time.Duration(expiry) * time.Hour
+}
+
+// dataExportsKey set of export id which archive is in the blob store, the
+// archive is purged once its data_export key is expired
+const dataExportsKey = "data_exports"
+
+// dataExportBlobKey archive is kept in the blob store, so any instance can
+// serve and purge it
+func dataExportBlobKey(id string) string {
+	return fmt.Sprintf("exports/%s.zip", id)
+}
+
+func dataExportKey(id string) string {
+	return fmt.Sprintf("data_export:%s", id)
+}
+
+func dataExportPendingKey(UserID string) string {
+	return fmt.Sprintf("data_export_pending:%s", UserID)
+}
+
+func (s *service) signDataExport(id string, expires int64) (string, error) {
+	secret := s.config.GetString("DATA_EXPORT_SECRET")
+	if secret == "" {
+		return "", errors.New("missing data export secret")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%s.%d", id, expires)))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// buildDataExportUrl signed download url, valid until expires
+func (s *service) buildDataExportUrl(id string, expires time.Time) (string, error) {
+	baseUrl := s.config.GetString("DATA_EXPORT_BASE_URL")
+	if baseUrl == "" {
+		return "", errors.New("missing data export base url")
+	}
+
+	signature, err := s.signDataExport(id, expires.Unix())
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("expires", fmt.Sprintf("%d", expires.Unix()))
+	query.Set("signature", signature)
+
+	return fmt.Sprintf("%s/account/export/%s?%s", baseUrl, id, query.Encode()), nil
+}
+
+// RequestDataExport start building the archive in background, download link
+// is sent by email once it is ready
+func (s *service) RequestDataExport(
+	ctx context.Context,
+	UserID string,
+) (*domain.DataExportResponse, errpkg.ErrorService) {
+	user, err := s.repo.GetUserById(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if user == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"user not found",
+		)
+	}
+	if !user.Email.Valid {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"email is required to receive the data export",
+		)
+	}
+
+	id := s.math.AlphaNumericRandom(32)
+	if !id.Valid {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			"failed to generate data export id",
+		)
+	}
+
+	ok, err := s.redis.SetNX(ctx, dataExportPendingKey(UserID), id.String(), dataExportPendingTimeout)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !ok {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrTemporaryBlocked,
+			"data export is already in progress",
+		)
+	}
+
+	go s.processDataExport(context.Background(), user, id.String())
+
+	return &domain.DataExportResponse{
+		ID:     id.String(),
+		Status: dataExportStatusPending,
+	}, nil
+}
+
+// processDataExport build the archive and send the download link, failure is
+// only logged since nobody is waiting for it
+func (s *service) processDataExport(
+	ctx context.Context,
+	user *repository.User,
+	id string,
+) {
+	defer func() {
+		if err := s.redis.Del(ctx, dataExportPendingKey(user.ID)); err != nil {
+			log.Println("failed to release data export: ", err)
+		}
+	}()
+
+	err := s.buildDataExport(ctx, user, id)
+	if err != nil {
+		log.Println("failed to build data export: ", err)
+		return
+	}
+
+	expiry := s.dataExportExpiry()
+	err = s.redis.SetWithExpiration(ctx, dataExportKey(id), user.ID, expiry)
+	if err == nil {
+		err = s.redis.SAdd(ctx, dataExportsKey, id)
+	}
+	if err != nil {
+		log.Println("failed to store data export: ", err)
+		// archive nobody can download is not left behind
+		if err = s.blobStore.Delete(ctx, dataExportBlobKey(id)); err != nil {
+			log.Println("failed to remove data export: ", err)
+		}
+		return
+	}
+
+	link, err := s.buildDataExportUrl(id, s.time.Now().Add(expiry))
+	if err != nil {
+		log.Println("failed to sign data export: ", err)
+		return
+	}
+
+	err = s.mailer.Send(mailerpkg.DATA_EXPORT, user.Email.String, map[string]interface{}{
+		"Link":         link,
+		"ExpiryInHour": int(expiry.Hours()),
+	})
+	if err != nil {
+		log.Println("failed to send data export: ", err)
+	}
+}
+
+func (s *service) buildDataExport(
+	ctx context.Context,
+	user *repository.User,
+	id string,
+) (err error) {
+	profile, err := s.repo.GetProfileByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	onboardingSteps, err := s.repo.GetOnboardingStepsByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	otpLogs, err := s.repo.GetOneTimePasswordLogsByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	payments, err := s.repo.GetPaymentsByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	emailChanges, err := s.repo.GetEmailChangesByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	loginFailure, err := s.repo.GetLoginFailure(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	// swipes are made and received by the profile, not the user
+	var swipes, receivedSwipes []*repository.Swipe
+	var verifications []*repository.ProfileVerification
+	var discoveryPreferences *repository.DiscoveryPreferences
+	if profile != nil {
+		swipes, err = s.repo.GetSwipesBySwiperId(ctx, profile.ID)
+		if err != nil {
+			return err
+		}
+
+		receivedSwipes, err = s.repo.GetSwipesBySwipedId(ctx, profile.ID)
+		if err != nil {
+			return err
+		}
+
+		verifications, err = s.repo.GetProfileVerifications(ctx, profile.ID)
+		if err != nil {
+			return err
+		}
+
+		discoveryPreferences, err = s.repo.GetDiscoveryPreferences(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// archive is built in a temporary file and uploaded once complete, so
+	// download never sees a partial archive
+	file, err := os.CreateTemp("", "data-export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	archive := zip.NewWriter(file)
+	documents := map[string]interface{}{
+		"user.json":            ExportUserRes(user, onboardingSteps),
+		"swipes.json":          ExportSwipesRes(swipes),
+		"swipes_received.json": ExportReceivedSwipesRes(receivedSwipes),
+		"otp_logs.json":        ExportOneTimePasswordLogsRes(otpLogs),
+		"payments.json":        ExportPaymentsRes(payments),
+		"email_changes.json":   EmailChangesRes(emailChanges),
+		"login_failures.json":  ExportLoginFailuresRes(loginFailure),
+	}
+	if profile != nil {
+		documents["profile.json"] = ExportProfileRes(profile)
+		documents["verifications.json"] = ExportVerificationsRes(verifications)
+	}
+	// default preferences are not stored until the user save them
+	if discoveryPreferences != nil {
+		documents["discovery_preferences.json"] = DiscoveryPreferencesRes(discoveryPreferences, profile)
+	}
+
+	for name, document := range documents {
+		if err = writeJsonEntry(archive, name, document); err != nil {
+			return err
+		}
+	}
+
+	if profile != nil {
+		if err = s.writeBlobEntries(ctx, archive, "photos", splitNullString(profile.Photos)); err != nil {
+			return err
+		}
+
+		var selfies []string
+		for _, verification := range verifications {
+			selfies = append(selfies, verification.SelfieKey)
+		}
+		if err = s.writeBlobEntries(ctx, archive, "verifications", selfies); err != nil {
+			return err
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		return err
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return s.blobStore.Put(ctx, dataExportBlobKey(id), file, size, "application/zip")
+}
+
+func writeJsonEntry(archive *zip.Writer, name string, document interface{}) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(document)
+}
+
+// verificationExportPath selfie of a verification inside the archive
+func verificationExportPath(key string) string {
+	return "verifications/" + path.Base(key)
+}
+
+// writeBlobEntries copy every stored object into dir of the archive
+func (s *service) writeBlobEntries(ctx context.Context, archive *zip.Writer, dir string, keys []string) error {
+	for _, key := range keys {
+		src, err := s.blobStore.Get(ctx, key)
+		if err == blobstorepkg.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		entry, err := archive.Create(dir + "/" + path.Base(key))
+		if err != nil {
+			src.Close()
+			return err
+		}
+
+		_, err = io.Copy(entry, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetDataExport validate the signed url and return content of the archive
+func (s *service) GetDataExport(
+	ctx context.Context,
+	req *domain.DownloadDataExportRequest,
+) (io.ReadCloser, errpkg.ErrorService) {
+	expected, err := s.signDataExport(req.ID, req.Expires)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	if !hmac.Equal([]byte(expected), []byte(req.Signature)) {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"invalid download signature",
+		)
+	}
+
+	if s.time.Now().Unix() > req.Expires {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"download link is expired",
+		)
+	}
+
+	_, err = s.redis.Get(ctx, dataExportKey(req.ID))
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"data export not found",
+		)
+	}
+
+	body, err := s.blobStore.Get(ctx, dataExportBlobKey(req.ID))
+	if err == blobstorepkg.ErrNotFound || err == blobstorepkg.ErrInvalidKey {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"data export not found",
+		)
+	}
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return body, nil
+}
+
+// PurgeExpiredDataExports remove archive which download link is over, the
+// archive stay listed until its removal succeed
+func (s *service) PurgeExpiredDataExports(ctx context.Context) errpkg.ErrorService {
+	ids, err := s.redis.SMembers(ctx, dataExportsKey)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	for _, id := range ids {
+		_, err = s.redis.Get(ctx, dataExportKey(id))
+		if err == nil {
+			continue
+		}
+		if err != redis.Nil {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+
+		err = s.blobStore.Delete(ctx, dataExportBlobKey(id))
+		if err == nil {
+			err = s.redis.SRem(ctx, dataExportsKey, id)
+		}
+		if err != nil {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	blobstorepkg "github.com/ijlik/dating-user/pkg/blobstore"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+)
+
+var dataExportTestConfig = map[string]interface{}{
+	"DATA_EXPORT_SECRET":   "export_secret",
+	"DATA_EXPORT_BASE_URL": "https://api.dating.example.com",
+}
+
+func TestProcessDataExport(t *testing.T) {
+	svc, mock, rdb, mailer, _ := newFakeSenderTestService(t, dataExportTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_export"
+	profileID := "profile_id_export"
+	id := "export_id_1"
+
+	photoKey := photoKeyPrefix(profileID) + "/photo.png"
+	assert.NoError(t, svc.blobStore.Put(ctx, photoKey, strings.NewReader("png"), 3, "image/png"))
+	selfieKey := verificationKeyPrefix(profileID) + "/selfie.jpg"
+	assert.NoError(t, svc.blobStore.Put(ctx, selfieKey, strings.NewReader("jpg"), 3, "image/jpeg"))
+
+	profile := testProfile(UserID)
+	profile.ID = profileID
+	profile.Gender = validString("male")
+	profile.Photos = validString(photoKey)
+	profile.Hobby = validString("hiking")
+	profile.Interest = validString("music")
+	expectProfileByUserID(mock, UserID, profile)
+
+	getOnboardingStepsByUserIDQueryMock := "SELECT user_id, step, status, completed_at, created_at FROM user_onboarding_steps WHERE user_id = \\$1"
+	mock.ExpectQuery(getOnboardingStepsByUserIDQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "step", "status", "completed_at", "created_at"}).
+			AddRow(UserID, constant.ONBOARDING_STEP_PERSONAL_INFO, constant.ONBOARDING_STEP_STATUS_DONE, time.Now(), time.Now()))
+
+	getOneTimePasswordLogsByUserIDMock := "SELECT id, user_id, onetime_password_type, status, created_at, updated_at, otp_limit FROM one_time_password_logs WHERE user_id = \\$1 ORDER BY created_at"
+	mock.ExpectQuery(getOneTimePasswordLogsByUserIDMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "status", "created_at", "updated_at", "otp_limit"}).
+			AddRow("log_id_1", UserID, constant.OTP_TYPE_EMAIL, constant.OTP_STATUS_USED, time.Now(), time.Now(), 3))
+
+	getPaymentsByUserIDQueryMock := "SELECT id, user_id, amount, identifier, payment_method, payment_data, status, created_at, updated_at FROM payments WHERE user_id = \\$1 ORDER BY created_at"
+	mock.ExpectQuery(getPaymentsByUserIDQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "amount", "identifier", "payment_method", "payment_data", "status", "created_at", "updated_at"}))
+
+	getEmailChangesByUserIDQueryMock := "SELECT id, user_id, old_email, new_email, cancel_token, status, created_at, confirmed_at, cancelled_at FROM email_changes WHERE user_id = \\$1 ORDER BY created_at DESC"
+	mock.ExpectQuery(getEmailChangesByUserIDQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(emailChangeColumns).
+			AddRow("change_id_1", UserID, "old@example.com", "test@example.com", "hashed_cancel_token", constant.EMAIL_CHANGE_STATUS_CONFIRMED, time.Now(), time.Now(), nil))
+
+	getLoginFailureQueryMock := "SELECT user_id, failed_attempts, lockout_level, locked_until, previous_status, last_failed_at FROM login_failures WHERE user_id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getLoginFailureQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(loginFailureColumns).
+			AddRow(UserID, 2, 0, nil, nil, time.Now()))
+
+	// swipes belong to the profile
+	getSwipesBySwiperIdQueryMock := "SELECT id, swiper_id, swiped_id, is_like, created_at FROM swipes WHERE swiper_id = \\$1 ORDER BY created_at"
+	mock.ExpectQuery(getSwipesBySwiperIdQueryMock).WithArgs(profileID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "swiper_id", "swiped_id", "is_like", "created_at"}).
+			AddRow("swipe_id_1", profileID, "swiped_id_1", true, time.Now()))
+
+	getSwipesBySwipedIdQueryMock := "SELECT id, swiper_id, swiped_id, is_like, created_at FROM swipes WHERE swiped_id = \\$1 ORDER BY created_at"
+	mock.ExpectQuery(getSwipesBySwipedIdQueryMock).WithArgs(profileID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "swiper_id", "swiped_id", "is_like", "created_at"}).
+			AddRow("swipe_id_2", "swiper_id_1", profileID, true, time.Now()))
+
+	mock.ExpectQuery(getProfileVerificationsQueryMock).WithArgs(profileID).
+		WillReturnRows(sqlmock.NewRows(profileVerificationColumnsMock).
+			AddRow("verification_id_1", profileID, "wave", selfieKey, 0.95, true, constant.VERIFICATION_STATUS_APPROVED.String(), nil, time.Now(), time.Now()))
+
+	mock.ExpectQuery(getDiscoveryPreferencesQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "min_age", "max_age", "max_distance_km", "verified_only", "created_at", "updated_at"}).
+			AddRow(UserID, 21, 30, 50, true, time.Now(), nil))
+
+	rdb.values[dataExportPendingKey(UserID)] = id
+	user := &repository.User{
+		ID:     UserID,
+		Email:  sql.NullString{String: "test@example.com", Valid: true},
+		Status: constant.USER_STATUS_ACTIVE,
+	}
+	svc.processDataExport(ctx, user, id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// archive holds every document and the photo
+	archive := readDataExport(t, svc, id)
+
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	assert.ElementsMatch(t, []string{"user.json", "profile.json", "swipes.json", "swipes_received.json", "otp_logs.json", "payments.json", "verifications.json", "email_changes.json", "login_failures.json", "discovery_preferences.json", "photos/photo.png", "verifications/selfie.jpg"}, names)
+
+	swipes := readArchiveEntry(t, archive, "swipes.json")
+	assert.Contains(t, swipes, `"swiped_id": "swiped_id_1"`)
+	received := readArchiveEntry(t, archive, "swipes_received.json")
+	assert.Contains(t, received, `"id": "swipe_id_2"`)
+	assert.NotContains(t, received, "swiper_id_1")
+	emailChanges := readArchiveEntry(t, archive, "email_changes.json")
+	assert.Contains(t, emailChanges, `"old_email": "old@example.com"`)
+	assert.NotContains(t, emailChanges, "hashed_cancel_token")
+	loginFailures := readArchiveEntry(t, archive, "login_failures.json")
+	assert.Contains(t, loginFailures, `"failed_attempts": 2`)
+	preferences := readArchiveEntry(t, archive, "discovery_preferences.json")
+	assert.Contains(t, preferences, `"max_distance_km": 50`)
+
+	assert.Equal(t, UserID, rdb.values[dataExportKey(id)])
+	exports, _ := rdb.SMembers(ctx, dataExportsKey)
+	assert.Equal(t, []string{id}, exports)
+	_, pending := rdb.values[dataExportPendingKey(UserID)]
+	assert.False(t, pending)
+
+	assert.Len(t, mailer.sent, 1)
+	assert.Equal(t, mailerpkg.DATA_EXPORT, mailer.sent[0].mail)
+	assert.Equal(t, "test@example.com", mailer.sent[0].recipient)
+
+	// link from email is accepted by download
+	link := mailer.sent[0].param.(map[string]interface{})["Link"].(string)
+	assert.True(t, strings.HasPrefix(link, "https://api.dating.example.com/account/export/"+id+"?"))
+	parsed, err := url.Parse(link)
+	assert.NoError(t, err)
+	expires, err := strconv.ParseInt(parsed.Query().Get("expires"), 10, 64)
+	assert.NoError(t, err)
+
+	body, errs := svc.GetDataExport(ctx, &domain.DownloadDataExportRequest{
+		ID:        id,
+		Expires:   expires,
+		Signature: parsed.Query().Get("signature"),
+	})
+	assert.Nil(t, errs)
+	defer body.Close()
+	content, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.Equal(t, "PK", string(content[:2]))
+}
+
+// readDataExport open archive of the export from the blob store
+func readDataExport(t *testing.T, svc *service, id string) *zip.Reader {
+	body, err := svc.blobStore.Get(context.Background(), dataExportBlobKey(id))
+	assert.NoError(t, err)
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	assert.NoError(t, err)
+
+	return archive
+}
+
+func readArchiveEntry(t *testing.T, archive *zip.Reader, name string) string {
+	entry, err := archive.Open(name)
+	assert.NoError(t, err)
+	defer entry.Close()
+
+	content, err := io.ReadAll(entry)
+	assert.NoError(t, err)
+
+	return string(content)
+}
+
+func TestRequestDataExportInProgress(t *testing.T) {
+	svc, mock, rdb, _, _ := newFakeSenderTestService(t, dataExportTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	getUserByIdQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone", "email", "status", "created_at", "updated_at"}).
+			AddRow(UserID, nil, "test@example.com", constant.USER_STATUS_ACTIVE, time.Now(), nil))
+
+	rdb.values[dataExportPendingKey(UserID)] = "export_id_1"
+
+	_, errs := svc.RequestDataExport(ctx, UserID)
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrTemporaryBlocked, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDataExportInvalidLink(t *testing.T) {
+	svc, _, rdb, _, _ := newFakeSenderTestService(t, dataExportTestConfig)
+	ctx := context.Background()
+	id := "export_id_1"
+	rdb.values[dataExportKey(id)] = "user_id_1"
+
+	// tampered expiry
+	expires := time.Now().Add(time.Hour).Unix()
+	signature, err := svc.signDataExport(id, expires)
+	assert.NoError(t, err)
+
+	_, errs := svc.GetDataExport(ctx, &domain.DownloadDataExportRequest{
+		ID:        id,
+		Expires:   expires + 3600,
+		Signature: signature,
+	})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrInvalidToken, errs.GetCode())
+
+	// expired link
+	expires = time.Now().Add(-time.Minute).Unix()
+	signature, err = svc.signDataExport(id, expires)
+	assert.NoError(t, err)
+
+	_, errs = svc.GetDataExport(ctx, &domain.DownloadDataExportRequest{
+		ID:        id,
+		Expires:   expires,
+		Signature: signature,
+	})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrInvalidToken, errs.GetCode())
+
+	// archive already purged
+	expires = time.Now().Add(time.Hour).Unix()
+	signature, err = svc.signDataExport(id, expires)
+	assert.NoError(t, err)
+
+	_, errs = svc.GetDataExport(ctx, &domain.DownloadDataExportRequest{
+		ID:        id,
+		Expires:   expires,
+		Signature: signature,
+	})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrNotFound, errs.GetCode())
+}
+
+func TestPurgeExpiredDataExports(t *testing.T) {
+	svc, _, rdb, _, _ := newFakeSenderTestService(t, dataExportTestConfig)
+	ctx := context.Background()
+
+	for _, id := range []string{"export_id_1", "export_id_2"} {
+		assert.NoError(t, svc.blobStore.Put(ctx, dataExportBlobKey(id), strings.NewReader("zip"), 3, "application/zip"))
+		assert.NoError(t, rdb.SAdd(ctx, dataExportsKey, id))
+	}
+	// link of export_id_1 is over
+	rdb.values[dataExportKey("export_id_2")] = "user_id_1"
+
+	errs := svc.PurgeExpiredDataExports(ctx)
+	assert.Nil(t, errs)
+
+	_, err := svc.blobStore.Get(ctx, dataExportBlobKey("export_id_1"))
+	assert.Equal(t, blobstorepkg.ErrNotFound, err)
+	body, err := svc.blobStore.Get(ctx, dataExportBlobKey("export_id_2"))
+	assert.NoError(t, err)
+	body.Close()
+	exports, _ := rdb.SMembers(ctx, dataExportsKey)
+	assert.Equal(t, []string{"export_id_2"}, exports)
+}
@@ -33,6 +33,10 @@ func (s *service) ShowFeeds(
 		}
 		profiles = ProfilesFeeds(data)
 	}
+	// feed shows photos on cards, full size is loaded from the profile
+	if errs := s.withPhotoURLs(photoRenditionCard, profiles...); errs != nil {
+		return nil, errs
+	}
 
 	return profiles, nil
 }
@@ -88,6 +92,21 @@ func (s *service) Swipes(
 		}
 	}
 
+	// swiped profile must be discoverable by the swiper
+	mutual, err := s.repo.IsMutualInterest(ctx, req.SwiperId, req.SwipedId)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !mutual {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"profile is not available",
+		)
+	}
+
 	// Create Swipes
 	err = s.repo.CreateSwipes(ctx, &repository.Swipe{
 		SwiperId: req.SwiperId,
@@ -122,6 +122,21 @@ func (s *FeedServiceMock) Swipes(
 		}
 	}
 
+	// swiped profile must be discoverable by the swiper
+	mutual, err := s.repo.IsMutualInterest(ctx, req.SwiperId, req.SwipedId)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !mutual {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"profile is not available",
+		)
+	}
+
 	// Create Swipes
 	err = s.repo.CreateSwipes(ctx, &repository.Swipe{
 		SwiperId: req.SwiperId,
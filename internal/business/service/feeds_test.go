@@ -3,11 +3,12 @@ package service
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/ijlik/dating-user/internal/adapter/repository"
 	"github.com/ijlik/dating-user/internal/business/domain"
 	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	"github.com/ijlik/dating-user/pkg/geohash"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	mocktest "github.com/stretchr/testify/mock"
@@ -16,6 +17,14 @@ import (
 	"time"
 )
 
+// feed queries of the repository, covered in detail by its own tests
+const (
+	getFeedOriginQueryMock       = "SELECT profiles\\.latitude, profiles\\.longitude, COALESCE\\(pref\\.max_distance_km, 0\\) AS max_distance_km FROM profiles LEFT JOIN discovery_preferences AS pref ON pref\\.user_id = profiles\\.user_id WHERE profiles\\.id = \\$1"
+	getRandomProfileQueryMock    = "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, latitude, longitude, geohash, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at, bio, job_title, company, education, height_cm, languages, prompts, gender_custom, orientation, show_me, is_verified, \\(SELECT 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) FROM profiles AS me WHERE me\\.id = \\$1\\) AS distance_km FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND latitude IS NOT NULL AND \\(\\$2 = '' OR geohash LIKE ANY\\(STRING_TO_ARRAY\\(\\$2, ','\\)\\)\\) AND \\(COALESCE\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ''\\) = '' OR gender = ANY\\(STRING_TO_ARRAY\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ','\\)\\)\\) AND \\(COALESCE\\(show_me, ''\\) = '' OR \\(SELECT me\\.gender FROM profiles AS me WHERE me\\.id = \\$1\\) = ANY\\(STRING_TO_ARRAY\\(show_me, ','\\)\\)\\) AND NOT EXISTS \\(SELECT 1 FROM discovery_preferences AS pref JOIN profiles AS me ON me\\.user_id = pref\\.user_id WHERE me\\.id = \\$1 AND \\(DATE_PART\\('year', AGE\\(profiles\\.birth_date\\)\\) NOT BETWEEN pref\\.min_age AND pref\\.max_age OR \\(pref\\.max_distance_km > 0 AND 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) > pref\\.max_distance_km\\) OR \\(pref\\.verified_only AND NOT profiles\\.is_verified\\)\\)\\) AND user_id NOT IN \\(SELECT id FROM users WHERE status = 'DEACTIVE'\\) AND id <> \\$1 AND id NOT IN \\(SELECT swiped_id FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE\\) ORDER BY FLOOR\\(\\(SELECT 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) FROM profiles AS me WHERE me\\.id = \\$1\\) / 5\\) NULLS LAST, RANDOM\\(\\) LIMIT 1"
+	getProfileWithoutIdQueryMock = "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, latitude, longitude, geohash, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at, bio, job_title, company, education, height_cm, languages, prompts, gender_custom, orientation, show_me, is_verified, \\(SELECT 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) FROM profiles AS me WHERE me\\.id = \\$1\\) AS distance_km FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND latitude IS NOT NULL AND \\(\\$2 = '' OR geohash LIKE ANY\\(STRING_TO_ARRAY\\(\\$2, ','\\)\\)\\) AND \\(COALESCE\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ''\\) = '' OR gender = ANY\\(STRING_TO_ARRAY\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ','\\)\\)\\) AND \\(COALESCE\\(show_me, ''\\) = '' OR \\(SELECT me\\.gender FROM profiles AS me WHERE me\\.id = \\$1\\) = ANY\\(STRING_TO_ARRAY\\(show_me, ','\\)\\)\\) AND NOT EXISTS \\(SELECT 1 FROM discovery_preferences AS pref JOIN profiles AS me ON me\\.user_id = pref\\.user_id WHERE me\\.id = \\$1 AND \\(DATE_PART\\('year', AGE\\(profiles\\.birth_date\\)\\) NOT BETWEEN pref\\.min_age AND pref\\.max_age OR \\(pref\\.max_distance_km > 0 AND 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) > pref\\.max_distance_km\\) OR \\(pref\\.verified_only AND NOT profiles\\.is_verified\\)\\)\\) AND user_id NOT IN \\(SELECT id FROM users WHERE status = 'DEACTIVE'\\) AND id <> \\$1 AND id NOT IN \\(SELECT swiped_id FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE\\) AND id <> \\$3 ORDER BY FLOOR\\(\\(SELECT 6371 \\* 2 \\* ASIN\\(LEAST\\(1, SQRT\\(POWER\\(SIN\\(RADIANS\\(profiles\\.latitude - me\\.latitude\\) / 2\\), 2\\) \\+ COS\\(RADIANS\\(me\\.latitude\\)\\) \\* COS\\(RADIANS\\(profiles\\.latitude\\)\\) \\* POWER\\(SIN\\(RADIANS\\(profiles\\.longitude - me\\.longitude\\) / 2\\), 2\\)\\)\\)\\) FROM profiles AS me WHERE me\\.id = \\$1\\) / 5\\) NULLS LAST, RANDOM\\(\\) LIMIT 1"
+	isMutualInterestQueryMock    = "SELECT count\\(\\*\\) FROM profiles WHERE id = \\$2 AND \\(COALESCE\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ''\\) = '' OR gender = ANY\\(STRING_TO_ARRAY\\(\\(SELECT me\\.show_me FROM profiles AS me WHERE me\\.id = \\$1\\), ','\\)\\)\\) AND \\(COALESCE\\(show_me, ''\\) = '' OR \\(SELECT me\\.gender FROM profiles AS me WHERE me\\.id = \\$1\\) = ANY\\(STRING_TO_ARRAY\\(show_me, ','\\)\\)\\)"
+)
+
 func TestShowFeeds(t *testing.T) {
 	// Create a mock DB connection
 	db, mock, err := sqlmock.New()
@@ -41,7 +50,8 @@ func TestShowFeeds(t *testing.T) {
 		Photos:              sql.NullString{String: "photo1.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "swimming", Valid: true},
 		Interest:            sql.NullString{String: "cooking", Valid: true},
-		Location:            sql.NullString{String: "45.1234:-76.5678", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: -76.5678, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: 45.1234, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -49,15 +59,17 @@ func TestShowFeeds(t *testing.T) {
 		UpdatedAt:           sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	getRandomProfileQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND location <> '' AND id <> \\$1 AND id NOT IN \\(SELECT swiped_id FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE\\) ORDER BY RANDOM\\(\\) LIMIT 1"
+	// swiper look for profiles within 10 km
+	mock.ExpectQuery(getFeedOriginQueryMock).WithArgs(swiperID).
+		WillReturnRows(sqlmock.NewRows([]string{"latitude", "longitude", "max_distance_km"}).AddRow(-76.6, 45.1, 10))
+	cells := strings.Join(geohash.Cover(-76.6, 45.1, 10), "%,") + "%"
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "location", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
-		AddRow(randomProfile1.ID, "user_id_1", randomProfile1.Name, randomProfile1.BirthDate, randomProfile1.Gender, randomProfile1.Photos, randomProfile1.Hobby, randomProfile1.Interest, randomProfile1.Location, randomProfile1.IsPremium, randomProfile1.IsPremiumValidUntil, randomProfile1.DailySwapQuota, randomProfile1.CreatedAt, randomProfile1.UpdatedAt)
-	mock.ExpectQuery(getRandomProfileQueryMock).WithArgs(swiperID).WillReturnRows(rows)
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "latitude", "longitude", "geohash", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at", "distance_km"}).
+		AddRow(randomProfile1.ID, "user_id_1", randomProfile1.Name, randomProfile1.BirthDate, randomProfile1.Gender, randomProfile1.Photos, randomProfile1.Hobby, randomProfile1.Interest, randomProfile1.Latitude, randomProfile1.Longitude, randomProfile1.Geohash, randomProfile1.IsPremium, randomProfile1.IsPremiumValidUntil, randomProfile1.DailySwapQuota, randomProfile1.CreatedAt, randomProfile1.UpdatedAt, 3.4)
+	mock.ExpectQuery(getRandomProfileQueryMock).WithArgs(swiperID, cells).WillReturnRows(rows)
 
 	// Set up the expected query and result for getProfileWithoutId
-	getProfileWithoutIdQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE name <> '' AND birth_date < CURRENT_TIMESTAMP AND gender <> '' AND photos <> '' AND hobby <> '' AND interest <> '' AND location <> '' AND id <> \\$1 AND id <> \\$2 AND id NOT IN \\(SELECT swiped_id FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE\\) LIMIT 1"
-	mock.ExpectQuery(getProfileWithoutIdQueryMock).WithArgs(swiperID, randomProfile1.ID).WillReturnRows(rows)
+	mock.ExpectQuery(getProfileWithoutIdQueryMock).WithArgs(swiperID, cells, randomProfile1.ID).WillReturnRows(rows)
 
 	deleteSwipesShowOnlyQueryMock := "DELETE FROM swipes WHERE swiper_id = \\$1 AND swiped_id = \\$2 AND DATE\\(created_at\\) = CURRENT_DATE"
 	mock.ExpectExec(deleteSwipesShowOnlyQueryMock).WithArgs(swiperID, randomProfile1.ID).WillReturnResult(sqlmock.NewResult(1, 1))
@@ -67,10 +79,9 @@ func TestShowFeeds(t *testing.T) {
 
 	mockFeedService.Mock.On("ShowFeeds", ctx, swiperID, profileID).Return([]*domain.Profile{
 		ProfileRes(randomProfile1, &repository.User{
-			Email:           "test@email.com",
-			Status:          constant.USER_STATUS_ACTIVE,
-			OnboardingSteps: "personal-info:pending,photos:pending,hobby-and-interest:pending,location:pending",
-		}, 0),
+			Email:  sql.NullString{String: "test@email.com", Valid: true},
+			Status: constant.USER_STATUS_ACTIVE,
+		}, nil, 0),
 	})
 	// Call the function being tested
 	profiles, err := svc.feedService.ShowFeeds(ctx, swiperID, profileID)
@@ -90,13 +101,9 @@ func TestShowFeeds(t *testing.T) {
 	assert.Equal(t, strings.Split(randomProfile1.Photos.String, ","), profile.Photos, "Photos mismatch")
 	assert.Equal(t, strings.Split(randomProfile1.Hobby.String, ","), profile.Hobby, "Hobby mismatch")
 	assert.Equal(t, strings.Split(randomProfile1.Interest.String, ","), profile.Interest, "Interest mismatch")
-	coordinate := strings.Split(randomProfile1.Location.String, ":")
-	location := &domain.Location{
-		Longitude: coordinate[0],
-		Latitude:  coordinate[1],
-		Url:       fmt.Sprintf("https://www.google.com/maps?q=%s,%s", coordinate[0], coordinate[1]),
-	}
-	assert.Equal(t, location, profile.Location, "Location mismatch")
+	// exact coordinates are not shared on the feed
+	assert.Nil(t, profile.Location, "Location should be hidden")
+	assert.Equal(t, 3, profile.DistanceKm, "DistanceKm mismatch")
 	assert.Equal(t, randomProfile1.IsPremium, profile.IsPremium, "IsPremium mismatch")
 	assert.Equal(t, randomProfile1.IsPremiumValidUntil.Time, profile.IsPremiumValidUntil, "IsPremiumValidUntil mismatch")
 	assert.Equal(t, randomProfile1.DailySwapQuota, profile.DailySwapQuota, "DailySwapQuota mismatch")
@@ -138,7 +145,8 @@ func TestSwipes(t *testing.T) {
 		Photos:              sql.NullString{String: "photo1.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "swimming", Valid: true},
 		Interest:            sql.NullString{String: "cooking", Valid: true},
-		Location:            sql.NullString{String: "San Francisco", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: 37.7749, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: -122.4194, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -146,10 +154,9 @@ func TestSwipes(t *testing.T) {
 		UpdatedAt:           sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	getProfileByUserIDQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE user_id = \\$1 LIMIT 1"
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "location", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
-		AddRow(expectedProfile.ID, expectedProfile.UserID, expectedProfile.Name, expectedProfile.BirthDate, expectedProfile.Gender, expectedProfile.Photos, expectedProfile.Hobby, expectedProfile.Interest, expectedProfile.Location, expectedProfile.IsPremium, expectedProfile.IsPremiumValidUntil, expectedProfile.DailySwapQuota, expectedProfile.CreatedAt, expectedProfile.UpdatedAt)
-	mock.ExpectQuery(getProfileByUserIDQueryMock).WithArgs(UserID).WillReturnRows(rows)
+	expectProfileByUserID(mock, UserID, expectedProfile)
+
+	mock.ExpectQuery(isMutualInterestQueryMock).WithArgs(swiperID, swipedID).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 
 	deleteSwipesShowOnlyQueryMock := "DELETE FROM swipes WHERE swiper_id = \\$1 AND swiped_id = \\$2 AND DATE\\(created_at\\) = CURRENT_DATE"
 	mock.ExpectExec(deleteSwipesShowOnlyQueryMock).WithArgs(swiperID, swipedID).WillReturnResult(sqlmock.NewResult(1, 1))
@@ -163,7 +170,7 @@ func TestSwipes(t *testing.T) {
 	mock.ExpectQuery(getSwipesCountQueryMock).WithArgs(swiperID).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(dailyQuota))
 
 	// Set up mock behavior for UpdatePremiumStatusProfile
-	updatePremiumStatusProfileQueryMock := "UPDATE profiles SET is_premium = \\$2, is_premium_valid_until = \\$3, daily_swap_quota = \\$4 WHERE id = \\$1"
+	updatePremiumStatusProfileQueryMock := "UPDATE profiles SET is_premium = \\$2, is_premium_valid_until = \\$3, daily_swap_quota = \\$4, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
 	mock.ExpectExec(updatePremiumStatusProfileQueryMock).
 		WithArgs(expectedProfile.ID, false, nil, expectedProfile.DailySwapQuota).
 		WillReturnResult(sqlmock.NewResult(0, 1))
@@ -175,3 +182,26 @@ func TestSwipes(t *testing.T) {
 	// Assertions
 	assert.Nil(t, err, "Expected no error")
 }
+
+func TestSwipesOutsideShowMe(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	swiperID := "profile_id_1"
+	swipedID := "profile_id_2"
+
+	profile := testProfile(UserID)
+	profile.ID = swiperID
+	expectProfileByUserID(mock, UserID, profile)
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE").WithArgs(swiperID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	// swiped profile only want to see women
+	mock.ExpectQuery(isMutualInterestQueryMock).WithArgs(swiperID, swipedID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	errs := svc.Swipes(ctx, &domain.SwipeRequest{SwiperId: swiperID, SwipedId: swipedID, IsLike: true}, UserID)
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
+)
+
+var defaultLockoutWindows = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	time.Hour,
+	24 * time.Hour,
+}
+
+// lockoutThreshold failed login before the account is locked
+func (s *service) lockoutThreshold() int {
+	threshold := s.config.GetInt("LOGIN_LOCKOUT_THRESHOLD")
+	if threshold == 0 {
+		threshold = 5
+	}
+
+	return threshold
+}
+
+// lockoutWindows LOGIN_LOCKOUT_WINDOWS comma separated duration of each lockout
+// level (e.g. 1m,5m,1h,24h), last window is used for every next level
+func (s *service) lockoutWindows() []time.Duration {
+	var windows []time.Duration
+	for _, value := range s.config.GetArray("LOGIN_LOCKOUT_WINDOWS") {
+		window, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || window <= 0 {
+			return defaultLockoutWindows
+		}
+		windows = append(windows, window)
+	}
+
+	if len(windows) == 0 {
+		return defaultLockoutWindows
+	}
+
+	return windows
+}
+
+func (s *service) lockoutWindow(level int) time.Duration {
+	windows := s.lockoutWindows()
+	if level > len(windows) {
+		level = len(windows)
+	}
+
+	return windows[level-1]
+}
+
+// previousStatus status before the account is locked
+func previousStatus(failure *repository.LoginFailure) constant.UserStatus {
+	if failure == nil || !failure.PreviousStatus.Valid {
+		return constant.USER_STATUS_ACTIVE
+	}
+
+	return constant.UserStatus(failure.PreviousStatus.String)
+}
+
+func temporaryBlockedError(lockedUntil time.Time) errpkg.ErrorService {
+	return errpkg.DefaultServiceError(
+		errpkg.ErrTemporaryBlocked,
+		fmt.Sprintf("Too many failed login, account is locked until %s", lockedUntil.UTC().Format(time.RFC3339)),
+	)
+}
+
+// checkLockout reject blocked user, lock that is already over is released and
+// user status is restored
+func (s *service) checkLockout(
+	ctx context.Context,
+	user *repository.User,
+) errpkg.ErrorService {
+	if user.Status != constant.USER_STATUS_TEMPORARY_BLOCKED {
+		return nil
+	}
+
+	failure, err := s.repo.GetLoginFailure(ctx, user.ID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	if failure != nil && failure.LockedUntil.Valid && s.time.Now().Before(failure.LockedUntil.Time) {
+		return temporaryBlockedError(failure.LockedUntil.Time)
+	}
+
+	status := previousStatus(failure)
+	err = s.repo.ReleaseLockUser(ctx, &repository.UpdateStatus{
+		ID:     user.ID,
+		Status: status,
+	})
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	user.Status = status
+
+	return nil
+}
+
+// recordLoginFailure add failure to the ledger and lock the account once
+// threshold is reached, returned time is set when account is locked
+func (s *service) recordLoginFailure(
+	ctx context.Context,
+	user *repository.User,
+) (*time.Time, error) {
+	failure, err := s.repo.RecordLoginFailure(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if failure.FailedAttempts < s.lockoutThreshold() {
+		return nil, nil
+	}
+
+	level := failure.LockoutLevel + 1
+	lockedUntil := s.time.Now().Add(s.lockoutWindow(level))
+	err = s.repo.LockUser(ctx, &repository.LockUser{
+		UserID:         user.ID,
+		LockoutLevel:   level,
+		LockedUntil:    lockedUntil,
+		PreviousStatus: user.Status.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	user.Status = constant.USER_STATUS_TEMPORARY_BLOCKED
+
+	// lock is already in place, failed notice should not fail the request
+	if user.Email.Valid {
+		err = s.mailer.Send(mailerpkg.ACCOUNT_LOCKED, user.Email.String, map[string]interface{}{
+			"LockedUntil": lockedUntil.UTC().Format("02 Jan 2006 15:04 MST"),
+		})
+		if err != nil {
+			log.Println("failed to send account locked notice: ", err)
+		}
+	}
+
+	return &lockedUntil, nil
+}
+
+// UnlockUser called by admin, release the lock and clear failure ledger
+func (s *service) UnlockUser(
+	ctx context.Context,
+	UserID string,
+) errpkg.ErrorService {
+	user, err := s.repo.GetUserById(ctx, UserID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if user == nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"user not found",
+		)
+	}
+
+	if user.Status == constant.USER_STATUS_TEMPORARY_BLOCKED {
+		failure, err := s.repo.GetLoginFailure(ctx, user.ID)
+		if err != nil {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+
+		err = s.repo.ReleaseLockUser(ctx, &repository.UpdateStatus{
+			ID:     user.ID,
+			Status: previousStatus(failure),
+		})
+		if err != nil {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+	}
+
+	err = s.repo.DeleteLoginFailure(ctx, user.ID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	configdata "github.com/ijlik/dating-user/pkg/config/data"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+)
+
+var loginFailureColumns = []string{"user_id", "failed_attempts", "lockout_level", "locked_until", "previous_status", "last_failed_at"}
+
+func expectLoginFailureCleared(mock sqlmock.Sqlmock, UserID string) {
+	deleteLoginFailureQueryMock := "DELETE FROM login_failures WHERE user_id = \\$1"
+	mock.ExpectExec(deleteLoginFailureQueryMock).WithArgs(UserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectReleaseLockUser(mock sqlmock.Sqlmock, UserID string, status constant.UserStatus) {
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE login_failures SET locked_until = NULL, previous_status = NULL WHERE user_id = \\$1").
+		WithArgs(UserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET status = \\$2 WHERE id = \\$1").
+		WithArgs(UserID, status).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestLockoutWindow(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+
+	assert.Equal(t, time.Minute, svc.lockoutWindow(1))
+	assert.Equal(t, 5*time.Minute, svc.lockoutWindow(2))
+	assert.Equal(t, time.Hour, svc.lockoutWindow(3))
+	assert.Equal(t, 24*time.Hour, svc.lockoutWindow(4))
+	assert.Equal(t, 24*time.Hour, svc.lockoutWindow(10))
+
+	config := svc.config.(*configdata.ConfigData)
+	config.Data["LOGIN_LOCKOUT_WINDOWS"] = "30s, 2m"
+	assert.Equal(t, 30*time.Second, svc.lockoutWindow(1))
+	assert.Equal(t, 2*time.Minute, svc.lockoutWindow(5))
+
+	config.Data["LOGIN_LOCKOUT_WINDOWS"] = "30s,forever"
+	assert.Equal(t, time.Minute, svc.lockoutWindow(1))
+}
+
+func TestLoginOrRegisterLockAccount(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	mailer := &fakeMailer{}
+	svc.mailer = mailer
+	ctx := context.Background()
+	UserID := "user_id_1"
+	email := "test@example.com"
+
+	hashedCode, err := svc.hasher.Hash("123456")
+	assert.NoError(t, err)
+
+	getUserByEmailQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE email = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByEmailQueryMock).WithArgs(email).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone", "email", "status", "created_at", "updated_at"}).
+			AddRow(UserID, nil, email, constant.USER_STATUS_ACTIVE, time.Now(), nil))
+
+	getOneTimePasswordLogByUserAndTypeMock := "SELECT id, user_id, onetime_password_type, code, status, created_at, otp_limit FROM one_time_password_logs WHERE user_id = \\$1 AND onetime_password_type = \\$2 ORDER BY created_at DESC LIMIT 1"
+	mock.ExpectQuery(getOneTimePasswordLogByUserAndTypeMock).
+		WithArgs(UserID, constant.OTP_TYPE_EMAIL).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "code", "status", "created_at", "otp_limit"}).
+			AddRow("log_id_1", UserID, constant.OTP_TYPE_EMAIL, hashedCode, constant.OTP_STATUS_UNUSED, time.Now().UTC(), 3))
+
+	updateStatusOtpLogQueryMock := "UPDATE one_time_password_logs SET status = \\$1, updated_at = \\$2, otp_limit = \\$3 WHERE id = \\$4"
+	mock.ExpectExec(updateStatusOtpLogQueryMock).
+		WithArgs(constant.OTP_STATUS_UNUSED, sqlmock.AnyArg(), 2, "log_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// fifth failure, account was already locked once
+	recordLoginFailureQueryMock := "INSERT INTO login_failures \\(user_id, failed_attempts, last_failed_at\\) VALUES \\(\\$1, 1, CURRENT_TIMESTAMP\\) ON CONFLICT \\(user_id\\) DO UPDATE SET failed_attempts = login_failures.failed_attempts \\+ 1, last_failed_at = CURRENT_TIMESTAMP RETURNING user_id, failed_attempts, lockout_level, locked_until, previous_status, last_failed_at"
+	mock.ExpectQuery(recordLoginFailureQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(loginFailureColumns).
+			AddRow(UserID, 5, 1, nil, nil, time.Now()))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE login_failures SET failed_attempts = 0, lockout_level = \\$2, locked_until = \\$3, previous_status = \\$4 WHERE user_id = \\$1").
+		WithArgs(UserID, 2, sqlmock.AnyArg(), constant.USER_STATUS_ACTIVE.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET status = \\$2 WHERE id = \\$1").
+		WithArgs(UserID, constant.USER_STATUS_TEMPORARY_BLOCKED).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	_, errs := svc.LoginOrRegister(ctx, &domain.AuthRequest{
+		Email:   email,
+		Otp:     "654321",
+		OtpType: constant.OTP_TYPE_EMAIL,
+	})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrTemporaryBlocked, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Len(t, mailer.sent, 1)
+	assert.Equal(t, mailerpkg.ACCOUNT_LOCKED, mailer.sent[0].mail)
+	assert.Equal(t, email, mailer.sent[0].recipient)
+}
+
+func TestLoginOrRegisterBlockedUser(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	email := "test@example.com"
+
+	getUserByEmailQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE email = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByEmailQueryMock).WithArgs(email).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone", "email", "status", "created_at", "updated_at"}).
+			AddRow(UserID, nil, email, constant.USER_STATUS_TEMPORARY_BLOCKED, time.Now(), nil))
+
+	getLoginFailureQueryMock := "SELECT user_id, failed_attempts, lockout_level, locked_until, previous_status, last_failed_at FROM login_failures WHERE user_id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getLoginFailureQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(loginFailureColumns).
+			AddRow(UserID, 0, 1, time.Now().Add(time.Minute), "ACTIVE", time.Now()))
+
+	// code is not checked while the account is locked
+	_, errs := svc.LoginOrRegister(ctx, &domain.AuthRequest{
+		Email:   email,
+		Otp:     "123456",
+		OtpType: constant.OTP_TYPE_EMAIL,
+	})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrTemporaryBlocked, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckLockoutReleaseExpiredLock(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	getLoginFailureQueryMock := "SELECT user_id, failed_attempts, lockout_level, locked_until, previous_status, last_failed_at FROM login_failures WHERE user_id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getLoginFailureQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(loginFailureColumns).
+			AddRow(UserID, 0, 1, time.Now().Add(-time.Second), "UNVERIFIED", time.Now()))
+	expectReleaseLockUser(mock, UserID, constant.USER_STATUS_UNVERIFIED)
+
+	user := &repository.User{
+		ID:     UserID,
+		Email:  sql.NullString{String: "test@example.com", Valid: true},
+		Status: constant.USER_STATUS_TEMPORARY_BLOCKED,
+	}
+	errs := svc.checkLockout(ctx, user)
+	assert.Nil(t, errs)
+	assert.Equal(t, constant.USER_STATUS_UNVERIFIED, user.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnlockUser(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	getUserByIdQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone", "email", "status", "created_at", "updated_at"}).
+			AddRow(UserID, nil, "test@example.com", constant.USER_STATUS_TEMPORARY_BLOCKED, time.Now(), nil))
+
+	getLoginFailureQueryMock := "SELECT user_id, failed_attempts, lockout_level, locked_until, previous_status, last_failed_at FROM login_failures WHERE user_id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getLoginFailureQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(loginFailureColumns).
+			AddRow(UserID, 0, 4, time.Now().Add(24*time.Hour), "ACTIVE", time.Now()))
+	expectReleaseLockUser(mock, UserID, constant.USER_STATUS_ACTIVE)
+	expectLoginFailureCleared(mock, UserID)
+
+	errs := svc.UnlockUser(ctx, UserID)
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUnlockUserNotFound(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+
+	getUserByIdQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByIdQueryMock).WithArgs("unknown").
+		WillReturnError(sql.ErrNoRows)
+
+	errs := svc.UnlockUser(context.Background(), "unknown")
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrNotFound, errs.GetCode())
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
+)
+
+func (s *service) magicLinkExpiry() time.Duration {
+	expiry := s.config.GetInt("MAGIC_LINK_EXPIRY_IN_MINUTE")
+	if expiry == 0 {
+		expiry = 15
+	}
+
+	return time.Duration(expiry) * time.Minute
+}
+
+func (s *service) signMagicLinkNonce(nonce string) (string, error) {
+	secret := s.config.GetString("MAGIC_LINK_SECRET")
+	if secret == "" {
+		return "", errors.New("missing magic link secret")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(nonce))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// buildMagicLinkToken token format is <nonce>.<hmac of nonce>, only hash of
+// nonce is stored on one_time_password_logs
+func (s *service) buildMagicLinkToken(nonce string) (string, error) {
+	signature, err := s.signMagicLinkNonce(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s.%s", nonce, signature), nil
+}
+
+// parseMagicLinkToken return the nonce when signature is valid
+func (s *service) parseMagicLinkToken(token string) (string, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return "", false
+	}
+
+	expected, err := s.signMagicLinkNonce(parts[0])
+	if err != nil {
+		return "", false
+	}
+
+	if !hmac.Equal([]byte(expected), []byte(parts[1])) {
+		return "", false
+	}
+
+	return parts[0], true
+}
+
+func (s *service) sendMagicLinkAction(
+	ctx context.Context,
+	user *repository.User,
+) (int, error) {
+	baseUrl := s.config.GetString("MAGIC_LINK_BASE_URL")
+	if baseUrl == "" {
+		return 0, errors.New("missing magic link base url")
+	}
+
+	interval := s.config.GetInt("RESEND_OTP_INTERVAL")
+	if interval == 0 {
+		interval = 60
+	}
+	start := s.time.Now().UTC().Add(time.Duration(-1*interval) * time.Second)
+	end := s.time.Now().UTC()
+	countLink, err := s.repo.GetCountOneTimePasswordByTime(ctx, user.ID, constant.OTP_TYPE_MAGIC_LINK.String(), start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	if countLink > 0 {
+		return interval, nil
+	}
+
+	nonce := s.math.AlphaNumericRandom(32)
+	if !nonce.Valid {
+		return 0, errors.New("failed to generate magic link")
+	}
+
+	token, err := s.buildMagicLinkToken(nonce.String())
+	if err != nil {
+		return 0, err
+	}
+
+	hashedNonce, err := s.hasher.Hash(nonce.String())
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.repo.CreateOneTimePasswordLog(ctx, &repository.OneTimePasswordLog{
+		UserID:              user.ID,
+		OneTimePasswordType: constant.OTP_TYPE_MAGIC_LINK,
+		Code:                hashedNonce,
+		Status:              constant.OTP_STATUS_UNUSED,
+		CreatedAt:           s.time.Now(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.mailer.Send(mailerpkg.DEEPLINK, user.Email.String, map[string]interface{}{
+		"Link":           fmt.Sprintf("%s/auth/magic/%s", strings.TrimSuffix(baseUrl, "/"), token),
+		"ExpiryInMinute": int(s.magicLinkExpiry().Minutes()),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return interval, nil
+}
+
+func (s *service) LoginWithMagicLink(
+	ctx context.Context,
+	req *domain.MagicLinkRequest,
+) (*domain.AuthResponse, errpkg.ErrorService) {
+	nonce, ok := s.parseMagicLinkToken(req.Token)
+	if !ok {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"Invalid login link",
+		)
+	}
+
+	hashedNonce, err := s.hasher.Hash(nonce)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	otp, err := s.repo.GetOneTimePasswordLogByCode(ctx, constant.OTP_TYPE_MAGIC_LINK.String(), hashedNonce)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if otp == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"Invalid login link",
+		)
+	}
+
+	if otp.Status != constant.OTP_STATUS_UNUSED {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrTokenAlreadyUsed,
+			"Login link already used or expired",
+		)
+	}
+
+	if s.time.Now().UTC().After(otp.CreatedAt.Add(s.magicLinkExpiry())) {
+		err = s.repo.UpdateStatusOneTimePasswordLog(ctx, constant.OTP_STATUS_EXPIRED, otp.ID, 0)
+		if err != nil {
+			return nil, errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"Login link is expired",
+		)
+	}
+
+	// mark link as used before login, concurrent request using the same link
+	// will fail here
+	used, err := s.repo.UseOneTimePasswordLog(ctx, otp.ID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !used {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrTokenAlreadyUsed,
+			"Login link already used or expired",
+		)
+	}
+
+	user, err := s.repo.GetUserById(ctx, otp.UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if user == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"Invalid login link",
+		)
+	}
+
+	if errs := s.checkLockout(ctx, user); errs != nil {
+		return nil, errs
+	}
+
+	return s.completeLogin(ctx, user, req.DeviceInfo)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+)
+
+var magicLinkTestConfig = map[string]interface{}{
+	"MAGIC_LINK_SECRET":   "magic_secret",
+	"MAGIC_LINK_BASE_URL": "https://dating.example.com/",
+}
+
+func TestMagicLinkToken(t *testing.T) {
+	svc, _, _, _, _ := newFakeSenderTestService(t, magicLinkTestConfig)
+
+	token, err := svc.buildMagicLinkToken("nonce")
+	assert.NoError(t, err)
+
+	nonce, ok := svc.parseMagicLinkToken(token)
+	assert.True(t, ok)
+	assert.Equal(t, "nonce", nonce)
+
+	_, ok = svc.parseMagicLinkToken("other." + strings.Split(token, ".")[1])
+	assert.False(t, ok)
+
+	_, ok = svc.parseMagicLinkToken("nonce")
+	assert.False(t, ok)
+}
+
+func TestResendMagicLink(t *testing.T) {
+	svc, mock, _, mailer, _ := newFakeSenderTestService(t, magicLinkTestConfig)
+	ctx := context.Background()
+	email := "test@example.com"
+	UserID := "user_id_1"
+
+	getUserByEmailQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE email = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByEmailQueryMock).WithArgs(email).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone", "email", "status", "created_at", "updated_at"}).
+			AddRow(UserID, nil, email, constant.USER_STATUS_ACTIVE, time.Now(), nil))
+
+	getCountOTPbyTimeQueryMock := "SELECT count\\(\\*\\) FROM one_time_password_logs WHERE user_id = \\$1 AND onetime_password_type = \\$2 AND created_at BETWEEN \\$3 AND \\$4"
+	mock.ExpectQuery(getCountOTPbyTimeQueryMock).
+		WithArgs(UserID, constant.OTP_TYPE_MAGIC_LINK, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	updatePreviousUnusedOtpToExpiredMock := "UPDATE one_time_password_logs SET status = 'EXPIRED' WHERE status = 'UNUSED' AND user_id = \\$1"
+	mock.ExpectExec(updatePreviousUnusedOtpToExpiredMock).WithArgs(UserID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	createOneTimePasswordLogMock := "INSERT INTO one_time_password_logs \\(user_id, onetime_password_type, code, status, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5\\)"
+	mock.ExpectExec(createOneTimePasswordLogMock).
+		WithArgs(UserID, constant.OTP_TYPE_MAGIC_LINK, sqlmock.AnyArg(), constant.OTP_STATUS_UNUSED, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	req := &domain.ResendOtpRequest{Email: email, MagicLink: true}
+	assert.NoError(t, req.Validate(true))
+
+	res, errs := svc.ResendOtp(ctx, req)
+	assert.Nil(t, errs)
+	assert.Equal(t, "Login link already sent to your email: test@example.com.", res.Message)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Len(t, mailer.sent, 1)
+	assert.Equal(t, mailerpkg.DEEPLINK, mailer.sent[0].mail)
+	assert.Equal(t, email, mailer.sent[0].recipient)
+
+	link := mailer.sent[0].param.(map[string]interface{})["Link"].(string)
+	assert.True(t, strings.HasPrefix(link, "https://dating.example.com/auth/magic/"))
+	_, ok := svc.parseMagicLinkToken(strings.TrimPrefix(link, "https://dating.example.com/auth/magic/"))
+	assert.True(t, ok)
+}
+
+func TestResendMagicLinkOnlyForEmail(t *testing.T) {
+	req := &domain.ResendOtpRequest{Phone: "+6281234567890", Channel: "sms", MagicLink: true}
+	assert.Error(t, req.Validate(true))
+}
+
+func TestLoginWithMagicLink(t *testing.T) {
+	svc, mock, _, _, _ := newFakeSenderTestService(t, magicLinkTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	token, err := svc.buildMagicLinkToken("nonce")
+	assert.NoError(t, err)
+	hashedNonce, err := svc.hasher.Hash("nonce")
+	assert.NoError(t, err)
+
+	getOneTimePasswordLogByCodeMock := "SELECT id, user_id, onetime_password_type, code, status, created_at, otp_limit FROM one_time_password_logs WHERE onetime_password_type = \\$1 AND code = \\$2 LIMIT 1"
+	mock.ExpectQuery(getOneTimePasswordLogByCodeMock).
+		WithArgs(constant.OTP_TYPE_MAGIC_LINK, hashedNonce).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "code", "status", "created_at", "otp_limit"}).
+			AddRow("log_id_1", UserID, constant.OTP_TYPE_MAGIC_LINK, hashedNonce, constant.OTP_STATUS_UNUSED, time.Now().UTC(), 3))
+
+	useOneTimePasswordLogMock := "UPDATE one_time_password_logs SET status = 'USED', updated_at = \\$2 WHERE id = \\$1 AND status = 'UNUSED'"
+	mock.ExpectExec(useOneTimePasswordLogMock).WithArgs("log_id_1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	expectUserAndProfile(mock, UserID)
+	expectLoginFailureCleared(mock, UserID)
+
+	res, errs := svc.LoginWithMagicLink(ctx, &domain.MagicLinkRequest{
+		Token:      token,
+		DeviceInfo: domain.DeviceInfo{DeviceName: "Pixel 7"},
+	})
+	assert.Nil(t, errs)
+	assert.NotEmpty(t, res.AccessToken)
+	assert.NotEmpty(t, res.RefreshToken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginWithMagicLinkAlreadyUsed(t *testing.T) {
+	svc, mock, _, _, _ := newFakeSenderTestService(t, magicLinkTestConfig)
+	ctx := context.Background()
+
+	token, err := svc.buildMagicLinkToken("nonce")
+	assert.NoError(t, err)
+	hashedNonce, err := svc.hasher.Hash("nonce")
+	assert.NoError(t, err)
+
+	getOneTimePasswordLogByCodeMock := "SELECT id, user_id, onetime_password_type, code, status, created_at, otp_limit FROM one_time_password_logs WHERE onetime_password_type = \\$1 AND code = \\$2 LIMIT 1"
+	mock.ExpectQuery(getOneTimePasswordLogByCodeMock).
+		WithArgs(constant.OTP_TYPE_MAGIC_LINK, hashedNonce).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "code", "status", "created_at", "otp_limit"}).
+			AddRow("log_id_1", "user_id_1", constant.OTP_TYPE_MAGIC_LINK, hashedNonce, constant.OTP_STATUS_USED, time.Now().UTC(), 3))
+
+	_, errs := svc.LoginWithMagicLink(ctx, &domain.MagicLinkRequest{Token: token})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrTokenAlreadyUsed, errs.GetCode())
+}
+
+func TestLoginWithMagicLinkForged(t *testing.T) {
+	svc, mock, _, _, _ := newFakeSenderTestService(t, magicLinkTestConfig)
+
+	_, errs := svc.LoginWithMagicLink(context.Background(), &domain.MagicLinkRequest{Token: "nonce.forged"})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrInvalidToken, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
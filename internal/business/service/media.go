@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/ijlik/dating-user/internal/business/domain"
+	blobstorepkg "github.com/ijlik/dating-user/pkg/blobstore"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+)
+
+func (s *service) signMedia(key string, expires int64) (string, error) {
+	secret := s.config.GetString("MEDIA_URL_SECRET")
+	if secret == "" {
+		return "", errors.New("missing media url secret")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%s.%d", key, expires)))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// mediaExpires expiry is rounded up to the window, so the same object keep
+// the same URL for a while and clients can cache it. URL is valid between
+// one and two windows
+func (s *service) mediaExpires() int64 {
+	window := int64(s.config.GetInt("MEDIA_URL_EXPIRY_IN_MINUTE")) * 60
+	if window == 0 {
+		window = 60 * 60
+	}
+
+	return (s.time.Now().Unix()/window + 2) * window
+}
+
+// buildMediaUrl signed URL of a stored object, an object can not be fetched
+// without a URL handed out by the service
+func (s *service) buildMediaUrl(key string, expires int64) (string, error) {
+	baseUrl := s.config.GetString("MEDIA_BASE_URL")
+	if baseUrl == "" {
+		return "", errors.New("missing media base url")
+	}
+
+	signature, err := s.signMedia(key, expires)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("expires", fmt.Sprintf("%d", expires))
+	query.Set("signature", signature)
+
+	return fmt.Sprintf("%s/media/%s?%s", strings.TrimSuffix(baseUrl, "/"), (&url.URL{Path: key}).EscapedPath(), query.Encode()), nil
+}
+
+// GetMedia validate the signed URL and return content of the stored object
+func (s *service) GetMedia(
+	ctx context.Context,
+	req *domain.GetMediaRequest,
+) (io.ReadCloser, errpkg.ErrorService) {
+	expected, err := s.signMedia(req.Key, req.Expires)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	if !hmac.Equal([]byte(expected), []byte(req.Signature)) {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"invalid media signature",
+		)
+	}
+
+	if s.time.Now().Unix() > req.Expires {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"media link is expired",
+		)
+	}
+
+	body, err := s.blobStore.Get(ctx, req.Key)
+	if err == blobstorepkg.ErrNotFound || err == blobstorepkg.ErrInvalidKey {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"media not found",
+		)
+	}
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return body, nil
+}
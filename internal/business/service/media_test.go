@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ijlik/dating-user/internal/business/domain"
+	configdata "github.com/ijlik/dating-user/pkg/config/data"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	"github.com/stretchr/testify/assert"
+)
+
+// mediaRequest request made with a signed url handed out by the service
+func mediaRequest(t *testing.T, link string) *domain.GetMediaRequest {
+	parsed, err := url.Parse(link)
+	assert.NoError(t, err)
+
+	expires, err := strconv.ParseInt(parsed.Query().Get("expires"), 10, 64)
+	assert.NoError(t, err)
+
+	return &domain.GetMediaRequest{
+		Key:       strings.TrimPrefix(parsed.Path, "/media/"),
+		Expires:   expires,
+		Signature: parsed.Query().Get("signature"),
+	}
+}
+
+func TestWithPhotoURLs(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+
+	// photo stored before renditions is served as is
+	profile := &domain.Profile{Photos: []string{"photos/profile_id_1/abc_full.jpg", "photos/profile_id_1/legacy.png"}}
+	errs := svc.withPhotoURLs(photoRenditionCard, profile)
+	assert.Nil(t, errs)
+	assert.True(t, strings.HasPrefix(profile.Photos[0], "http://localhost:8080/media/photos/profile_id_1/abc_card.jpg?expires="))
+	assert.True(t, strings.HasPrefix(profile.Photos[1], "http://localhost:8080/media/photos/profile_id_1/legacy.png?expires="))
+
+	// expiry is rounded to the window, url stay the same within it
+	again := &domain.Profile{Photos: []string{"photos/profile_id_1/abc_full.jpg"}}
+	assert.Nil(t, svc.withPhotoURLs(photoRenditionCard, again))
+	assert.Equal(t, profile.Photos[0], again.Photos[0])
+
+	req := mediaRequest(t, profile.Photos[0])
+	assert.Equal(t, "photos/profile_id_1/abc_card.jpg", req.Key)
+	assert.Zero(t, req.Expires%3600)
+	assert.Greater(t, req.Expires, time.Now().Add(time.Hour).Unix()-1)
+}
+
+func TestWithPhotoURLsMissingSecret(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+	delete(svc.config.(*configdata.ConfigData).Data, "MEDIA_URL_SECRET")
+
+	errs := svc.withPhotoURLs(photoRenditionFull, &domain.Profile{Photos: []string{"photos/profile_id_1/abc_full.jpg"}})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrInternal, errs.GetCode())
+}
+
+func TestGetMedia(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+	ctx := context.Background()
+	key := "photos/profile_id_1/abc_card.jpg"
+	assert.NoError(t, svc.blobStore.Put(ctx, key, strings.NewReader("image"), 5, "image/jpeg"))
+
+	profile := &domain.Profile{Photos: []string{"photos/profile_id_1/abc_full.jpg"}}
+	assert.Nil(t, svc.withPhotoURLs(photoRenditionCard, profile))
+
+	body, errs := svc.GetMedia(ctx, mediaRequest(t, profile.Photos[0]))
+	assert.Nil(t, errs)
+	content, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "image", string(content))
+}
+
+func TestGetMediaInvalidLink(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+	ctx := context.Background()
+	key := "photos/profile_id_1/abc_full.jpg"
+	assert.NoError(t, svc.blobStore.Put(ctx, key, strings.NewReader("image"), 5, "image/jpeg"))
+
+	// signature of another key, enumerating keys needs the secret
+	expires := time.Now().Add(time.Hour).Unix()
+	signature, err := svc.signMedia("photos/profile_id_1/other_full.jpg", expires)
+	assert.NoError(t, err)
+
+	_, errs := svc.GetMedia(ctx, &domain.GetMediaRequest{Key: key, Expires: expires, Signature: signature})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrInvalidToken, errs.GetCode())
+
+	// tampered expiry
+	signature, err = svc.signMedia(key, expires)
+	assert.NoError(t, err)
+
+	_, errs = svc.GetMedia(ctx, &domain.GetMediaRequest{Key: key, Expires: expires + 3600, Signature: signature})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrInvalidToken, errs.GetCode())
+
+	// expired link
+	expires = time.Now().Add(-time.Minute).Unix()
+	signature, err = svc.signMedia(key, expires)
+	assert.NoError(t, err)
+
+	_, errs = svc.GetMedia(ctx, &domain.GetMediaRequest{Key: key, Expires: expires, Signature: signature})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrInvalidToken, errs.GetCode())
+}
+
+func TestGetMediaNotFound(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+	expires := time.Now().Add(time.Hour).Unix()
+
+	for _, key := range []string{"../secret", "photos/profile_id_1/missing.jpg"} {
+		signature, err := svc.signMedia(key, expires)
+		assert.NoError(t, err)
+
+		_, errs := svc.GetMedia(context.Background(), &domain.GetMediaRequest{Key: key, Expires: expires, Signature: signature})
+		assert.NotNil(t, errs)
+		assert.Equal(t, errpkg.ErrNotFound, errs.GetCode())
+	}
+}
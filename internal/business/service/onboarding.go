@@ -5,18 +5,24 @@ import (
 	"fmt"
 	"github.com/ijlik/dating-user/internal/adapter/repository"
 	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
 	errpkg "github.com/ijlik/dating-user/pkg/error"
-	"io"
-	"os"
-	"path/filepath"
+	"github.com/ijlik/dating-user/pkg/geohash"
 	"strings"
 )
 
+// profileGeohashPrecision cells of about 5 m, finer than any feed distance
+const profileGeohashPrecision = 9
+
 func (s *service) UpdatePersonalInfo(
 	ctx context.Context,
 	req *domain.UpdatePersonalInfo,
 	UserID string,
 ) errpkg.ErrorService {
+	if errs := s.checkOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO); errs != nil {
+		return errs
+	}
+
 	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
 	if err != nil {
 		return errpkg.DefaultServiceError(
@@ -32,46 +38,7 @@ func (s *service) UpdatePersonalInfo(
 		)
 	}
 
-	user, err := s.repo.GetUserById(ctx, UserID)
-	if err != nil {
-		return errpkg.DefaultServiceError(
-			errpkg.ErrInternal,
-			err.Error(),
-		)
-	}
-	onboardingSteps := user.GetOnboardingSteps()
-	currentStepStatus := ""
-	for _, step := range onboardingSteps {
-		if step.Step == "personal-info" {
-			currentStepStatus = step.Status
-			break
-		}
-	}
-
-	if currentStepStatus == "pending" {
-		// Update Onboarding Step
-		var steps string
-		for _, step := range onboardingSteps {
-			status := step.Status
-			if step.Step == "personal-info" {
-				status = "done"
-			}
-			steps += fmt.Sprintf("%s:%s,", step.Step, status)
-		}
-		steps = strings.TrimSuffix(steps, ",")
-		err = s.repo.UpdateOnboardingStepsUser(ctx, &repository.UpdateOnboardingSteps{
-			ID:              UserID,
-			OnboardingSteps: steps,
-		})
-		if err != nil {
-			return errpkg.DefaultServiceError(
-				errpkg.ErrInternal,
-				err.Error(),
-			)
-		}
-	}
-
-	return nil
+	return s.completeOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO)
 }
 
 func (s *service) UpdatePhotos(
@@ -79,6 +46,10 @@ func (s *service) UpdatePhotos(
 	req *domain.UpdatePhotos,
 	UserID string,
 ) errpkg.ErrorService {
+	if errs := s.checkOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_PHOTOS); errs != nil {
+		return errs
+	}
+
 	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
 	if err != nil {
 		return errpkg.DefaultServiceError(
@@ -87,106 +58,55 @@ func (s *service) UpdatePhotos(
 		)
 	}
 
-	uploadDir := fmt.Sprintf("storage/photos/%s", profile.ID)
-	var allPhotos string
-	if err = os.RemoveAll(uploadDir); err != nil {
-		return errpkg.DefaultServiceError(
-			errpkg.ErrInternal,
-			err.Error(),
-		)
+	minPhotos, maxPhotos := s.profilePhotoLimits()
+	if len(req.Photos) > maxPhotos {
+		return photoLimitError(maxPhotos)
+	}
+
+	// count is checked before anything is stored, the same photo uploaded
+	// twice count once
+	uploads, errs := decodePhotos(profile.ID, req.Photos)
+	if errs != nil {
+		return errs
 	}
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+	if len(uploads) < minPhotos {
 		return errpkg.DefaultServiceError(
-			errpkg.ErrInternal,
-			err.Error(),
+			errpkg.ErrBadRequest,
+			fmt.Sprintf("minimum photo count is %d", minPhotos),
 		)
 	}
-	for _, photo := range req.Photos {
-		// Generate a unique filename for each photo
-		fileName := filepath.Join(uploadDir, photo.Filename)
-
-		// Create the destination file
-		dst, err := os.Create(fileName)
-		if err != nil {
-			return errpkg.DefaultServiceError(
-				errpkg.ErrInternal,
-				err.Error(),
-			)
-		}
-		defer dst.Close()
 
-		// Open the uploaded file
-		src, err := photo.Open()
-		if err != nil {
-			return errpkg.DefaultServiceError(
-				errpkg.ErrInternal,
-				err.Error(),
-			)
-		}
-		defer src.Close()
-
-		// Copy the uploaded file to the destination
-		if _, err = io.Copy(dst, src); err != nil {
-			return errpkg.DefaultServiceError(
-				errpkg.ErrInternal,
-				err.Error(),
-			)
-		}
-		allPhotos += fmt.Sprintf("%s,", fileName)
+	keys, errs := s.storePhotos(ctx, uploads)
+	if errs != nil {
+		return errs
 	}
 
-	err = s.repo.UpdatePhotosProfile(ctx, &repository.UpdatePhotos{
-		ID:     profile.ID,
-		Photos: strings.TrimSuffix(allPhotos, ","),
-	})
-
-	if err != nil {
+	if err = s.repo.ReplaceProfilePhotos(ctx, profile.ID, keys); err != nil {
 		return errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
 			err.Error(),
 		)
 	}
 
-	user, err := s.repo.GetUserById(ctx, UserID)
-	if err != nil {
+	// previous photos are removed once nothing refers to them anymore
+	stored := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		stored[key] = true
+	}
+	var replaced []string
+	for _, key := range splitNullString(profile.Photos) {
+		if !stored[key] {
+			replaced = append(replaced, key)
+		}
+	}
+	if err = s.deletePhotos(ctx, replaced); err != nil {
 		return errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
 			err.Error(),
 		)
 	}
-	onboardingSteps := user.GetOnboardingSteps()
-	currentStepStatus := ""
-	for _, step := range onboardingSteps {
-		if step.Step == "photos" {
-			currentStepStatus = step.Status
-			break
-		}
-	}
-
-	if currentStepStatus == "pending" {
-		// Update Onboarding Step
-		var steps string
-		for _, step := range onboardingSteps {
-			status := step.Status
-			if step.Step == "photos" {
-				status = "done"
-			}
-			steps += fmt.Sprintf("%s:%s,", step.Step, status)
-		}
-		steps = strings.TrimSuffix(steps, ",")
-		err = s.repo.UpdateOnboardingStepsUser(ctx, &repository.UpdateOnboardingSteps{
-			ID:              UserID,
-			OnboardingSteps: steps,
-		})
-		if err != nil {
-			return errpkg.DefaultServiceError(
-				errpkg.ErrInternal,
-				err.Error(),
-			)
-		}
-	}
 
-	return nil
+	return s.completeOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_PHOTOS)
 }
 
 func (s *service) UpdateHobbyAndInterest(
@@ -194,6 +114,10 @@ func (s *service) UpdateHobbyAndInterest(
 	req *domain.UpdateHobbyAndInterest,
 	UserID string,
 ) errpkg.ErrorService {
+	if errs := s.checkOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_HOBBY_AND_INTEREST); errs != nil {
+		return errs
+	}
+
 	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
 	if err != nil {
 		return errpkg.DefaultServiceError(
@@ -213,46 +137,7 @@ func (s *service) UpdateHobbyAndInterest(
 		)
 	}
 
-	user, err := s.repo.GetUserById(ctx, UserID)
-	if err != nil {
-		return errpkg.DefaultServiceError(
-			errpkg.ErrInternal,
-			err.Error(),
-		)
-	}
-	onboardingSteps := user.GetOnboardingSteps()
-	currentStepStatus := ""
-	for _, step := range onboardingSteps {
-		if step.Step == "hobby-and-interest" {
-			currentStepStatus = step.Status
-			break
-		}
-	}
-
-	if currentStepStatus == "pending" {
-		// Update Onboarding Step
-		var steps string
-		for _, step := range onboardingSteps {
-			status := step.Status
-			if step.Step == "hobby-and-interest" {
-				status = "done"
-			}
-			steps += fmt.Sprintf("%s:%s,", step.Step, status)
-		}
-		steps = strings.TrimSuffix(steps, ",")
-		err = s.repo.UpdateOnboardingStepsUser(ctx, &repository.UpdateOnboardingSteps{
-			ID:              UserID,
-			OnboardingSteps: steps,
-		})
-		if err != nil {
-			return errpkg.DefaultServiceError(
-				errpkg.ErrInternal,
-				err.Error(),
-			)
-		}
-	}
-
-	return nil
+	return s.completeOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_HOBBY_AND_INTEREST)
 }
 
 func (s *service) UpdateLocation(
@@ -260,6 +145,10 @@ func (s *service) UpdateLocation(
 	req *domain.Location,
 	UserID string,
 ) errpkg.ErrorService {
+	if errs := s.checkOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_LOCATION); errs != nil {
+		return errs
+	}
+
 	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
 	if err != nil {
 		return errpkg.DefaultServiceError(
@@ -268,8 +157,10 @@ func (s *service) UpdateLocation(
 		)
 	}
 	err = s.repo.UpdateLocationProfile(ctx, &repository.UpdateLocation{
-		ID:       profile.ID,
-		Location: fmt.Sprintf("%s:%s", req.Longitude, req.Latitude),
+		ID:        profile.ID,
+		Latitude:  req.Lat,
+		Longitude: req.Lon,
+		Geohash:   geohash.Encode(req.Lat, req.Lon, profileGeohashPrecision),
 	})
 	if err != nil {
 		return errpkg.DefaultServiceError(
@@ -278,43 +169,5 @@ func (s *service) UpdateLocation(
 		)
 	}
 
-	user, err := s.repo.GetUserById(ctx, UserID)
-	if err != nil {
-		return errpkg.DefaultServiceError(
-			errpkg.ErrInternal,
-			err.Error(),
-		)
-	}
-	onboardingSteps := user.GetOnboardingSteps()
-	currentStepStatus := ""
-	for _, step := range onboardingSteps {
-		if step.Step == "location" {
-			currentStepStatus = step.Status
-			break
-		}
-	}
-
-	if currentStepStatus == "pending" {
-		// Update Onboarding Step
-		var steps string
-		for _, step := range onboardingSteps {
-			status := step.Status
-			if step.Step == "location" {
-				status = "done"
-			}
-			steps += fmt.Sprintf("%s:%s,", step.Step, status)
-		}
-		steps = strings.TrimSuffix(steps, ",")
-		err = s.repo.UpdateOnboardingStepsUser(ctx, &repository.UpdateOnboardingSteps{
-			ID:              UserID,
-			OnboardingSteps: steps,
-		})
-		if err != nil {
-			return errpkg.DefaultServiceError(
-				errpkg.ErrInternal,
-				err.Error(),
-			)
-		}
-	}
-	return nil
+	return s.completeOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_LOCATION)
 }
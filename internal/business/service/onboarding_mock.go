@@ -2,12 +2,13 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"github.com/ijlik/dating-user/internal/adapter/repository"
 	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
 	errpkg "github.com/ijlik/dating-user/pkg/error"
+	"github.com/ijlik/dating-user/pkg/geohash"
 	"github.com/stretchr/testify/mock"
-	"path/filepath"
+	"path"
 	"strings"
 )
 
@@ -69,44 +70,13 @@ func (s *OnboardingServiceMock) UpdatePersonalInfo(
 		)
 	}
 
-	user, err := s.repo.GetUserById(ctx, UserID)
+	_, err = s.repo.CompleteOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO)
 	if err != nil {
 		return errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
 			err.Error(),
 		)
 	}
-	onboardingSteps := user.GetOnboardingSteps()
-	currentStepStatus := ""
-	for _, step := range onboardingSteps {
-		if step.Step == "personal-info" {
-			currentStepStatus = step.Status
-			break
-		}
-	}
-
-	if currentStepStatus == "pending" {
-		// Update Onboarding Step
-		var steps string
-		for _, step := range onboardingSteps {
-			status := step.Status
-			if step.Step == "personal-info" {
-				status = "done"
-			}
-			steps += fmt.Sprintf("%s:%s,", step.Step, status)
-		}
-		steps = strings.TrimSuffix(steps, ",")
-		err = s.repo.UpdateOnboardingStepsUser(ctx, &repository.UpdateOnboardingSteps{
-			ID:              UserID,
-			OnboardingSteps: steps,
-		})
-		if err != nil {
-			return errpkg.DefaultServiceError(
-				errpkg.ErrInternal,
-				err.Error(),
-			)
-		}
-	}
 
 	return nil
 }
@@ -138,18 +108,13 @@ func (s *OnboardingServiceMock) UpdatePhotos(
 		)
 	}
 
-	uploadDir := fmt.Sprintf("storage/photos/%s", profile.ID)
-	var allPhotos string
+	uploadDir := photoKeyPrefix(profile.ID)
+	var keys []string
 	for _, photo := range req.Photos {
-		// Generate a unique filename for each photo
-		fileName := filepath.Join(uploadDir, photo.Filename)
-		allPhotos += fmt.Sprintf("%s,", fileName)
+		keys = append(keys, path.Join(uploadDir, photo.Filename))
 	}
 
-	err = s.repo.UpdatePhotosProfile(ctx, &repository.UpdatePhotos{
-		ID:     profile.ID,
-		Photos: strings.TrimSuffix(allPhotos, ","),
-	})
+	err = s.repo.ReplaceProfilePhotos(ctx, profile.ID, keys)
 
 	if err != nil {
 		return errpkg.DefaultServiceError(
@@ -158,46 +123,13 @@ func (s *OnboardingServiceMock) UpdatePhotos(
 		)
 	}
 
-	user, err := s.repo.GetUserById(ctx, UserID)
+	_, err = s.repo.CompleteOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_PHOTOS)
 	if err != nil {
 		return errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
 			err.Error(),
 		)
 	}
-	onboardingSteps := user.GetOnboardingSteps()
-	currentStepStatus := ""
-	for _, step := range onboardingSteps {
-		if step.Step == "photos" {
-			currentStepStatus = step.Status
-			break
-		}
-	}
-
-	if currentStepStatus == "pending" {
-		// Update Onboarding Step
-		var steps string
-		for _, step := range onboardingSteps {
-			status := step.Status
-			if step.Step == "photos" {
-				status = "done"
-			}
-			steps += fmt.Sprintf("%s:%s,", step.Step, status)
-		}
-		steps = strings.TrimSuffix(steps, ",")
-		err = s.repo.UpdateOnboardingStepsUser(ctx, &repository.UpdateOnboardingSteps{
-			ID:              UserID,
-			OnboardingSteps: steps,
-		})
-		if err != nil {
-			return errpkg.DefaultServiceError(
-				errpkg.ErrInternal,
-				err.Error(),
-			)
-		}
-	}
-
-	return nil
 
 	return nil
 }
@@ -240,44 +172,13 @@ func (s *OnboardingServiceMock) UpdateHobbyAndInterest(
 		)
 	}
 
-	user, err := s.repo.GetUserById(ctx, UserID)
+	_, err = s.repo.CompleteOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_HOBBY_AND_INTEREST)
 	if err != nil {
 		return errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
 			err.Error(),
 		)
 	}
-	onboardingSteps := user.GetOnboardingSteps()
-	currentStepStatus := ""
-	for _, step := range onboardingSteps {
-		if step.Step == "hobby-and-interest" {
-			currentStepStatus = step.Status
-			break
-		}
-	}
-
-	if currentStepStatus == "pending" {
-		// Update Onboarding Step
-		var steps string
-		for _, step := range onboardingSteps {
-			status := step.Status
-			if step.Step == "hobby-and-interest" {
-				status = "done"
-			}
-			steps += fmt.Sprintf("%s:%s,", step.Step, status)
-		}
-		steps = strings.TrimSuffix(steps, ",")
-		err = s.repo.UpdateOnboardingStepsUser(ctx, &repository.UpdateOnboardingSteps{
-			ID:              UserID,
-			OnboardingSteps: steps,
-		})
-		if err != nil {
-			return errpkg.DefaultServiceError(
-				errpkg.ErrInternal,
-				err.Error(),
-			)
-		}
-	}
 
 	return nil
 }
@@ -309,8 +210,10 @@ func (s *OnboardingServiceMock) UpdateLocation(
 		)
 	}
 	err = s.repo.UpdateLocationProfile(ctx, &repository.UpdateLocation{
-		ID:       profile.ID,
-		Location: fmt.Sprintf("%s:%s", req.Longitude, req.Latitude),
+		ID:        profile.ID,
+		Latitude:  req.Lat,
+		Longitude: req.Lon,
+		Geohash:   geohash.Encode(req.Lat, req.Lon, profileGeohashPrecision),
 	})
 	if err != nil {
 		return errpkg.DefaultServiceError(
@@ -319,45 +222,13 @@ func (s *OnboardingServiceMock) UpdateLocation(
 		)
 	}
 
-	user, err := s.repo.GetUserById(ctx, UserID)
+	_, err = s.repo.CompleteOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_LOCATION)
 	if err != nil {
 		return errpkg.DefaultServiceError(
 			errpkg.ErrInternal,
 			err.Error(),
 		)
 	}
-	onboardingSteps := user.GetOnboardingSteps()
-	currentStepStatus := ""
-	for _, step := range onboardingSteps {
-		if step.Step == "location" {
-			currentStepStatus = step.Status
-			break
-		}
-	}
-
-	if currentStepStatus == "pending" {
-		// Update Onboarding Step
-		var steps string
-		for _, step := range onboardingSteps {
-			status := step.Status
-			if step.Step == "location" {
-				status = "done"
-			}
-			steps += fmt.Sprintf("%s:%s,", step.Step, status)
-		}
-		steps = strings.TrimSuffix(steps, ",")
-		err = s.repo.UpdateOnboardingStepsUser(ctx, &repository.UpdateOnboardingSteps{
-			ID:              UserID,
-			OnboardingSteps: steps,
-		})
-		if err != nil {
-			return errpkg.DefaultServiceError(
-				errpkg.ErrInternal,
-				err.Error(),
-			)
-		}
-	}
-	return nil
 
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/ijlik/dating-user/internal/adapter/repository"
 	"github.com/ijlik/dating-user/internal/business/domain"
 	"github.com/ijlik/dating-user/pkg/constant"
+	"github.com/ijlik/dating-user/pkg/geohash"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	mocktest "github.com/stretchr/testify/mock"
@@ -46,7 +47,8 @@ func TestUpdatePersonalInfo(t *testing.T) {
 		Photos:              sql.NullString{String: "photo1.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "swimming", Valid: true},
 		Interest:            sql.NullString{String: "cooking", Valid: true},
-		Location:            sql.NullString{String: "San Francisco", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: 37.7749, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: -122.4194, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -54,10 +56,7 @@ func TestUpdatePersonalInfo(t *testing.T) {
 		UpdatedAt:           sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	getProfileByUserIDQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE user_id = \\$1 LIMIT 1"
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "location", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
-		AddRow(expectedProfile.ID, expectedProfile.UserID, expectedProfile.Name, expectedProfile.BirthDate, expectedProfile.Gender, expectedProfile.Photos, expectedProfile.Hobby, expectedProfile.Interest, expectedProfile.Location, expectedProfile.IsPremium, expectedProfile.IsPremiumValidUntil, expectedProfile.DailySwapQuota, expectedProfile.CreatedAt, expectedProfile.UpdatedAt)
-	mock.ExpectQuery(getProfileByUserIDQueryMock).WithArgs(UserID).WillReturnRows(rows)
+	expectProfileByUserID(mock, UserID, expectedProfile)
 
 	// Set up mock behavior for UpdateBasicInfoProfile
 	profileID := "profile_id_1"
@@ -66,32 +65,14 @@ func TestUpdatePersonalInfo(t *testing.T) {
 	newGender := "Female"
 
 	// Set up the expected query and result for UpdateBasicInfoProfile
-	updateBasicInfoProfileQueryMock := "UPDATE profiles SET name = \\$2, birth_date = \\$3, gender = \\$4 WHERE id = \\$1"
+	updateBasicInfoProfileQueryMock := "UPDATE profiles SET name = \\$2, birth_date = \\$3, gender = \\$4, gender_custom = \\$5, orientation = \\$6, show_me = \\$7, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
 	mock.ExpectExec(updateBasicInfoProfileQueryMock).
-		WithArgs(profileID, newName, newBirthDate, newGender).
+		WithArgs(profileID, newName, newBirthDate, newGender, nil, nil, nil).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	// Set up mock behavior for GetUserById
-	UserID = "test_user_id"
-	expectedData := &repository.User{
-		ID:              UserID,
-		Phone:           sql.NullString{},
-		Email:           "test@example.com",
-		Status:          constant.USER_STATUS_UNVERIFIED,
-		OnboardingSteps: "pending",
-		CreatedAt:       time.Now(),
-	}
-
-	getUserByIdQueryMock := "SELECT id, phone, email, status, onboarding_steps, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
-	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).WillReturnRows(sqlmock.NewRows([]string{"id", "phone", "email", "status", "onboarding_steps", "created_at", "updated_at"}).
-		AddRow(expectedData.ID, expectedData.Phone, expectedData.Email, expectedData.Status, expectedData.OnboardingSteps, expectedData.CreatedAt, nil))
-
-	// Set up mock behavior for UpdateOnboardingStepsUser
-	UserID = "test_user_id"
-	onboardingSteps := "completed"
-
-	updateOnboardingStepsUserQueryMock := "UPDATE users SET onboarding_steps = \\$2 WHERE id = \\$1"
-	mock.ExpectExec(updateOnboardingStepsUserQueryMock).WithArgs(UserID, onboardingSteps).WillReturnResult(sqlmock.NewResult(0, 1))
+	// Set up mock behavior for CompleteOnboardingStep
+	completeOnboardingStepQueryMock := "INSERT INTO user_onboarding_steps \\(user_id, step, status, completed_at, created_at\\) VALUES \\(\\$1, \\$2, \\$3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP\\) ON CONFLICT \\(user_id, step\\) DO UPDATE"
+	mock.ExpectExec(completeOnboardingStepQueryMock).WithArgs(UserID, constant.ONBOARDING_STEP_PERSONAL_INFO, constant.ONBOARDING_STEP_STATUS_DONE).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	mockOnboardingService.Mock.On("UpdatePersonalInfo", ctx, req, UserID).Return(nil)
 	// Call the function being tested
@@ -140,7 +121,8 @@ func TestUpdatePhotos(t *testing.T) {
 		Photos:              sql.NullString{String: "photo1.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "swimming", Valid: true},
 		Interest:            sql.NullString{String: "cooking", Valid: true},
-		Location:            sql.NullString{String: "San Francisco", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: 37.7749, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: -122.4194, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -148,43 +130,19 @@ func TestUpdatePhotos(t *testing.T) {
 		UpdatedAt:           sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	getProfileByUserIDQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE user_id = \\$1 LIMIT 1"
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "location", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
-		AddRow(expectedProfile.ID, expectedProfile.UserID, expectedProfile.Name, expectedProfile.BirthDate, expectedProfile.Gender, expectedProfile.Photos, expectedProfile.Hobby, expectedProfile.Interest, expectedProfile.Location, expectedProfile.IsPremium, expectedProfile.IsPremiumValidUntil, expectedProfile.DailySwapQuota, expectedProfile.CreatedAt, expectedProfile.UpdatedAt)
-	mock.ExpectQuery(getProfileByUserIDQueryMock).WithArgs(UserID).WillReturnRows(rows)
+	expectProfileByUserID(mock, UserID, expectedProfile)
 
-	// Set up mock behavior for UpdatePhotosProfile
+	// Set up mock behavior for ReplaceProfilePhotos
 	profileID := "profile_id_1"
-	photo1FileName := "storage/photos/profile_id_1/file1.png"
-	photo2FileName := "storage/photos/profile_id_1/file2.png"
-
-	// Set up the expected query and result for UpdatePhotosProfile
-	updatePhotosProfileQueryMock := "UPDATE profiles SET photos = \\$2 WHERE id = \\$1"
-	mock.ExpectExec(updatePhotosProfileQueryMock).
-		WithArgs(profileID, photo1FileName+","+photo2FileName).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	// Set up mock behavior for GetUserById
-	UserID = "test_user_id"
-	expectedData := &repository.User{
-		ID:              UserID,
-		Phone:           sql.NullString{},
-		Email:           "test@example.com",
-		Status:          constant.USER_STATUS_UNVERIFIED,
-		OnboardingSteps: "pending",
-		CreatedAt:       time.Now(),
-	}
+	photo1FileName := "photos/profile_id_1/file1.png"
+	photo2FileName := "photos/profile_id_1/file2.png"
 
-	getUserByIdQueryMock := "SELECT id, phone, email, status, onboarding_steps, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
-	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).WillReturnRows(sqlmock.NewRows([]string{"id", "phone", "email", "status", "onboarding_steps", "created_at", "updated_at"}).
-		AddRow(expectedData.ID, expectedData.Phone, expectedData.Email, expectedData.Status, expectedData.OnboardingSteps, expectedData.CreatedAt, nil))
+	// Set up the expected transaction for ReplaceProfilePhotos
+	expectReplaceProfilePhotos(mock, profileID, photo1FileName, photo2FileName)
 
-	// Set up mock behavior for UpdateOnboardingStepsUser
-	UserID = "test_user_id"
-	onboardingSteps := "completed"
-
-	updateOnboardingStepsUserQueryMock := "UPDATE users SET onboarding_steps = \\$2 WHERE id = \\$1"
-	mock.ExpectExec(updateOnboardingStepsUserQueryMock).WithArgs(UserID, onboardingSteps).WillReturnResult(sqlmock.NewResult(0, 1))
+	// Set up mock behavior for CompleteOnboardingStep
+	completeOnboardingStepQueryMock := "INSERT INTO user_onboarding_steps \\(user_id, step, status, completed_at, created_at\\) VALUES \\(\\$1, \\$2, \\$3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP\\) ON CONFLICT \\(user_id, step\\) DO UPDATE"
+	mock.ExpectExec(completeOnboardingStepQueryMock).WithArgs(UserID, constant.ONBOARDING_STEP_PHOTOS, constant.ONBOARDING_STEP_STATUS_DONE).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	mockOnboardingService.Mock.On("UpdatePhotos", ctx, req, UserID).Return(nil)
 	// Call the function being tested
@@ -223,7 +181,8 @@ func TestUpdateHobbyAndInterest(t *testing.T) {
 		Photos:              sql.NullString{String: "photo1.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "swimming", Valid: true},
 		Interest:            sql.NullString{String: "cooking", Valid: true},
-		Location:            sql.NullString{String: "San Francisco", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: 37.7749, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: -122.4194, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -231,10 +190,7 @@ func TestUpdateHobbyAndInterest(t *testing.T) {
 		UpdatedAt:           sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	getProfileByUserIDQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE user_id = \\$1 LIMIT 1"
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "location", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
-		AddRow(expectedProfile.ID, expectedProfile.UserID, expectedProfile.Name, expectedProfile.BirthDate, expectedProfile.Gender, expectedProfile.Photos, expectedProfile.Hobby, expectedProfile.Interest, expectedProfile.Location, expectedProfile.IsPremium, expectedProfile.IsPremiumValidUntil, expectedProfile.DailySwapQuota, expectedProfile.CreatedAt, expectedProfile.UpdatedAt)
-	mock.ExpectQuery(getProfileByUserIDQueryMock).WithArgs(UserID).WillReturnRows(rows)
+	expectProfileByUserID(mock, UserID, expectedProfile)
 
 	// Set up mock behavior for UpdateHobbyAndInterestProfile
 	profileID := "profile_id_1"
@@ -242,32 +198,14 @@ func TestUpdateHobbyAndInterest(t *testing.T) {
 	newInterest := "Cooking,Reading"
 
 	// Set up the expected query and result for UpdateHobbyAndInterestProfile
-	updateHobbyAndInterestProfileQueryMock := "UPDATE profiles SET hobby = \\$2, interest = \\$3 WHERE id = \\$1"
+	updateHobbyAndInterestProfileQueryMock := "UPDATE profiles SET hobby = \\$2, interest = \\$3, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
 	mock.ExpectExec(updateHobbyAndInterestProfileQueryMock).
 		WithArgs(profileID, newHobby, newInterest).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	// Set up mock behavior for GetUserById
-	UserID = "test_user_id"
-	expectedData := &repository.User{
-		ID:              UserID,
-		Phone:           sql.NullString{},
-		Email:           "test@example.com",
-		Status:          constant.USER_STATUS_UNVERIFIED,
-		OnboardingSteps: "pending",
-		CreatedAt:       time.Now(),
-	}
-
-	getUserByIdQueryMock := "SELECT id, phone, email, status, onboarding_steps, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
-	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).WillReturnRows(sqlmock.NewRows([]string{"id", "phone", "email", "status", "onboarding_steps", "created_at", "updated_at"}).
-		AddRow(expectedData.ID, expectedData.Phone, expectedData.Email, expectedData.Status, expectedData.OnboardingSteps, expectedData.CreatedAt, nil))
-
-	// Set up mock behavior for UpdateOnboardingStepsUser
-	UserID = "test_user_id"
-	onboardingSteps := "completed"
-
-	updateOnboardingStepsUserQueryMock := "UPDATE users SET onboarding_steps = \\$2 WHERE id = \\$1"
-	mock.ExpectExec(updateOnboardingStepsUserQueryMock).WithArgs(UserID, onboardingSteps).WillReturnResult(sqlmock.NewResult(0, 1))
+	// Set up mock behavior for CompleteOnboardingStep
+	completeOnboardingStepQueryMock := "INSERT INTO user_onboarding_steps \\(user_id, step, status, completed_at, created_at\\) VALUES \\(\\$1, \\$2, \\$3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP\\) ON CONFLICT \\(user_id, step\\) DO UPDATE"
+	mock.ExpectExec(completeOnboardingStepQueryMock).WithArgs(UserID, constant.ONBOARDING_STEP_HOBBY_AND_INTEREST, constant.ONBOARDING_STEP_STATUS_DONE).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	mockOnboardingService.Mock.On("UpdateHobbyAndInterest", ctx, req, UserID).Return(nil)
 	// Call the function being tested
@@ -295,6 +233,7 @@ func TestUpdateLocation(t *testing.T) {
 		Longitude: "45.1234",
 		Latitude:  "-76.5678",
 	}
+	assert.Nil(t, req.Validate())
 
 	// Set up mock behavior for GetProfileByUserID
 	expectedProfile := &repository.Profile{
@@ -306,7 +245,8 @@ func TestUpdateLocation(t *testing.T) {
 		Photos:              sql.NullString{String: "photo1.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "swimming", Valid: true},
 		Interest:            sql.NullString{String: "cooking", Valid: true},
-		Location:            sql.NullString{String: "San Francisco", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: 37.7749, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: -122.4194, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -314,42 +254,20 @@ func TestUpdateLocation(t *testing.T) {
 		UpdatedAt:           sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	getProfileByUserIDQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE user_id = \\$1 LIMIT 1"
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "location", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
-		AddRow(expectedProfile.ID, expectedProfile.UserID, expectedProfile.Name, expectedProfile.BirthDate, expectedProfile.Gender, expectedProfile.Photos, expectedProfile.Hobby, expectedProfile.Interest, expectedProfile.Location, expectedProfile.IsPremium, expectedProfile.IsPremiumValidUntil, expectedProfile.DailySwapQuota, expectedProfile.CreatedAt, expectedProfile.UpdatedAt)
-	mock.ExpectQuery(getProfileByUserIDQueryMock).WithArgs(UserID).WillReturnRows(rows)
+	expectProfileByUserID(mock, UserID, expectedProfile)
 
 	// Set up mock behavior for UpdateLocationProfile
 	profileID := "profile_id_1"
-	newLocation := "45.1234:-76.5678"
 
 	// Set up the expected query and result for UpdateLocationProfile
-	updateLocationProfileQueryMock := "UPDATE profiles SET location = \\$2 WHERE id = \\$1"
+	updateLocationProfileQueryMock := "UPDATE profiles SET latitude = \\$2, longitude = \\$3, geohash = \\$4, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
 	mock.ExpectExec(updateLocationProfileQueryMock).
-		WithArgs(profileID, newLocation).
+		WithArgs(profileID, -76.5678, 45.1234, geohash.Encode(-76.5678, 45.1234, profileGeohashPrecision)).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	// Set up mock behavior for GetUserById
-	UserID = "test_user_id"
-	expectedData := &repository.User{
-		ID:              UserID,
-		Phone:           sql.NullString{},
-		Email:           "test@example.com",
-		Status:          constant.USER_STATUS_UNVERIFIED,
-		OnboardingSteps: "pending",
-		CreatedAt:       time.Now(),
-	}
-
-	getUserByIdQueryMock := "SELECT id, phone, email, status, onboarding_steps, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
-	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).WillReturnRows(sqlmock.NewRows([]string{"id", "phone", "email", "status", "onboarding_steps", "created_at", "updated_at"}).
-		AddRow(expectedData.ID, expectedData.Phone, expectedData.Email, expectedData.Status, expectedData.OnboardingSteps, expectedData.CreatedAt, nil))
-
-	// Set up mock behavior for UpdateOnboardingStepsUser
-	UserID = "test_user_id"
-	onboardingSteps := "completed"
-
-	updateOnboardingStepsUserQueryMock := "UPDATE users SET onboarding_steps = \\$2 WHERE id = \\$1"
-	mock.ExpectExec(updateOnboardingStepsUserQueryMock).WithArgs(UserID, onboardingSteps).WillReturnResult(sqlmock.NewResult(0, 1))
+	// Set up mock behavior for CompleteOnboardingStep
+	completeOnboardingStepQueryMock := "INSERT INTO user_onboarding_steps \\(user_id, step, status, completed_at, created_at\\) VALUES \\(\\$1, \\$2, \\$3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP\\) ON CONFLICT \\(user_id, step\\) DO UPDATE"
+	mock.ExpectExec(completeOnboardingStepQueryMock).WithArgs(UserID, constant.ONBOARDING_STEP_LOCATION, constant.ONBOARDING_STEP_STATUS_DONE).WillReturnResult(sqlmock.NewResult(0, 1))
 
 	mockOnboardingService.Mock.On("UpdateLocation", ctx, req, UserID).Return(nil)
 	// Call the function being tested
@@ -358,3 +276,27 @@ func TestUpdateLocation(t *testing.T) {
 	// Assertions
 	assert.Nil(t, err, "Expected no error")
 }
+
+func TestLocationValidate(t *testing.T) {
+	req := &domain.Location{Longitude: "106.8456", Latitude: "-6.2088"}
+	assert.Nil(t, req.Validate())
+	assert.Equal(t, 106.8456, req.Lon)
+	assert.Equal(t, -6.2088, req.Lat)
+
+	for _, tc := range []struct {
+		longitude string
+		latitude  string
+		message   string
+	}{
+		{"106.8", "500", "latitude must be between -90 and 90"},
+		{"106.8", "-90.1", "latitude must be between -90 and 90"},
+		{"180.5", "-6.2", "longitude must be between -180 and 180"},
+		{"1e2", "-6.2", "invalid format longitude"},
+		{"", "-6.2", "missing longitude"},
+	} {
+		errs := (&domain.Location{Longitude: tc.longitude, Latitude: tc.latitude}).Validate()
+		if assert.NotNil(t, errs) {
+			assert.Equal(t, tc.message, errs.Error())
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+)
+
+type onboardingStepDefinition struct {
+	Step      constant.OnboardingStep
+	Required  bool
+	DependsOn []constant.OnboardingStep
+}
+
+// onboardingFlow every onboarding step in the order shown to the user, a
+// step added here is pending for every user until it is completed
+var onboardingFlow = []onboardingStepDefinition{
+	{
+		Step:     constant.ONBOARDING_STEP_PERSONAL_INFO,
+		Required: true,
+	},
+	{
+		Step:      constant.ONBOARDING_STEP_PHOTOS,
+		Required:  true,
+		DependsOn: []constant.OnboardingStep{constant.ONBOARDING_STEP_PERSONAL_INFO},
+	},
+	{
+		Step:      constant.ONBOARDING_STEP_HOBBY_AND_INTEREST,
+		Required:  true,
+		DependsOn: []constant.OnboardingStep{constant.ONBOARDING_STEP_PERSONAL_INFO},
+	},
+	{
+		Step:      constant.ONBOARDING_STEP_LOCATION,
+		Required:  true,
+		DependsOn: []constant.OnboardingStep{constant.ONBOARDING_STEP_PERSONAL_INFO},
+	},
+}
+
+func findOnboardingStep(step constant.OnboardingStep) *onboardingStepDefinition {
+	for i := range onboardingFlow {
+		if onboardingFlow[i].Step == step {
+			return &onboardingFlow[i]
+		}
+	}
+
+	return nil
+}
+
+// doneOnboardingSteps steps already completed by the user, step without row
+// is pending
+func doneOnboardingSteps(rows []*repository.OnboardingStep) map[constant.OnboardingStep]*repository.OnboardingStep {
+	done := make(map[constant.OnboardingStep]*repository.OnboardingStep)
+	for _, row := range rows {
+		if row.Status == constant.ONBOARDING_STEP_STATUS_DONE {
+			done[row.Step] = row
+		}
+	}
+
+	return done
+}
+
+// checkOnboardingStep step can only be completed once its dependencies are
+// done
+func (s *service) checkOnboardingStep(
+	ctx context.Context,
+	UserID string,
+	step constant.OnboardingStep,
+) errpkg.ErrorService {
+	definition := findOnboardingStep(step)
+	if definition == nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			fmt.Sprintf("unknown onboarding step %s", step),
+		)
+	}
+	if len(definition.DependsOn) == 0 {
+		return nil
+	}
+
+	rows, err := s.repo.GetOnboardingStepsByUserID(ctx, UserID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	done := doneOnboardingSteps(rows)
+	for _, dependency := range definition.DependsOn {
+		if _, ok := done[dependency]; !ok {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrBadRequest,
+				fmt.Sprintf("complete %s step first", dependency),
+			)
+		}
+	}
+
+	return nil
+}
+
+// completeOnboardingStep mark the step done, completing a done step again
+// is a no-op. Default discovery preferences are created once the last
+// required step is done
+func (s *service) completeOnboardingStep(
+	ctx context.Context,
+	UserID string,
+	step constant.OnboardingStep,
+) errpkg.ErrorService {
+	completed, err := s.repo.CompleteOnboardingStep(ctx, UserID, step)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !completed {
+		return nil
+	}
+
+	rows, err := s.repo.GetOnboardingStepsByUserID(ctx, UserID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !OnboardingProgressRes(rows).IsCompleted {
+		return nil
+	}
+
+	return s.createDefaultDiscoveryPreferences(ctx, UserID)
+}
+
+func (s *service) GetOnboardingProgress(
+	ctx context.Context,
+	UserID string,
+) (*domain.OnboardingProgress, errpkg.ErrorService) {
+	rows, err := s.repo.GetOnboardingStepsByUserID(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return OnboardingProgressRes(rows), nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	"github.com/ijlik/dating-user/pkg/geohash"
+	"github.com/stretchr/testify/assert"
+)
+
+var onboardingStepColumns = []string{"user_id", "step", "status", "completed_at", "created_at"}
+
+func expectOnboardingSteps(mock sqlmock.Sqlmock, UserID string, done ...constant.OnboardingStep) {
+	rows := sqlmock.NewRows(onboardingStepColumns)
+	for _, step := range done {
+		rows.AddRow(UserID, step, constant.ONBOARDING_STEP_STATUS_DONE, time.Now(), time.Now())
+	}
+
+	getOnboardingStepsByUserIDQueryMock := "SELECT user_id, step, status, completed_at, created_at FROM user_onboarding_steps WHERE user_id = \\$1"
+	mock.ExpectQuery(getOnboardingStepsByUserIDQueryMock).WithArgs(UserID).WillReturnRows(rows)
+}
+
+func TestGetOnboardingProgress(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	// step done out of order is counted, next step is the first pending one
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO, constant.ONBOARDING_STEP_HOBBY_AND_INTEREST)
+
+	progress, errs := svc.GetOnboardingProgress(ctx, UserID)
+	assert.Nil(t, errs)
+	assert.Equal(t, 2, progress.CompletedSteps)
+	assert.Equal(t, 4, progress.TotalSteps)
+	assert.False(t, progress.IsCompleted)
+	assert.Equal(t, "photos", progress.NextStep)
+	assert.Equal(t, []string{"photos", "location"}, progress.MissingSteps)
+	assert.Equal(t, "done", progress.Steps[0].Status)
+	assert.NotNil(t, progress.Steps[0].CompletedAt)
+	assert.Equal(t, "pending", progress.Steps[1].Status)
+	assert.True(t, progress.Steps[2].Required)
+	assert.Equal(t, []string{"personal-info"}, progress.Steps[3].DependsOn)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOnboardingProgressRes(t *testing.T) {
+	// new user start from the first step
+	progress := OnboardingProgressRes(nil)
+	assert.Equal(t, 0, progress.CompletedSteps)
+	assert.Equal(t, "personal-info", progress.NextStep)
+
+	// hobby and interest is required, feeds only show profiles having them
+	progress = OnboardingProgressRes([]*repository.OnboardingStep{
+		{Step: constant.ONBOARDING_STEP_PERSONAL_INFO, Status: constant.ONBOARDING_STEP_STATUS_DONE},
+		{Step: constant.ONBOARDING_STEP_PHOTOS, Status: constant.ONBOARDING_STEP_STATUS_DONE},
+		{Step: constant.ONBOARDING_STEP_HOBBY_AND_INTEREST, Status: constant.ONBOARDING_STEP_STATUS_PENDING},
+		{Step: constant.ONBOARDING_STEP_LOCATION, Status: constant.ONBOARDING_STEP_STATUS_DONE},
+	})
+	assert.False(t, progress.IsCompleted)
+	assert.Equal(t, "hobby-and-interest", progress.NextStep)
+	assert.Equal(t, []string{"hobby-and-interest"}, progress.MissingSteps)
+	assert.Equal(t, 3, progress.CompletedSteps)
+
+	// every step done
+	progress = OnboardingProgressRes([]*repository.OnboardingStep{
+		{Step: constant.ONBOARDING_STEP_PERSONAL_INFO, Status: constant.ONBOARDING_STEP_STATUS_DONE},
+		{Step: constant.ONBOARDING_STEP_PHOTOS, Status: constant.ONBOARDING_STEP_STATUS_DONE},
+		{Step: constant.ONBOARDING_STEP_HOBBY_AND_INTEREST, Status: constant.ONBOARDING_STEP_STATUS_DONE},
+		{Step: constant.ONBOARDING_STEP_LOCATION, Status: constant.ONBOARDING_STEP_STATUS_DONE},
+	})
+	assert.True(t, progress.IsCompleted)
+	assert.Empty(t, progress.NextStep)
+	assert.Empty(t, progress.MissingSteps)
+	assert.Equal(t, 4, progress.CompletedSteps)
+}
+
+func TestUpdateLocationDependencyPending(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	expectOnboardingSteps(mock, UserID)
+
+	errs := svc.UpdateLocation(ctx, &domain.Location{Longitude: "106.8", Latitude: "-6.2", Lon: 106.8, Lat: -6.2}, UserID)
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.Equal(t, "complete personal-info step first", errs.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateLocationCompleteStep(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO)
+
+	expectProfileByUserID(mock, UserID, testProfile(UserID))
+
+	updateLocationProfileQueryMock := "UPDATE profiles SET latitude = \\$2, longitude = \\$3, geohash = \\$4, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
+	mock.ExpectExec(updateLocationProfileQueryMock).WithArgs("profile_id_1", -6.2, 106.8, geohash.Encode(-6.2, 106.8, profileGeohashPrecision)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	completeOnboardingStepQueryMock := "INSERT INTO user_onboarding_steps \\(user_id, step, status, completed_at, created_at\\)"
+	mock.ExpectExec(completeOnboardingStepQueryMock).
+		WithArgs(UserID, constant.ONBOARDING_STEP_LOCATION, constant.ONBOARDING_STEP_STATUS_DONE).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// photos is still missing, onboarding is not completed yet
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO, constant.ONBOARDING_STEP_LOCATION)
+
+	errs := svc.UpdateLocation(ctx, &domain.Location{Longitude: "106.8", Latitude: "-6.2", Lon: 106.8, Lat: -6.2}, UserID)
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
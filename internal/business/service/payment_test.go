@@ -44,7 +44,8 @@ func TestCreatePayment(t *testing.T) {
 		Photos:              sql.NullString{String: "photo1.jpg", Valid: true},
 		Hobby:               sql.NullString{String: "swimming", Valid: true},
 		Interest:            sql.NullString{String: "cooking", Valid: true},
-		Location:            sql.NullString{String: "San Francisco", Valid: true},
+		Latitude:            sql.NullFloat64{Float64: 37.7749, Valid: true},
+		Longitude:           sql.NullFloat64{Float64: -122.4194, Valid: true},
 		IsPremium:           true,
 		IsPremiumValidUntil: sql.NullTime{Time: time.Now().AddDate(1, 0, 0), Valid: true},
 		DailySwapQuota:      10,
@@ -60,10 +61,7 @@ func TestCreatePayment(t *testing.T) {
 	dailySwapQuota := -1
 
 	// Mock the GetProfileByUserID function to return the expected profile data
-	getProfileByUserIDQueryMock := "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, location, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at FROM profiles WHERE user_id = \\$1 LIMIT 1"
-	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "location", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at"}).
-		AddRow(expectedProfile.ID, expectedProfile.UserID, expectedProfile.Name, expectedProfile.BirthDate, expectedProfile.Gender, expectedProfile.Photos, expectedProfile.Hobby, expectedProfile.Interest, expectedProfile.Location, expectedProfile.IsPremium, expectedProfile.IsPremiumValidUntil, expectedProfile.DailySwapQuota, expectedProfile.CreatedAt, expectedProfile.UpdatedAt)
-	mock.ExpectQuery(getProfileByUserIDQueryMock).WithArgs(UserID).WillReturnRows(rows)
+	expectProfileByUserID(mock, UserID, expectedProfile)
 
 	// Mock the CreatePayment function to return nil (success)
 	createPaymentQueryMock := "INSERT INTO payments \\(user_id, amount, identifier, payment_method, payment_data, status, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6, CURRENT_TIMESTAMP\\)"
@@ -72,7 +70,7 @@ func TestCreatePayment(t *testing.T) {
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Set up the expected query and result for UpdatePremiumStatusProfile
-	updatePremiumStatusProfileQueryMock := "UPDATE profiles SET is_premium = \\$2, is_premium_valid_until = \\$3, daily_swap_quota = \\$4 WHERE id = \\$1"
+	updatePremiumStatusProfileQueryMock := "UPDATE profiles SET is_premium = \\$2, is_premium_valid_until = \\$3, daily_swap_quota = \\$4, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
 	mock.ExpectExec(updatePremiumStatusProfileQueryMock).
 		WithArgs(profileID, isPremium, premiumValidUntil, dailySwapQuota).
 		WillReturnResult(sqlmock.NewResult(1, 1))
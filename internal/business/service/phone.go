@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ijlik/dating-user/internal/adapter/redis"
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+)
+
+// pendingPhoneKey phone waiting for otp confirmation, the user record is only
+// updated once the code is verified
+func pendingPhoneKey(UserID string) string {
+	return fmt.Sprintf("pending_phone:%s", UserID)
+}
+
+// phoneHolder return user which already own the phone, unverified holder is
+// a phone registration which never logged in and does not block the phone
+func (s *service) phoneHolder(
+	ctx context.Context,
+	UserID, phone string,
+) (*repository.User, errpkg.ErrorService) {
+	holder, err := s.repo.GetUserByPhone(ctx, phone)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	if holder == nil || holder.ID == UserID {
+		return nil, nil
+	}
+
+	if holder.Status != constant.USER_STATUS_UNVERIFIED {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrAlreadyRegistered,
+			"phone is already registered",
+		)
+	}
+
+	return holder, nil
+}
+
+// sendNewPhoneOtp code is sent directly to the new phone, resend interval of
+// login otp does not apply since every request target a new phone
+func (s *service) sendNewPhoneOtp(
+	ctx context.Context,
+	UserID, phone string,
+) error {
+	sender, ok := s.otpSender.Get(constant.OTP_TYPE_SMS)
+	if !ok {
+		return errors.New("otp channel SMS is not supported")
+	}
+
+	otpNumber := s.generateOtpCode()
+	if !otpNumber.Valid {
+		return errors.New("failed to generate otp")
+	}
+
+	hashedCode, err := s.hasher.Hash(boundOtpCode(phone, otpNumber.String()))
+	if err != nil {
+		return err
+	}
+
+	if err = sender.Send(ctx, phone, otpNumber.String()); err != nil {
+		return err
+	}
+
+	return s.repo.CreateOneTimePasswordLog(ctx, &repository.OneTimePasswordLog{
+		UserID:              UserID,
+		OneTimePasswordType: constant.OTP_TYPE_NEW_PHONE,
+		Code:                hashedCode,
+		Status:              constant.OTP_STATUS_UNUSED,
+		CreatedAt:           s.time.Now(),
+		OTPLimit:            s.config.GetInt("OTP_MAX_TRY_LIMIT"),
+	})
+}
+
+// AttachPhone send otp by sms to the new phone, phone is attached by
+// VerifyPhone
+func (s *service) AttachPhone(
+	ctx context.Context,
+	UserID string,
+	req *domain.AttachPhoneRequest,
+) (*domain.ResendOtpResponse, errpkg.ErrorService) {
+	user, err := s.repo.GetUserById(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if user == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"user not found",
+		)
+	}
+
+	if user.VerifiedPhone() == req.Phone {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"phone is already verified",
+		)
+	}
+
+	if _, errs := s.phoneHolder(ctx, UserID, req.Phone); errs != nil {
+		return nil, errs
+	}
+
+	err = s.redis.SetWithExpiration(ctx, pendingPhoneKey(UserID), req.Phone, s.otpExpiry())
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	if err = s.sendNewPhoneOtp(ctx, UserID, req.Phone); err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	interval := s.config.GetInt("RESEND_OTP_INTERVAL")
+	if interval == 0 {
+		interval = 60
+	}
+
+	return &domain.ResendOtpResponse{
+		Message:           fmt.Sprintf("OTP already sent to your phone: %s.", req.Phone),
+		ResendOTPInterval: interval,
+	}, nil
+}
+
+// VerifyPhone check the sms otp and attach the pending phone to the user
+func (s *service) VerifyPhone(
+	ctx context.Context,
+	UserID string,
+	req *domain.VerifyPhoneRequest,
+) errpkg.ErrorService {
+	phone, err := s.redis.Get(ctx, pendingPhoneKey(UserID))
+	if err == redis.Nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"no phone waiting for verification",
+		)
+	}
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	otp, errs := s.checkOtpCode(ctx, UserID, constant.OTP_TYPE_NEW_PHONE, phone, req.Otp)
+	if errs != nil {
+		return errs
+	}
+
+	// phone may be taken while waiting for the code
+	holder, errs := s.phoneHolder(ctx, UserID, phone)
+	if errs != nil {
+		return errs
+	}
+	if holder != nil {
+		err = s.repo.DeleteUser(ctx, holder.ID)
+		if err != nil {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+	}
+
+	err = s.repo.UpdatePhoneUser(ctx, &repository.UpdatePhone{
+		ID:    UserID,
+		Phone: phone,
+	})
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	err = s.repo.UpdateStatusOneTimePasswordLog(ctx, constant.OTP_STATUS_USED, otp.ID, otp.OTPLimit-1)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	if err = s.redis.Del(ctx, pendingPhoneKey(UserID)); err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return nil
+}
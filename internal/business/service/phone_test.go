@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	"github.com/stretchr/testify/assert"
+)
+
+var phoneTestConfig = map[string]interface{}{
+	"RESEND_OTP_INTERVAL": "60",
+	"OTP_MAX_TRY_LIMIT":   "3",
+}
+
+var userColumns = []string{"id", "phone", "email", "status", "created_at", "updated_at"}
+
+func TestAttachPhone(t *testing.T) {
+	svc, mock, rdb, _, sms := newFakeSenderTestService(t, phoneTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	phone := "+6281234567890"
+
+	getUserByIdQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(UserID, nil, "test@example.com", constant.USER_STATUS_ACTIVE, time.Now(), nil))
+
+	getUserByPhoneQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE phone = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByPhoneQueryMock).WithArgs(phone).
+		WillReturnRows(sqlmock.NewRows(userColumns))
+
+	updatePreviousUnusedOtpToExpiredMock := "UPDATE one_time_password_logs SET status = 'EXPIRED' WHERE status = 'UNUSED' AND user_id = \\$1"
+	mock.ExpectExec(updatePreviousUnusedOtpToExpiredMock).
+		WithArgs(UserID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	createOneTimePasswordLogMock := "INSERT INTO one_time_password_logs \\(user_id, onetime_password_type, code, status, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5\\)"
+	mock.ExpectExec(createOneTimePasswordLogMock).
+		WithArgs(UserID, constant.OTP_TYPE_NEW_PHONE, hashedCodeArg{hasher: svc.hasher, code: func() string { return boundOtpCode(phone, sms.LastCode(phone)) }}, constant.OTP_STATUS_UNUSED, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// local format is normalised before validation
+	req := &domain.AttachPhoneRequest{Phone: "0062 812-3456-7890"}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, phone, req.Phone)
+
+	res, errs := svc.AttachPhone(ctx, UserID, req)
+	assert.Nil(t, errs)
+	assert.Equal(t, 60, res.ResendOTPInterval)
+	assert.Len(t, sms.LastCode(phone), 6)
+	assert.Equal(t, phone, rdb.values[pendingPhoneKey(UserID)])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachPhoneAlreadyRegistered(t *testing.T) {
+	svc, mock, _, _, sms := newFakeSenderTestService(t, phoneTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	phone := "+6281234567890"
+
+	getUserByIdQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(UserID, nil, "test@example.com", constant.USER_STATUS_ACTIVE, time.Now(), nil))
+
+	getUserByPhoneQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE phone = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByPhoneQueryMock).WithArgs(phone).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow("user_id_2", phone, nil, constant.USER_STATUS_ACTIVE, time.Now(), nil))
+
+	_, errs := svc.AttachPhone(ctx, UserID, &domain.AttachPhoneRequest{Phone: phone})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrAlreadyRegistered, errs.GetCode())
+	assert.Empty(t, sms.Messages())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerifyPhone(t *testing.T) {
+	svc, mock, rdb, _, _ := newFakeSenderTestService(t, phoneTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	phone := "+6281234567890"
+	rdb.values[pendingPhoneKey(UserID)] = phone
+
+	hashedCode, err := svc.hasher.Hash(boundOtpCode("+6281234567890", "A1B2C3"))
+	assert.NoError(t, err)
+
+	getOneTimePasswordLogByUserAndTypeMock := "SELECT id, user_id, onetime_password_type, code, status, created_at, otp_limit FROM one_time_password_logs WHERE user_id = \\$1 AND onetime_password_type = \\$2 ORDER BY created_at DESC LIMIT 1"
+	mock.ExpectQuery(getOneTimePasswordLogByUserAndTypeMock).
+		WithArgs(UserID, constant.OTP_TYPE_NEW_PHONE).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "code", "status", "created_at", "otp_limit"}).
+			AddRow("log_id_1", UserID, constant.OTP_TYPE_NEW_PHONE, hashedCode, constant.OTP_STATUS_UNUSED, time.Now().UTC(), 3))
+
+	// phone registration which never logged in is released
+	getUserByPhoneQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE phone = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByPhoneQueryMock).WithArgs(phone).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow("user_id_stub", phone, nil, constant.USER_STATUS_UNVERIFIED, time.Now(), nil))
+	expectDeleteUser(mock, "user_id_stub")
+
+	updatePhoneUserQueryMock := "UPDATE users SET phone = \\$2, updated_at = CURRENT_TIMESTAMP WHERE id = \\$1"
+	mock.ExpectExec(updatePhoneUserQueryMock).WithArgs(UserID, phone).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	updateStatusOtpLogQueryMock := "UPDATE one_time_password_logs SET status = \\$1, updated_at = \\$2, otp_limit = \\$3 WHERE id = \\$4"
+	mock.ExpectExec(updateStatusOtpLogQueryMock).
+		WithArgs(constant.OTP_STATUS_USED, sqlmock.AnyArg(), 2, "log_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errs := svc.VerifyPhone(ctx, UserID, &domain.VerifyPhoneRequest{Otp: " a1b2c3 "})
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, pending := rdb.values[pendingPhoneKey(UserID)]
+	assert.False(t, pending)
+}
+
+func TestVerifyPhoneInvalidOtp(t *testing.T) {
+	svc, mock, rdb, _, _ := newFakeSenderTestService(t, phoneTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	rdb.values[pendingPhoneKey(UserID)] = "+6281234567890"
+
+	hashedCode, err := svc.hasher.Hash(boundOtpCode("+6281234567890", "123456"))
+	assert.NoError(t, err)
+
+	getOneTimePasswordLogByUserAndTypeMock := "SELECT id, user_id, onetime_password_type, code, status, created_at, otp_limit FROM one_time_password_logs WHERE user_id = \\$1 AND onetime_password_type = \\$2 ORDER BY created_at DESC LIMIT 1"
+	mock.ExpectQuery(getOneTimePasswordLogByUserAndTypeMock).
+		WithArgs(UserID, constant.OTP_TYPE_NEW_PHONE).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "code", "status", "created_at", "otp_limit"}).
+			AddRow("log_id_1", UserID, constant.OTP_TYPE_NEW_PHONE, hashedCode, constant.OTP_STATUS_UNUSED, time.Now().UTC(), 3))
+
+	updateStatusOtpLogQueryMock := "UPDATE one_time_password_logs SET status = \\$1, updated_at = \\$2, otp_limit = \\$3 WHERE id = \\$4"
+	mock.ExpectExec(updateStatusOtpLogQueryMock).
+		WithArgs(constant.OTP_STATUS_UNUSED, sqlmock.AnyArg(), 2, "log_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	errs := svc.VerifyPhone(ctx, UserID, &domain.VerifyPhoneRequest{Otp: "654321"})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrUnauthorize, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// phone stay pending for another try
+	assert.Equal(t, "+6281234567890", rdb.values[pendingPhoneKey(UserID)])
+}
+
+func TestAttachPhoneAfterLoginOtp(t *testing.T) {
+	svc, mock, rdb, _, sms := newFakeSenderTestService(t, phoneTestConfig)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	oldPhone := "+6281234567891"
+	phone := "+6281234567890"
+
+	// login code was just sent to the current phone
+	assert.NoError(t, sms.Send(ctx, oldPhone, "111111"))
+
+	getUserByIdQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByIdQueryMock).WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(UserID, oldPhone, nil, constant.USER_STATUS_ACTIVE, time.Now(), nil))
+
+	getUserByPhoneQueryMock := "SELECT id, phone, email, status, created_at, updated_at FROM users WHERE phone = \\$1 LIMIT 1"
+	mock.ExpectQuery(getUserByPhoneQueryMock).WithArgs(phone).
+		WillReturnRows(sqlmock.NewRows(userColumns))
+
+	// resend interval of the login otp does not hold the code back
+	updatePreviousUnusedOtpToExpiredMock := "UPDATE one_time_password_logs SET status = 'EXPIRED' WHERE status = 'UNUSED' AND user_id = \\$1"
+	mock.ExpectExec(updatePreviousUnusedOtpToExpiredMock).WithArgs(UserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	createOneTimePasswordLogMock := "INSERT INTO one_time_password_logs \\(user_id, onetime_password_type, code, status, created_at\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5\\)"
+	mock.ExpectExec(createOneTimePasswordLogMock).
+		WithArgs(UserID, constant.OTP_TYPE_NEW_PHONE, sqlmock.AnyArg(), constant.OTP_STATUS_UNUSED, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, errs := svc.AttachPhone(ctx, UserID, &domain.AttachPhoneRequest{Phone: phone})
+	assert.Nil(t, errs)
+	code := sms.LastCode(phone)
+	assert.Len(t, code, 6)
+	assert.Equal(t, phone, rdb.values[pendingPhoneKey(UserID)])
+
+	// login code does not confirm the new phone, neither does the code of
+	// another phone
+	hashedCode, err := svc.hasher.Hash(boundOtpCode(phone, code))
+	assert.NoError(t, err)
+	getOneTimePasswordLogByUserAndTypeMock := "SELECT id, user_id, onetime_password_type, code, status, created_at, otp_limit FROM one_time_password_logs WHERE user_id = \\$1 AND onetime_password_type = \\$2 ORDER BY created_at DESC LIMIT 1"
+	updateStatusOtpLogQueryMock := "UPDATE one_time_password_logs SET status = \\$1, updated_at = \\$2, otp_limit = \\$3 WHERE id = \\$4"
+	for i, pending := range []string{phone, oldPhone} {
+		rdb.values[pendingPhoneKey(UserID)] = pending
+		otp := "111111"
+		if pending == oldPhone {
+			otp = code
+		}
+
+		mock.ExpectQuery(getOneTimePasswordLogByUserAndTypeMock).
+			WithArgs(UserID, constant.OTP_TYPE_NEW_PHONE).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "onetime_password_type", "code", "status", "created_at", "otp_limit"}).
+				AddRow("log_id_1", UserID, constant.OTP_TYPE_NEW_PHONE, hashedCode, constant.OTP_STATUS_UNUSED, time.Now().UTC(), 3-i))
+		mock.ExpectExec(updateStatusOtpLogQueryMock).
+			WithArgs(constant.OTP_STATUS_UNUSED, sqlmock.AnyArg(), 2-i, "log_id_1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		errs = svc.VerifyPhone(ctx, UserID, &domain.VerifyPhoneRequest{Otp: otp})
+		assert.NotNil(t, errs)
+		assert.Equal(t, errpkg.ErrUnauthorize, errs.GetCode())
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
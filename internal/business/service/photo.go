@@ -0,0 +1,534 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"mime/multipart"
+	"strings"
+
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	blobstorepkg "github.com/ijlik/dating-user/pkg/blobstore"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	imagingpkg "github.com/ijlik/dating-user/pkg/imaging"
+)
+
+const (
+	photoRenditionThumb = "thumb"
+	photoRenditionCard  = "card"
+	photoRenditionFull  = "full"
+
+	photoJPEGQuality = 85
+)
+
+// photoRenditions largest first, each one is resized from the previous
+var photoRenditions = []struct {
+	name         string
+	maxDimension int
+}{
+	{photoRenditionFull, 1600},
+	{photoRenditionCard, 640},
+	{photoRenditionThumb, 160},
+}
+
+// photoKeyPrefix every photo of the profile is stored under this prefix
+func photoKeyPrefix(profileID string) string {
+	return "photos/" + profileID
+}
+
+// photoRenditionKey photo is referred by the key of its full rendition, photo
+// stored before renditions existed is its own rendition
+func photoRenditionKey(key, rendition string) string {
+	suffix := "_" + photoRenditionFull + ".jpg"
+	if !strings.HasSuffix(key, suffix) {
+		return key
+	}
+	return strings.TrimSuffix(key, suffix) + "_" + rendition + ".jpg"
+}
+
+// uploadedPhoto decoded upload, not stored yet
+type uploadedPhoto struct {
+	key string
+	img *image.RGBA
+}
+
+// decodePhoto upload is decoded and re-encoded when stored, so nothing but
+// pixels is kept (EXIF location included). Key is derived from the uploaded
+// content, uploading the same photo twice keep one photo
+func decodePhoto(
+	profileID string,
+	photo *multipart.FileHeader,
+) (*uploadedPhoto, errpkg.ErrorService) {
+	src, err := photo.Open()
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	defer src.Close()
+
+	content, err := io.ReadAll(src)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	// content type header sent by the client is not trusted
+	img, err := imagingpkg.Decode(content)
+	if err == imagingpkg.ErrUnsupportedFormat {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"invalid file type: only JPEG and PNG images are allowed",
+		)
+	}
+	if err == imagingpkg.ErrTooLarge {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			err.Error(),
+		)
+	}
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"invalid image: the file could not be decoded",
+		)
+	}
+
+	return &uploadedPhoto{
+		key: blobstorepkg.ContentKey(photoKeyPrefix(profileID), content, "_"+photoRenditionFull+".jpg"),
+		img: img,
+	}, nil
+}
+
+// decodePhotos uploads in order, the same photo uploaded twice is kept once.
+// Nothing is stored yet, so photo count can be checked first
+func decodePhotos(
+	profileID string,
+	photos []*multipart.FileHeader,
+) ([]*uploadedPhoto, errpkg.ErrorService) {
+	var uploads []*uploadedPhoto
+	decoded := make(map[string]bool)
+	for _, photo := range photos {
+		upload, errs := decodePhoto(profileID, photo)
+		if errs != nil {
+			return nil, errs
+		}
+		if !decoded[upload.key] {
+			decoded[upload.key] = true
+			uploads = append(uploads, upload)
+		}
+	}
+
+	return uploads, nil
+}
+
+// storePhotos every rendition of the uploads, keys in upload order
+func (s *service) storePhotos(
+	ctx context.Context,
+	uploads []*uploadedPhoto,
+) ([]string, errpkg.ErrorService) {
+	keys := make([]string, 0, len(uploads))
+	for _, upload := range uploads {
+		img := upload.img
+		for _, rendition := range photoRenditions {
+			img = imagingpkg.Resize(img, rendition.maxDimension)
+			encoded, err := imagingpkg.EncodeJPEG(img, photoJPEGQuality)
+			if err != nil {
+				return nil, errpkg.DefaultServiceError(
+					errpkg.ErrInternal,
+					err.Error(),
+				)
+			}
+
+			renditionKey := photoRenditionKey(upload.key, rendition.name)
+			if err = s.blobStore.Put(ctx, renditionKey, bytes.NewReader(encoded), int64(len(encoded)), "image/jpeg"); err != nil {
+				return nil, errpkg.DefaultServiceError(
+					errpkg.ErrInternal,
+					err.Error(),
+				)
+			}
+		}
+		keys = append(keys, upload.key)
+	}
+
+	return keys, nil
+}
+
+func (s *service) deletePhotos(
+	ctx context.Context,
+	keys []string,
+) error {
+	for _, key := range keys {
+		deleted := make(map[string]bool, len(photoRenditions))
+		for _, rendition := range photoRenditions {
+			renditionKey := photoRenditionKey(key, rendition.name)
+			if deleted[renditionKey] {
+				continue
+			}
+			if err := s.blobStore.Delete(ctx, renditionKey); err != nil {
+				return err
+			}
+			deleted[renditionKey] = true
+		}
+	}
+
+	return nil
+}
+
+// withPhotoURLs profile photos are stored as object keys, clients get a
+// signed URL of the rendition
+func (s *service) withPhotoURLs(rendition string, profiles ...*domain.Profile) errpkg.ErrorService {
+	expires := s.mediaExpires()
+	for _, profile := range profiles {
+		for i, key := range profile.Photos {
+			link, err := s.buildMediaUrl(photoRenditionKey(key, rendition), expires)
+			if err != nil {
+				return errpkg.DefaultServiceError(
+					errpkg.ErrInternal,
+					err.Error(),
+				)
+			}
+			profile.Photos[i] = link
+		}
+	}
+
+	return nil
+}
+
+// profilePhotoLimits the photos onboarding step is done from the minimum
+// count, a profile can not go below it afterward
+func (s *service) profilePhotoLimits() (int, int) {
+	minPhotos := s.config.GetInt("PROFILE_PHOTOS_MIN")
+	if minPhotos == 0 {
+		minPhotos = 1
+	}
+	maxPhotos := s.config.GetInt("PROFILE_PHOTOS_MAX")
+	if maxPhotos == 0 {
+		maxPhotos = 5
+	}
+
+	return minPhotos, maxPhotos
+}
+
+func (s *service) getPhotosOfUser(
+	ctx context.Context,
+	UserID string,
+) (*repository.Profile, []*repository.ProfilePhoto, errpkg.ErrorService) {
+	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
+	if err != nil {
+		return nil, nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if profile == nil {
+		return nil, nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"profile not found",
+		)
+	}
+
+	photos, err := s.repo.GetProfilePhotos(ctx, profile.ID)
+	if err != nil {
+		return nil, nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return profile, photos, nil
+}
+
+func (s *service) profilePhotosRes(photos []*repository.ProfilePhoto) ([]*domain.ProfilePhoto, errpkg.ErrorService) {
+	expires := s.mediaExpires()
+	res := make([]*domain.ProfilePhoto, 0, len(photos))
+	for _, photo := range photos {
+		item := &domain.ProfilePhoto{
+			ID:        photo.ID,
+			Position:  photo.Position,
+			IsPrimary: photo.IsPrimary,
+			CreatedAt: photo.CreatedAt,
+		}
+
+		links := map[string]*string{
+			photoRenditionFull:  &item.URL,
+			photoRenditionCard:  &item.CardURL,
+			photoRenditionThumb: &item.ThumbURL,
+		}
+		for rendition, link := range links {
+			var err error
+			*link, err = s.buildMediaUrl(photoRenditionKey(photo.BlobKey, rendition), expires)
+			if err != nil {
+				return nil, errpkg.DefaultServiceError(
+					errpkg.ErrInternal,
+					err.Error(),
+				)
+			}
+		}
+
+		res = append(res, item)
+	}
+
+	return res, nil
+}
+
+// profilePhotosChanged photos after the change, the photos onboarding step
+// is completed once the profile reach the minimum count
+func (s *service) profilePhotosChanged(
+	ctx context.Context,
+	UserID string,
+	profileID string,
+) ([]*domain.ProfilePhoto, errpkg.ErrorService) {
+	photos, err := s.repo.GetProfilePhotos(ctx, profileID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	minPhotos, _ := s.profilePhotoLimits()
+	if len(photos) >= minPhotos {
+		errs := s.checkOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_PHOTOS)
+		if errs == nil {
+			errs = s.completeOnboardingStep(ctx, UserID, constant.ONBOARDING_STEP_PHOTOS)
+		}
+		// step which dependency is pending is completed by a later change
+		if errs != nil && errs.GetCode() != errpkg.ErrBadRequest {
+			return nil, errs
+		}
+	}
+
+	return s.profilePhotosRes(photos)
+}
+
+// newPhotoUploads uploads which are not on the profile yet
+func newPhotoUploads(photos []*repository.ProfilePhoto, uploads []*uploadedPhoto) []*uploadedPhoto {
+	existing := make(map[string]bool, len(photos))
+	for _, photo := range photos {
+		existing[photo.BlobKey] = true
+	}
+
+	var res []*uploadedPhoto
+	for _, upload := range uploads {
+		if !existing[upload.key] {
+			res = append(res, upload)
+		}
+	}
+	return res
+}
+
+func photoLimitError(maxPhotos int) errpkg.ErrorService {
+	return errpkg.DefaultServiceError(
+		errpkg.ErrBadRequest,
+		fmt.Sprintf("maximum photo count is %d", maxPhotos),
+	)
+}
+
+func findProfilePhoto(photos []*repository.ProfilePhoto, id string) *repository.ProfilePhoto {
+	for _, photo := range photos {
+		if photo.ID == id {
+			return photo
+		}
+	}
+	return nil
+}
+
+func (s *service) GetProfilePhotos(
+	ctx context.Context,
+	UserID string,
+) ([]*domain.ProfilePhoto, errpkg.ErrorService) {
+	_, photos, errs := s.getPhotosOfUser(ctx, UserID)
+	if errs != nil {
+		return nil, errs
+	}
+
+	return s.profilePhotosRes(photos)
+}
+
+// AddProfilePhotos photos are appended after the current ones
+func (s *service) AddProfilePhotos(
+	ctx context.Context,
+	UserID string,
+	req *domain.UpdatePhotos,
+) ([]*domain.ProfilePhoto, errpkg.ErrorService) {
+	profile, photos, errs := s.getPhotosOfUser(ctx, UserID)
+	if errs != nil {
+		return nil, errs
+	}
+
+	uploads, errs := decodePhotos(profile.ID, req.Photos)
+	if errs != nil {
+		return nil, errs
+	}
+	// photo already on the profile is kept where it is
+	uploads = newPhotoUploads(photos, uploads)
+
+	_, maxPhotos := s.profilePhotoLimits()
+	if len(photos)+len(uploads) > maxPhotos {
+		return nil, photoLimitError(maxPhotos)
+	}
+
+	keys, errs := s.storePhotos(ctx, uploads)
+	if errs != nil {
+		return nil, errs
+	}
+
+	// count is checked again under lock, concurrent upload may have used it
+	added, err := s.repo.AddProfilePhotos(ctx, profile.ID, keys, maxPhotos)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !added {
+		if err = s.deletePhotos(ctx, keys); err != nil {
+			return nil, errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+		return nil, photoLimitError(maxPhotos)
+	}
+
+	return s.profilePhotosChanged(ctx, UserID, profile.ID)
+}
+
+// DeleteProfilePhoto next photo become primary when the primary one is
+// deleted
+func (s *service) DeleteProfilePhoto(
+	ctx context.Context,
+	UserID string,
+	photoID string,
+) ([]*domain.ProfilePhoto, errpkg.ErrorService) {
+	profile, photos, errs := s.getPhotosOfUser(ctx, UserID)
+	if errs != nil {
+		return nil, errs
+	}
+
+	photo := findProfilePhoto(photos, photoID)
+	if photo == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"photo not found",
+		)
+	}
+
+	minPhotos, _ := s.profilePhotoLimits()
+	if len(photos)-1 < minPhotos {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			fmt.Sprintf("minimum photo count is %d", minPhotos),
+		)
+	}
+
+	deleted, err := s.repo.DeleteProfilePhoto(ctx, profile.ID, photo.ID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !deleted {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"photo not found",
+		)
+	}
+
+	if err = s.deletePhotos(ctx, []string{photo.BlobKey}); err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return s.profilePhotosChanged(ctx, UserID, profile.ID)
+}
+
+// ReorderProfilePhotos every photo of the profile must be listed
+func (s *service) ReorderProfilePhotos(
+	ctx context.Context,
+	UserID string,
+	req *domain.ReorderProfilePhotosRequest,
+) ([]*domain.ProfilePhoto, errpkg.ErrorService) {
+	profile, photos, errs := s.getPhotosOfUser(ctx, UserID)
+	if errs != nil {
+		return nil, errs
+	}
+
+	if len(req.PhotoIDs) != len(photos) {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"photo_ids must list every photo of the profile",
+		)
+	}
+	listed := make(map[string]bool, len(req.PhotoIDs))
+	for _, id := range req.PhotoIDs {
+		// duplicate would leave another photo out of the list
+		if listed[id] || findProfilePhoto(photos, id) == nil {
+			return nil, errpkg.DefaultServiceError(
+				errpkg.ErrBadRequest,
+				"photo_ids must list every photo of the profile",
+			)
+		}
+		listed[id] = true
+	}
+
+	reordered, err := s.repo.ReorderProfilePhotos(ctx, profile.ID, req.PhotoIDs)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !reordered {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"photo_ids must list every photo of the profile",
+		)
+	}
+
+	return s.profilePhotosChanged(ctx, UserID, profile.ID)
+}
+
+func (s *service) SetPrimaryProfilePhoto(
+	ctx context.Context,
+	UserID string,
+	photoID string,
+) ([]*domain.ProfilePhoto, errpkg.ErrorService) {
+	profile, photos, errs := s.getPhotosOfUser(ctx, UserID)
+	if errs != nil {
+		return nil, errs
+	}
+	if findProfilePhoto(photos, photoID) == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"photo not found",
+		)
+	}
+
+	updated, err := s.repo.SetPrimaryProfilePhoto(ctx, profile.ID, photoID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !updated {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"photo not found",
+		)
+	}
+
+	return s.profilePhotosChanged(ctx, UserID, profile.ID)
+}
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	blobstorepkg "github.com/ijlik/dating-user/pkg/blobstore"
+	configdata "github.com/ijlik/dating-user/pkg/config/data"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPNG = func() []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2000, 1000)))
+	return buf.Bytes()
+}()
+
+const (
+	insertProfilePhotoQueryMock        = "INSERT INTO profile_photos \\(profile_id, blob_key, position, created_at\\)"
+	ensurePrimaryProfilePhotoQueryMock = "UPDATE profile_photos SET is_primary = true WHERE id ="
+	syncProfilePhotosQueryMock         = "UPDATE profiles SET photos = \\(SELECT STRING_AGG\\(blob_key, ',' ORDER BY is_primary DESC, position\\) FROM profile_photos WHERE profile_id = \\$1\\)"
+	unverifyProfileQueryMock           = "UPDATE profiles SET is_verified = false WHERE id = \\$1 AND is_verified"
+	getProfilePhotosQueryMock          = "SELECT id, profile_id, blob_key, position, is_primary, created_at FROM profile_photos WHERE profile_id = \\$1 ORDER BY position, created_at"
+)
+
+var profilePhotoColumns = []string{"id", "profile_id", "blob_key", "position", "is_primary", "created_at"}
+
+func expectReplaceProfilePhotos(mock sqlmock.Sqlmock, profileID string, keys ...string) {
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM profile_photos WHERE profile_id = \\$1").WithArgs(profileID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectInsertProfilePhotos(mock, profileID, keys...)
+	mock.ExpectCommit()
+}
+
+func expectInsertProfilePhotos(mock sqlmock.Sqlmock, profileID string, keys ...string) {
+	for _, key := range keys {
+		mock.ExpectExec(insertProfilePhotoQueryMock).WithArgs(profileID, key).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec(ensurePrimaryProfilePhotoQueryMock).WithArgs(profileID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(syncProfilePhotosQueryMock).WithArgs(profileID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(unverifyProfileQueryMock).WithArgs(profileID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectLockProfilePhotos profile has count photos when AddProfilePhotos
+// take the lock
+func expectLockProfilePhotos(mock sqlmock.Sqlmock, count int) {
+	mock.ExpectQuery("SELECT id FROM profiles WHERE id = \\$1 FOR UPDATE").WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("profile_id_1"))
+	rows := sqlmock.NewRows([]string{"blob_key"})
+	for i := 1; i <= count; i++ {
+		rows.AddRow(testPhotoKey(i))
+	}
+	mock.ExpectQuery("SELECT blob_key FROM profile_photos WHERE profile_id = \\$1").WithArgs("profile_id_1").
+		WillReturnRows(rows)
+}
+
+// expectPhotosOfUser profile of UserID is "profile_id_1", photo i is
+// "00000000-0000-0000-0000-00000000000i" with key "photos/profile_id_1/i.png"
+func expectPhotosOfUser(mock sqlmock.Sqlmock, UserID string, count int) {
+	expectProfileByUserID(mock, UserID, testProfile(UserID))
+	expectProfilePhotos(mock, count)
+}
+
+func expectProfilePhotos(mock sqlmock.Sqlmock, count int) {
+	rows := sqlmock.NewRows(profilePhotoColumns)
+	for i := 1; i <= count; i++ {
+		rows.AddRow(testPhotoID(i), "profile_id_1", testPhotoKey(i), i-1, i == 1, time.Now())
+	}
+	mock.ExpectQuery(getProfilePhotosQueryMock).WithArgs("profile_id_1").WillReturnRows(rows)
+}
+
+func testPhotoID(i int) string {
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+}
+
+func testPhotoKey(i int) string {
+	return fmt.Sprintf("photos/profile_id_1/%d.png", i)
+}
+
+func newPhotoFileHeader(t *testing.T, filename string, content []byte) *multipart.FileHeader {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("photos", filename)
+	assert.NoError(t, err)
+	_, err = part.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	assert.NoError(t, err)
+	t.Cleanup(func() { form.RemoveAll() })
+
+	return form.File["photos"][0]
+}
+
+func TestUpdatePhotosContentKey(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	oldKey := photoKeyPrefix("profile_id_1") + "/old.png"
+	assert.NoError(t, svc.blobStore.Put(ctx, oldKey, strings.NewReader("png"), 3, "image/png"))
+
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO)
+
+	profile := testProfile(UserID)
+	profile.Photos = validString(oldKey)
+	expectProfileByUserID(mock, UserID, profile)
+
+	// client file name is ignored, the same photo uploaded twice is kept once
+	key := blobstorepkg.ContentKey(photoKeyPrefix("profile_id_1"), testPNG, "_full.jpg")
+	expectReplaceProfilePhotos(mock, "profile_id_1", key)
+
+	completeOnboardingStepQueryMock := "INSERT INTO user_onboarding_steps \\(user_id, step, status, completed_at, created_at\\)"
+	mock.ExpectExec(completeOnboardingStepQueryMock).
+		WithArgs(UserID, constant.ONBOARDING_STEP_PHOTOS, constant.ONBOARDING_STEP_STATUS_DONE).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO, constant.ONBOARDING_STEP_PHOTOS)
+
+	errs := svc.UpdatePhotos(ctx, &domain.UpdatePhotos{Photos: []*multipart.FileHeader{
+		newPhotoFileHeader(t, "../../etc/passwd.png", testPNG),
+		newPhotoFileHeader(t, "copy.png", testPNG),
+	}}, UserID)
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// every rendition is a JPEG fitting its max dimension
+	for _, rendition := range []struct {
+		name   string
+		bounds image.Rectangle
+	}{
+		{photoRenditionFull, image.Rect(0, 0, 1600, 800)},
+		{photoRenditionCard, image.Rect(0, 0, 640, 320)},
+		{photoRenditionThumb, image.Rect(0, 0, 160, 80)},
+	} {
+		body, err := svc.blobStore.Get(ctx, photoRenditionKey(key, rendition.name))
+		assert.NoError(t, err)
+		config, err := jpeg.DecodeConfig(body)
+		body.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, rendition.bounds.Dx(), config.Width)
+		assert.Equal(t, rendition.bounds.Dy(), config.Height)
+	}
+
+	// replaced photo is removed from the store
+	_, err := svc.blobStore.Get(ctx, oldKey)
+	assert.Equal(t, blobstorepkg.ErrNotFound, err)
+}
+
+func TestUpdatePhotosUnsupportedType(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO)
+
+	expectProfileByUserID(mock, UserID, testProfile(UserID))
+
+	// extension claim png, content is not
+	errs := svc.UpdatePhotos(ctx, &domain.UpdatePhotos{Photos: []*multipart.FileHeader{
+		newPhotoFileHeader(t, "photo.png", []byte("<html></html>")),
+	}}, UserID)
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdatePhotosMinimum(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	svc.config.(*configdata.ConfigData).Data["PROFILE_PHOTOS_MIN"] = "2"
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO)
+
+	expectProfileByUserID(mock, UserID, testProfile(UserID))
+
+	// the same photo twice count once, nothing is stored
+	errs := svc.UpdatePhotos(ctx, &domain.UpdatePhotos{Photos: []*multipart.FileHeader{
+		newPhotoFileHeader(t, "photo.png", testPNG),
+		newPhotoFileHeader(t, "copy.png", testPNG),
+	}}, UserID)
+	assert.NotNil(t, errs)
+	assert.Equal(t, "minimum photo count is 2", errs.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	key := blobstorepkg.ContentKey(photoKeyPrefix("profile_id_1"), testPNG, "_full.jpg")
+	_, err := svc.blobStore.Get(ctx, key)
+	assert.Equal(t, blobstorepkg.ErrNotFound, err)
+}
+
+func TestAddProfilePhotos(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	expectPhotosOfUser(mock, UserID, 0)
+	key := blobstorepkg.ContentKey(photoKeyPrefix("profile_id_1"), testPNG, "_full.jpg")
+	mock.ExpectBegin()
+	expectLockProfilePhotos(mock, 0)
+	expectInsertProfilePhotos(mock, "profile_id_1", key)
+	mock.ExpectCommit()
+	expectProfilePhotos(mock, 1)
+
+	// minimum reached, photos step is completed
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO)
+	completeOnboardingStepQueryMock := "INSERT INTO user_onboarding_steps \\(user_id, step, status, completed_at, created_at\\)"
+	mock.ExpectExec(completeOnboardingStepQueryMock).
+		WithArgs(UserID, constant.ONBOARDING_STEP_PHOTOS, constant.ONBOARDING_STEP_STATUS_DONE).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO, constant.ONBOARDING_STEP_PHOTOS)
+
+	photos, errs := svc.AddProfilePhotos(ctx, UserID, &domain.UpdatePhotos{Photos: []*multipart.FileHeader{
+		newPhotoFileHeader(t, "photo.png", testPNG),
+	}})
+	assert.Nil(t, errs)
+	assert.Len(t, photos, 1)
+	assert.True(t, photos[0].IsPrimary)
+	assert.True(t, strings.HasPrefix(photos[0].URL, "http://localhost:8080/media/"+testPhotoKey(1)+"?expires="))
+	assert.True(t, strings.HasPrefix(photos[0].ThumbURL, "http://localhost:8080/media/"+testPhotoKey(1)+"?expires="))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddProfilePhotosPersonalInfoPending(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	expectPhotosOfUser(mock, UserID, 0)
+	key := blobstorepkg.ContentKey(photoKeyPrefix("profile_id_1"), testPNG, "_full.jpg")
+	mock.ExpectBegin()
+	expectLockProfilePhotos(mock, 0)
+	expectInsertProfilePhotos(mock, "profile_id_1", key)
+	mock.ExpectCommit()
+	expectProfilePhotos(mock, 1)
+
+	// photos are kept, the step wait for personal info
+	expectOnboardingSteps(mock, UserID)
+
+	photos, errs := svc.AddProfilePhotos(ctx, UserID, &domain.UpdatePhotos{Photos: []*multipart.FileHeader{
+		newPhotoFileHeader(t, "photo.png", testPNG),
+	}})
+	assert.Nil(t, errs)
+	assert.Len(t, photos, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddProfilePhotosLimit(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	expectPhotosOfUser(mock, UserID, 5)
+
+	_, errs := svc.AddProfilePhotos(ctx, UserID, &domain.UpdatePhotos{Photos: []*multipart.FileHeader{
+		newPhotoFileHeader(t, "photo.png", testPNG),
+	}})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.Equal(t, "maximum photo count is 5", errs.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddProfilePhotosConcurrentLimit(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	// another upload took the last slot while the photo was stored
+	expectPhotosOfUser(mock, UserID, 4)
+	mock.ExpectBegin()
+	expectLockProfilePhotos(mock, 5)
+	mock.ExpectCommit()
+
+	_, errs := svc.AddProfilePhotos(ctx, UserID, &domain.UpdatePhotos{Photos: []*multipart.FileHeader{
+		newPhotoFileHeader(t, "photo.png", testPNG),
+	}})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.Equal(t, "maximum photo count is 5", errs.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// stored renditions are not left behind
+	key := blobstorepkg.ContentKey(photoKeyPrefix("profile_id_1"), testPNG, "_full.jpg")
+	for _, rendition := range []string{photoRenditionFull, photoRenditionCard, photoRenditionThumb} {
+		_, err := svc.blobStore.Get(ctx, photoRenditionKey(key, rendition))
+		assert.Equal(t, blobstorepkg.ErrNotFound, err)
+	}
+}
+
+func TestDeleteProfilePhoto(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	assert.NoError(t, svc.blobStore.Put(ctx, testPhotoKey(1), strings.NewReader("png"), 3, "image/png"))
+
+	expectPhotosOfUser(mock, UserID, 2)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM profiles WHERE id = \\$1 FOR UPDATE").WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("profile_id_1"))
+	mock.ExpectQuery("DELETE FROM profile_photos WHERE profile_id = \\$1 AND id = \\$2 RETURNING id").
+		WithArgs("profile_id_1", testPhotoID(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testPhotoID(1)))
+	mock.ExpectExec(ensurePrimaryProfilePhotoQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(syncProfilePhotosQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(unverifyProfileQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectProfilePhotos(mock, 1)
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO, constant.ONBOARDING_STEP_PHOTOS)
+	completeOnboardingStepQueryMock := "INSERT INTO user_onboarding_steps \\(user_id, step, status, completed_at, created_at\\)"
+	mock.ExpectExec(completeOnboardingStepQueryMock).
+		WithArgs(UserID, constant.ONBOARDING_STEP_PHOTOS, constant.ONBOARDING_STEP_STATUS_DONE).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, errs := svc.DeleteProfilePhoto(ctx, UserID, testPhotoID(1))
+	assert.Nil(t, errs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err := svc.blobStore.Get(ctx, testPhotoKey(1))
+	assert.Equal(t, blobstorepkg.ErrNotFound, err)
+}
+
+func TestDeleteProfilePhotoRejected(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	// last photo can not be deleted
+	expectPhotosOfUser(mock, UserID, 1)
+	_, errs := svc.DeleteProfilePhoto(ctx, UserID, testPhotoID(1))
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.Equal(t, "minimum photo count is 1", errs.Error())
+
+	// photo of another profile
+	expectPhotosOfUser(mock, UserID, 2)
+	_, errs = svc.DeleteProfilePhoto(ctx, UserID, testPhotoID(3))
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrNotFound, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReorderProfilePhotos(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	// every photo must be listed
+	expectPhotosOfUser(mock, UserID, 2)
+	_, errs := svc.ReorderProfilePhotos(ctx, UserID, &domain.ReorderProfilePhotosRequest{
+		PhotoIDs: []string{testPhotoID(2)},
+	})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+
+	// duplicate leave the other photo out
+	expectPhotosOfUser(mock, UserID, 2)
+	_, errs = svc.ReorderProfilePhotos(ctx, UserID, &domain.ReorderProfilePhotosRequest{
+		PhotoIDs: []string{testPhotoID(1), testPhotoID(1)},
+	})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+
+	expectPhotosOfUser(mock, UserID, 2)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM profiles WHERE id = \\$1 FOR UPDATE").WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("profile_id_1"))
+	mock.ExpectQuery("SELECT id FROM profile_photos WHERE profile_id = \\$1").WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testPhotoID(1)).AddRow(testPhotoID(2)))
+	updateProfilePhotoPositionQueryMock := "UPDATE profile_photos SET position = \\$3 WHERE profile_id = \\$1 AND id = \\$2"
+	mock.ExpectExec(updateProfilePhotoPositionQueryMock).WithArgs("profile_id_1", testPhotoID(2), 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(updateProfilePhotoPositionQueryMock).WithArgs("profile_id_1", testPhotoID(1), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(syncProfilePhotosQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectProfilePhotos(mock, 2)
+	expectOnboardingSteps(mock, UserID, constant.ONBOARDING_STEP_PERSONAL_INFO, constant.ONBOARDING_STEP_PHOTOS)
+	completeOnboardingStepQueryMock := "INSERT INTO user_onboarding_steps \\(user_id, step, status, completed_at, created_at\\)"
+	mock.ExpectExec(completeOnboardingStepQueryMock).
+		WithArgs(UserID, constant.ONBOARDING_STEP_PHOTOS, constant.ONBOARDING_STEP_STATUS_DONE).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, errs = svc.ReorderProfilePhotos(ctx, UserID, &domain.ReorderProfilePhotosRequest{
+		PhotoIDs: []string{testPhotoID(2), testPhotoID(1)},
+	})
+	assert.Nil(t, errs)
+
+	// photo deleted by another request before the lock is taken
+	expectPhotosOfUser(mock, UserID, 2)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM profiles WHERE id = \\$1 FOR UPDATE").WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("profile_id_1"))
+	mock.ExpectQuery("SELECT id FROM profile_photos WHERE profile_id = \\$1").WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testPhotoID(2)))
+	mock.ExpectCommit()
+
+	_, errs = svc.ReorderProfilePhotos(ctx, UserID, &domain.ReorderProfilePhotosRequest{
+		PhotoIDs: []string{testPhotoID(2), testPhotoID(1)},
+	})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetPrimaryProfilePhotoNotFound(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	expectPhotosOfUser(mock, UserID, 2)
+	_, errs := svc.SetPrimaryProfilePhoto(ctx, UserID, testPhotoID(3))
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrNotFound, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeletePhotosRenditions(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+	ctx := context.Background()
+
+	key := "photos/profile_id_1/abc_full.jpg"
+	for _, rendition := range []string{photoRenditionFull, photoRenditionCard, photoRenditionThumb} {
+		assert.NoError(t, svc.blobStore.Put(ctx, photoRenditionKey(key, rendition), strings.NewReader("jpg"), 3, "image/jpeg"))
+	}
+
+	assert.NoError(t, svc.deletePhotos(ctx, []string{key, testPhotoKey(1)}))
+	for _, rendition := range []string{photoRenditionFull, photoRenditionCard, photoRenditionThumb} {
+		_, err := svc.blobStore.Get(ctx, photoRenditionKey(key, rendition))
+		assert.Equal(t, blobstorepkg.ErrNotFound, err)
+	}
+}
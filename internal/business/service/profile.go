@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+)
+
+// nullStringPatch field not sent is null and keep the stored value
+func nullStringPatch(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
+
+// PatchProfile update only the given fields, rejected when the profile was
+// modified since the client read it
+func (s *service) PatchProfile(
+	ctx context.Context,
+	UserID string,
+	req *domain.PatchProfileRequest,
+) (*domain.Profile, errpkg.ErrorService) {
+	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if profile == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"profile not found",
+		)
+	}
+
+	if req.IfMatch != "" && req.IfMatch != domain.ProfileETag(profile.GetUpdatedAt(), profile.CreatedAt) {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrPreconditionFailed,
+			"profile was modified, reload and retry",
+		)
+	}
+
+	patch := &repository.PatchProfile{
+		ID:        profile.ID,
+		UpdatedAt: profile.UpdatedAt,
+	}
+	if req.Name != nil {
+		patch.Name = sql.NullString{String: *req.Name, Valid: true}
+	}
+	if req.BirthDate != nil {
+		patch.BirthDate = sql.NullTime{Time: *req.BirthDate, Valid: true}
+	}
+	if req.Gender != nil {
+		patch.Gender = sql.NullString{String: *req.Gender, Valid: true}
+		// custom label only describe gender Other
+		if *req.Gender != constant.GENDER_OTHER.String() {
+			patch.GenderCustom = sql.NullString{Valid: true}
+		}
+	}
+	if req.GenderCustom != nil {
+		gender := profile.Gender.String
+		if req.Gender != nil {
+			gender = *req.Gender
+		}
+		if *req.GenderCustom != "" && gender != constant.GENDER_OTHER.String() {
+			return nil, errpkg.DefaultServiceError(
+				errpkg.ErrBadRequest,
+				fmt.Sprintf("gender custom is only allowed for gender %s", constant.GENDER_OTHER),
+			)
+		}
+		patch.GenderCustom = sql.NullString{String: *req.GenderCustom, Valid: true}
+	}
+	patch.Orientation = nullStringPatch(req.Orientation)
+	if req.ShowMe != nil {
+		patch.ShowMe = sql.NullString{String: strings.Join(*req.ShowMe, ","), Valid: true}
+	}
+	if req.Hobby != nil {
+		patch.Hobby = sql.NullString{
+			String: strings.Join(req.Hobby.Apply(splitNullString(profile.Hobby)), ","),
+			Valid:  true,
+		}
+	}
+	if req.Interest != nil {
+		patch.Interest = sql.NullString{
+			String: strings.Join(req.Interest.Apply(splitNullString(profile.Interest)), ","),
+			Valid:  true,
+		}
+	}
+
+	patch.Bio = nullStringPatch(req.Bio)
+	patch.JobTitle = nullStringPatch(req.JobTitle)
+	patch.Company = nullStringPatch(req.Company)
+	patch.Education = nullStringPatch(req.Education)
+	if req.HeightCm != nil {
+		patch.HeightCm = sql.NullInt32{Int32: int32(*req.HeightCm), Valid: true}
+	}
+	if req.Languages != nil {
+		patch.Languages = sql.NullString{String: strings.Join(*req.Languages, ","), Valid: true}
+	}
+	if req.Prompts != nil {
+		prompts, errs := s.profilePromptAnswers(ctx, *req.Prompts)
+		if errs != nil {
+			return nil, errs
+		}
+		patch.Prompts = sql.NullString{String: prompts, Valid: true}
+	}
+
+	ok, err := s.repo.PatchProfile(ctx, patch)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !ok {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrPreconditionFailed,
+			"profile was modified, reload and retry",
+		)
+	}
+
+	return s.ShowProfile(ctx, UserID)
+}
+
+// profilePromptAnswers json stored on the profile, only active question of
+// the catalogue can be answered
+func (s *service) profilePromptAnswers(
+	ctx context.Context,
+	req []domain.PromptAnswerRequest,
+) (string, errpkg.ErrorService) {
+	catalogue, err := s.repo.GetActiveProfilePrompts(ctx)
+	if err != nil {
+		return "", errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	questions := map[string]string{}
+	for _, item := range catalogue {
+		questions[item.ID] = item.Question
+	}
+
+	answers := []domain.ProfilePromptAnswer{}
+	for _, item := range req {
+		question, ok := questions[item.PromptID]
+		if !ok {
+			return "", errpkg.DefaultServiceError(
+				errpkg.ErrBadRequest,
+				fmt.Sprintf("unknown prompt %s", item.PromptID),
+			)
+		}
+
+		answers = append(answers, domain.ProfilePromptAnswer{
+			PromptID: item.PromptID,
+			Question: question,
+			Answer:   item.Answer,
+		})
+	}
+
+	data, err := json.Marshal(answers)
+	if err != nil {
+		return "", errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return string(data), nil
+}
+
+// GetProfilePrompts question which can be answered on the profile
+func (s *service) GetProfilePrompts(
+	ctx context.Context,
+) ([]*domain.ProfilePrompt, errpkg.ErrorService) {
+	prompts, err := s.repo.GetActiveProfilePrompts(ctx)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return ProfilePromptsRes(prompts), nil
+}
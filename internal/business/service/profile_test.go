@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	"github.com/stretchr/testify/assert"
+)
+
+const getProfileByUserIDQueryMock = "SELECT id, user_id, name, birth_date, gender, photos, hobby, interest, latitude, longitude, geohash, is_premium, is_premium_valid_until, daily_swap_quota, created_at, updated_at, bio, job_title, company, education, height_cm, languages, prompts, gender_custom, orientation, show_me, is_verified FROM profiles WHERE user_id = \\$1 LIMIT 1"
+
+// testProfile profile of UserID with personal info filled in, tests set the
+// fields they depend on
+func testProfile(UserID string) *repository.Profile {
+	return &repository.Profile{
+		ID:             "profile_id_1",
+		UserID:         UserID,
+		Name:           validString("John"),
+		Gender:         validString("Male"),
+		DailySwapQuota: 10,
+		CreatedAt:      time.Now(),
+	}
+}
+
+func validString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: true}
+}
+
+// expectProfileByUserID every column of profile is returned for UserID
+func expectProfileByUserID(mock sqlmock.Sqlmock, UserID string, profile *repository.Profile) {
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "birth_date", "gender", "photos", "hobby", "interest", "latitude", "longitude", "geohash", "is_premium", "is_premium_valid_until", "daily_swap_quota", "created_at", "updated_at", "bio", "job_title", "company", "education", "height_cm", "languages", "prompts", "gender_custom", "orientation", "show_me", "is_verified"})
+	rows.AddRow(profile.ID, profile.UserID, profile.Name, profile.BirthDate, profile.Gender, profile.Photos, profile.Hobby, profile.Interest, profile.Latitude, profile.Longitude, profile.Geohash, profile.IsPremium, profile.IsPremiumValidUntil, profile.DailySwapQuota, profile.CreatedAt, profile.UpdatedAt, profile.Bio, profile.JobTitle, profile.Company, profile.Education, profile.HeightCm, profile.Languages, profile.Prompts, profile.GenderCustom, profile.Orientation, profile.ShowMe, profile.IsVerified)
+	mock.ExpectQuery(getProfileByUserIDQueryMock).WithArgs(UserID).WillReturnRows(rows)
+}
+
+const patchProfileQueryMock = "UPDATE profiles SET name = COALESCE\\(\\$2, name\\), birth_date = COALESCE\\(\\$3, birth_date\\), gender = COALESCE\\(\\$4, gender\\), hobby = COALESCE\\(\\$5, hobby\\), interest = COALESCE\\(\\$6, interest\\), bio = COALESCE\\(\\$7, bio\\), job_title = COALESCE\\(\\$8, job_title\\), company = COALESCE\\(\\$9, company\\), education = COALESCE\\(\\$10, education\\), height_cm = COALESCE\\(\\$11, height_cm\\), languages = COALESCE\\(\\$12, languages\\), prompts = COALESCE\\(\\$13, prompts\\), gender_custom = COALESCE\\(\\$14, gender_custom\\), orientation = COALESCE\\(\\$15, orientation\\), show_me = COALESCE\\(\\$16, show_me\\), updated_at = CURRENT_TIMESTAMP WHERE id = \\$1 AND updated_at IS NOT DISTINCT FROM \\$17"
+
+func TestPatchProfile(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	profileID := "profile_id_1"
+	createdAt := time.Now().Add(-time.Hour)
+	version := time.Now().Add(-time.Minute)
+	updatedAt := time.Now()
+
+	profile := testProfile(UserID)
+	profile.Hobby = validString("hiking,chess")
+	profile.Interest = validString("music")
+	profile.CreatedAt = createdAt
+	profile.UpdatedAt = sql.NullTime{Time: version, Valid: true}
+	expectProfileByUserID(mock, UserID, profile)
+
+	// untouched field is sent as null, removal is case insensitive
+	mock.ExpectExec(patchProfileQueryMock).
+		WithArgs(profileID, "Johnny", nil, nil, "hiking,climbing", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, version).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	patched := *profile
+	patched.Name = validString("Johnny")
+	patched.Hobby = validString("hiking,climbing")
+	patched.UpdatedAt = sql.NullTime{Time: updatedAt, Valid: true}
+	expectProfileByUserID(mock, UserID, &patched)
+	mock.ExpectQuery("SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1").WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(UserID, nil, "test@example.com", constant.USER_STATUS_ACTIVE, createdAt, nil))
+	expectOnboardingSteps(mock, UserID)
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE").WithArgs(profileID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	name := " Johnny "
+	req := &domain.PatchProfileRequest{
+		Name:    &name,
+		Hobby:   &domain.ListPatch{Add: []string{"climbing", "Hiking"}, Remove: []string{"CHESS"}},
+		IfMatch: domain.ProfileETag(version, createdAt),
+	}
+	assert.Nil(t, req.Validate())
+
+	res, errs := svc.PatchProfile(ctx, UserID, req)
+	assert.Nil(t, errs)
+	assert.Equal(t, "Johnny", res.Name)
+	assert.Equal(t, []string{"hiking", "climbing"}, res.Hobby)
+	assert.NotEqual(t, req.IfMatch, res.ETag())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPatchProfileStaleETag(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	createdAt := time.Now().Add(-time.Hour)
+
+	profile := testProfile(UserID)
+	profile.CreatedAt = createdAt
+	profile.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	expectProfileByUserID(mock, UserID, profile)
+
+	name := "Johnny"
+	_, errs := svc.PatchProfile(ctx, UserID, &domain.PatchProfileRequest{
+		Name:    &name,
+		IfMatch: domain.ProfileETag(time.Time{}, createdAt),
+	})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrPreconditionFailed, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPatchProfileConcurrentUpdate(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	version := time.Now()
+
+	profile := testProfile(UserID)
+	profile.CreatedAt = version
+	profile.UpdatedAt = sql.NullTime{Time: version, Valid: true}
+	expectProfileByUserID(mock, UserID, profile)
+
+	// another request updated the profile between read and write
+	// custom label is cleared once gender is no longer Other
+	mock.ExpectExec(patchProfileQueryMock).
+		WithArgs("profile_id_1", nil, nil, "Female", nil, nil, nil, nil, nil, nil, nil, nil, nil, "", nil, nil, version).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	gender := "female"
+	req := &domain.PatchProfileRequest{Gender: &gender}
+	assert.Nil(t, req.Validate())
+
+	_, errs := svc.PatchProfile(ctx, UserID, req)
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrPreconditionFailed, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+const getActiveProfilePromptsQueryMock = "SELECT id, question, is_active, position, created_at FROM profile_prompts WHERE is_active = true ORDER BY position, id"
+
+func TestPatchProfileAttributes(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	profileID := "profile_id_1"
+	version := time.Now()
+	prompts := `[{"prompt_id":"typical-sunday","question":"A typical Sunday","answer":"Hiking then brunch"}]`
+
+	profile := testProfile(UserID)
+	profile.CreatedAt = version
+	profile.UpdatedAt = sql.NullTime{Time: version, Valid: true}
+	expectProfileByUserID(mock, UserID, profile)
+	mock.ExpectQuery(getActiveProfilePromptsQueryMock).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "question", "is_active", "position", "created_at"}).
+			AddRow("typical-sunday", "A typical Sunday", true, 1, version))
+	mock.ExpectExec(patchProfileQueryMock).
+		WithArgs(profileID, nil, nil, nil, nil, nil, "Coffee lover", "", nil, nil, 180, "en,id", prompts, nil, nil, nil, version).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	patched := *profile
+	patched.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	patched.Bio = validString("Coffee lover")
+	patched.JobTitle = validString("")
+	patched.HeightCm = sql.NullInt32{Int32: 180, Valid: true}
+	patched.Languages = validString("en,id")
+	patched.Prompts = validString(prompts)
+	expectProfileByUserID(mock, UserID, &patched)
+	mock.ExpectQuery("SELECT id, phone, email, status, created_at, updated_at FROM users WHERE id = \\$1 LIMIT 1").WithArgs(UserID).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(UserID, nil, "test@example.com", constant.USER_STATUS_ACTIVE, version, nil))
+	expectOnboardingSteps(mock, UserID)
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM swipes WHERE swiper_id = \\$1 AND DATE\\(created_at\\) = CURRENT_DATE").WithArgs(profileID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	bio := " Coffee lover "
+	jobTitle := ""
+	height := 180
+	languages := []string{"EN", " id"}
+	req := &domain.PatchProfileRequest{
+		Bio:       &bio,
+		JobTitle:  &jobTitle,
+		HeightCm:  &height,
+		Languages: &languages,
+		Prompts:   &[]domain.PromptAnswerRequest{{PromptID: "typical-sunday", Answer: " Hiking then brunch "}},
+	}
+	assert.Nil(t, req.Validate())
+
+	res, errs := svc.PatchProfile(ctx, UserID, req)
+	assert.Nil(t, errs)
+	assert.Equal(t, "Coffee lover", res.Bio)
+	assert.Equal(t, 180, res.HeightCm)
+	assert.Equal(t, []string{"en", "id"}, res.Languages)
+	assert.Equal(t, []domain.ProfilePromptAnswer{{PromptID: "typical-sunday", Question: "A typical Sunday", Answer: "Hiking then brunch"}}, res.Prompts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPatchProfileUnknownPrompt(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	version := time.Now()
+
+	profile := testProfile(UserID)
+	profile.CreatedAt = version
+	profile.UpdatedAt = sql.NullTime{Time: version, Valid: true}
+	expectProfileByUserID(mock, UserID, profile)
+	mock.ExpectQuery(getActiveProfilePromptsQueryMock).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "question", "is_active", "position", "created_at"}).
+			AddRow("typical-sunday", "A typical Sunday", true, 1, version))
+
+	_, errs := svc.PatchProfile(ctx, UserID, &domain.PatchProfileRequest{
+		Prompts: &[]domain.PromptAnswerRequest{{PromptID: "retired-prompt", Answer: "answer"}},
+	})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPatchProfileRequestValidate(t *testing.T) {
+	height := 90
+	assert.NotNil(t, (&domain.PatchProfileRequest{HeightCm: &height}).Validate())
+
+	languages := []string{"english"}
+	assert.NotNil(t, (&domain.PatchProfileRequest{Languages: &languages}).Validate())
+
+	bio := strings.Repeat("a", domain.MaxBioLength+1)
+	assert.NotNil(t, (&domain.PatchProfileRequest{Bio: &bio}).Validate())
+
+	prompts := []domain.PromptAnswerRequest{
+		{PromptID: "a", Answer: "a"},
+		{PromptID: "b", Answer: "b"},
+		{PromptID: "c", Answer: "c"},
+		{PromptID: "d", Answer: "d"},
+	}
+	assert.NotNil(t, (&domain.PatchProfileRequest{Prompts: &prompts}).Validate())
+
+	duplicate := []domain.PromptAnswerRequest{{PromptID: "a", Answer: "a"}, {PromptID: "a", Answer: "b"}}
+	assert.NotNil(t, (&domain.PatchProfileRequest{Prompts: &duplicate}).Validate())
+
+	assert.NotNil(t, (&domain.PatchProfileRequest{}).Validate())
+}
+
+func TestPatchProfileGenderCustom(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+	version := time.Now()
+
+	profile := testProfile(UserID)
+	profile.CreatedAt = version
+	profile.UpdatedAt = sql.NullTime{Time: version, Valid: true}
+	expectProfileByUserID(mock, UserID, profile)
+
+	// stored gender is not Other
+	custom := "Genderfluid"
+	req := &domain.PatchProfileRequest{GenderCustom: &custom}
+	assert.Nil(t, req.Validate())
+
+	_, errs := svc.PatchProfile(ctx, UserID, req)
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdatePersonalInfoGenderValidate(t *testing.T) {
+	req := &domain.UpdatePersonalInfo{
+		Name:         "Alex",
+		BirthDates:   "1995-01-02T00:00:00Z",
+		Gender:       "other",
+		GenderCustom: " Genderfluid ",
+		Orientation:  "queer",
+		ShowMe:       []string{"non-binary", "FEMALE"},
+	}
+	assert.Nil(t, req.Validate())
+	assert.Equal(t, constant.GENDER_OTHER.String(), req.Gender)
+	assert.Equal(t, "Genderfluid", req.GenderCustom)
+	assert.Equal(t, constant.ORIENTATION_QUEER.String(), req.Orientation)
+	assert.Equal(t, []string{"Non-binary", "Female"}, req.ShowMe)
+
+	// custom label only describe gender Other
+	req = &domain.UpdatePersonalInfo{Name: "Alex", BirthDates: "1995-01-02T00:00:00Z", Gender: "Male", GenderCustom: "Genderfluid"}
+	assert.NotNil(t, req.Validate())
+
+	req = &domain.UpdatePersonalInfo{Name: "Alex", BirthDates: "1995-01-02T00:00:00Z", Gender: "Male", ShowMe: []string{"Robot"}}
+	assert.NotNil(t, req.Validate())
+
+	req = &domain.UpdatePersonalInfo{Name: "Alex", BirthDates: "1995-01-02T00:00:00Z", Gender: "Male", Orientation: "unknown"}
+	assert.NotNil(t, req.Validate())
+}
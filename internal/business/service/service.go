@@ -7,18 +7,30 @@ import (
 	"github.com/ijlik/dating-user/internal/adapter/repository"
 	"github.com/ijlik/dating-user/internal/business/port"
 
+	blobstorepkg "github.com/ijlik/dating-user/pkg/blobstore"
+	facematchpkg "github.com/ijlik/dating-user/pkg/facematch"
+	hashpkg "github.com/ijlik/dating-user/pkg/hash"
+	jwtpkg "github.com/ijlik/dating-user/pkg/jwt"
 	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
 	commonmath "github.com/ijlik/dating-user/pkg/math"
+	otpsenderpkg "github.com/ijlik/dating-user/pkg/otpsender"
 	timemachine "github.com/ijlik/dating-user/pkg/timemachine"
 )
 
 type service struct {
-	repo   repository.UserRepository
-	config configdata.Config
-	math   commonmath.Math
-	mailer mailerpkg.Mail
-	time   timemachine.TimeMachine
-	redis  redis.RedisDomain
+	repo      repository.UserRepository
+	config    configdata.Config
+	math      commonmath.Math
+	mailer    mailerpkg.Mail
+	time      timemachine.TimeMachine
+	redis     redis.RedisDomain
+	otpSender otpsenderpkg.Registry
+	keySet    jwtpkg.KeySet
+	hasher    hashpkg.Hasher
+	blobStore blobstorepkg.BlobStore
+	// faceMatcher nil when no provider is configured, every verification is
+	// then reviewed manually
+	faceMatcher facematchpkg.FaceMatcher
 }
 
 func NewUserService(
@@ -26,9 +38,17 @@ func NewUserService(
 	config configdata.Config,
 	mail mailerpkg.Mail,
 	redis redis.RedisDomain,
+	otpSender otpsenderpkg.Registry,
+	keySet jwtpkg.KeySet,
+	blobStore blobstorepkg.BlobStore,
+	faceMatcher facematchpkg.FaceMatcher,
 ) port.UserDomainService {
 	dateTime := timemachine.NewTimeMachine()
 	math := commonmath.NewMath()
+	// otp code and magic link are stored as keyed hash, pepper is kept on vault
+	hasher := hashpkg.NewHmacHasher(func() string {
+		return config.GetString("OTP_PEPPER")
+	})
 	return &service{
 		repo,
 		config,
@@ -36,5 +56,10 @@ func NewUserService(
 		mail,
 		dateTime,
 		redis,
+		otpSender,
+		keySet,
+		hasher,
+		blobStore,
+		faceMatcher,
 	}
 }
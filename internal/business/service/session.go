@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ijlik/dating-user/internal/business/domain"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	sessionpkg "github.com/ijlik/dating-user/pkg/session"
+)
+
+func (s *service) getUserSessions(ctx context.Context, UserID string) ([]*sessionpkg.Session, error) {
+	ids, err := s.redis.SMembers(ctx, sessionpkg.UserKey(UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*sessionpkg.Session
+	for _, id := range ids {
+		session, err := s.getSession(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		// session already expired, cleanup the registry
+		if session == nil {
+			if err = s.redis.SRem(ctx, sessionpkg.UserKey(UserID), id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (s *service) ListSessions(
+	ctx context.Context,
+	UserID, currentSessionID string,
+) ([]*domain.Session, errpkg.ErrorService) {
+	sessions, err := s.getUserSessions(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	res := make([]*domain.Session, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, &domain.Session{
+			ID:         session.ID,
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+
+	return res, nil
+}
+
+func (s *service) RevokeSession(
+	ctx context.Context,
+	UserID, sessionID string,
+) errpkg.ErrorService {
+	session, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	if session == nil || session.UserID != UserID {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"session not found",
+		)
+	}
+
+	err = s.revokeSession(ctx, UserID, sessionID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions log out every device except the current one
+func (s *service) RevokeOtherSessions(
+	ctx context.Context,
+	UserID, currentSessionID string,
+) errpkg.ErrorService {
+	sessions, err := s.getUserSessions(ctx, UserID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	for _, session := range sessions {
+		if session.ID == currentSessionID {
+			continue
+		}
+
+		err = s.revokeSession(ctx, UserID, session.ID)
+		if err != nil {
+			return errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+	}
+
+	return nil
+}
+
+// revokeAllSessions log out every device of the user
+func (s *service) revokeAllSessions(ctx context.Context, UserID string) error {
+	sessions, err := s.getUserSessions(ctx, UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if err = s.revokeSession(ctx, UserID, session.ID); err != nil {
+			return err
+		}
+	}
+
+	return s.redis.Del(ctx, sessionpkg.UserKey(UserID))
+}
+
+func (s *service) Logout(
+	ctx context.Context,
+	UserID, sessionID string,
+) errpkg.ErrorService {
+	err := s.revokeSession(ctx, UserID, sessionID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			"",
+		)
+	}
+
+	return nil
+}
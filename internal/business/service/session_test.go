@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ijlik/dating-user/internal/business/domain"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	sessionpkg "github.com/ijlik/dating-user/pkg/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListSessions(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	_, phone := issueTestTokens(t, svc, UserID, "Pixel 7")
+	_, laptop := issueTestTokens(t, svc, UserID, "MacBook")
+	issueTestTokens(t, svc, "user_id_2", "iPhone")
+
+	sessions, errs := svc.ListSessions(ctx, UserID, laptop.ID)
+	assert.Nil(t, errs)
+	assert.Len(t, sessions, 2)
+
+	devices := map[string]bool{}
+	for _, session := range sessions {
+		devices[session.DeviceName] = session.Current
+	}
+	assert.Equal(t, map[string]bool{"Pixel 7": false, "MacBook": true}, devices)
+	assert.NotEqual(t, phone.ID, laptop.ID)
+}
+
+func TestRevokeSession(t *testing.T) {
+	svc, _, rdb := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	res, phone := issueTestTokens(t, svc, UserID, "Pixel 7")
+	_, other := issueTestTokens(t, svc, "user_id_2", "iPhone")
+
+	// Cannot revoke session owned by other user
+	errs := svc.RevokeSession(ctx, UserID, other.ID)
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrNotFound, errs.GetCode())
+
+	assert.Nil(t, svc.RevokeSession(ctx, UserID, phone.ID))
+	_, err := rdb.Get(ctx, sessionpkg.Key(phone.ID))
+	assert.Error(t, err)
+
+	// Refresh token of the revoked session is no longer usable
+	_, errs = svc.RefreshToken(ctx, &domain.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrInvalidToken, errs.GetCode())
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	issueTestTokens(t, svc, UserID, "Pixel 7")
+	issueTestTokens(t, svc, UserID, "iPad")
+	_, current := issueTestTokens(t, svc, UserID, "MacBook")
+
+	assert.Nil(t, svc.RevokeOtherSessions(ctx, UserID, current.ID))
+
+	sessions, errs := svc.ListSessions(ctx, UserID, current.ID)
+	assert.Nil(t, errs)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, current.ID, sessions[0].ID)
+	assert.True(t, sessions[0].Current)
+}
+
+func TestLogoutRevokeCurrentSession(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	_, phone := issueTestTokens(t, svc, UserID, "Pixel 7")
+	res, laptop := issueTestTokens(t, svc, UserID, "MacBook")
+
+	assert.Nil(t, svc.Logout(ctx, UserID, laptop.ID))
+
+	_, errs := svc.RefreshToken(ctx, &domain.RefreshTokenRequest{RefreshToken: res.RefreshToken})
+	assert.NotNil(t, errs)
+
+	// Other device stay logged in
+	sessions, _ := svc.ListSessions(ctx, UserID, "")
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, phone.ID, sessions[0].ID)
+}
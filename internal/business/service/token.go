@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ijlik/dating-user/internal/adapter/redis"
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	jwtpkg "github.com/ijlik/dating-user/pkg/jwt"
+	sessionpkg "github.com/ijlik/dating-user/pkg/session"
+)
+
+const (
+	// refresh_token:<hash> hold the token data until it expired
+	refreshTokenKey = "refresh_token:%s"
+	// refresh_token_used:<hash> mark token already rotated
+	refreshTokenUsedKey = "refresh_token_used:%s"
+)
+
+var errRefreshTokenReused = errors.New("refresh token reuse detected")
+
+// refreshTokenData every refresh token of one session belong to the same
+// token family, revoking the session revoke the family
+type refreshTokenData struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"`
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *service) accessTokenTTL() time.Duration {
+	ttl := s.config.GetInt("ACCESS_TOKEN_TTL_IN_MINUTE")
+	if ttl == 0 {
+		ttl = 15
+	}
+
+	return time.Duration(ttl) * time.Minute
+}
+
+func (s *service) refreshTokenTTL() time.Duration {
+	ttl := s.config.GetInt("REFRESH_TOKEN_TTL_IN_HOUR")
+	if ttl == 0 {
+		ttl = 720
+	}
+
+	return time.Duration(ttl) * time.Hour
+}
+
+func (s *service) isPremium(profile *repository.Profile) bool {
+	if !profile.IsPremium {
+		return false
+	}
+
+	return !profile.IsPremiumValidUntil.Valid || profile.IsPremiumValidUntil.Time.After(s.time.Now())
+}
+
+// userScopes admin scope granted to email listed on ADMIN_EMAILS
+func (s *service) userScopes(user *repository.User, premium bool) []string {
+	scopes := []string{constant.SCOPE_USER.String()}
+	if premium {
+		scopes = append(scopes, constant.SCOPE_PREMIUM.String())
+	}
+
+	if user.Email.Valid {
+		for _, email := range s.config.GetArray("ADMIN_EMAILS") {
+			if strings.EqualFold(strings.TrimSpace(email), user.Email.String) {
+				scopes = append(scopes, constant.SCOPE_ADMIN.String())
+				break
+			}
+		}
+	}
+
+	return scopes
+}
+
+func (s *service) newSession(userID string, device domain.DeviceInfo) (*sessionpkg.Session, error) {
+	id := s.math.AlphaNumericRandom(16)
+	if !id.Valid {
+		return nil, errors.New("failed to generate session id")
+	}
+
+	now := s.time.Now()
+	return &sessionpkg.Session{
+		ID:         id.String(),
+		UserID:     userID,
+		DeviceName: device.DeviceName,
+		UserAgent:  device.UserAgent,
+		IP:         device.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}, nil
+}
+
+// issueTokens create short lived access token and long lived refresh token
+// for the session, session is stored (or refreshed) in registry
+func (s *service) issueTokens(
+	ctx context.Context,
+	user *repository.User,
+	profile *repository.Profile,
+	session *sessionpkg.Session,
+) (*domain.AuthResponse, error) {
+	accessTTL := s.accessTokenTTL()
+	premium := s.isPremium(profile)
+	accessToken, err := s.keySet.CreateToken(accessTTL, jwtpkg.Claims{
+		UserID:    user.ID,
+		ProfileID: profile.ID,
+		Email:     user.Email.String,
+		Phone:     user.VerifiedPhone(),
+		SessionID: session.ID,
+		Scopes:    s.userScopes(user, premium),
+		Premium:   premium,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refresh := s.math.AlphaNumericRandom(32)
+	if !refresh.Valid {
+		return nil, errors.New("failed to generate refresh token")
+	}
+	refreshToken := refresh.String()
+
+	data, err := json.Marshal(refreshTokenData{
+		UserID:    user.ID,
+		SessionID: session.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTTL := s.refreshTokenTTL()
+	err = s.redis.SetWithExpiration(ctx, fmt.Sprintf(refreshTokenKey, hashRefreshToken(refreshToken)), string(data), refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set session in redis
+	value, err := session.Encode()
+	if err != nil {
+		return nil, err
+	}
+	err = s.redis.SetWithExpiration(ctx, sessionpkg.Key(session.ID), value, refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	userSessionsKey := sessionpkg.UserKey(user.ID)
+	if err = s.redis.SAdd(ctx, userSessionsKey, session.ID); err != nil {
+		return nil, err
+	}
+	if err = s.redis.Expire(ctx, userSessionsKey, refreshTTL); err != nil {
+		return nil, err
+	}
+
+	return &domain.AuthResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(accessTTL.Seconds()),
+	}, nil
+}
+
+// rotateRefreshToken mark refresh token as used and return its session
+// replaying used token will revoke the whole session
+func (s *service) rotateRefreshToken(
+	ctx context.Context,
+	refreshToken string,
+) (*sessionpkg.Session, error) {
+	hash := hashRefreshToken(refreshToken)
+	value, err := s.redis.Get(ctx, fmt.Sprintf(refreshTokenKey, hash))
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var data refreshTokenData
+	if err = json.Unmarshal([]byte(value), &data); err != nil {
+		return nil, err
+	}
+
+	session, err := s.getSession(ctx, data.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, nil
+	}
+
+	firstUse, err := s.redis.SetNX(ctx, fmt.Sprintf(refreshTokenUsedKey, hash), data.SessionID, s.refreshTokenTTL())
+	if err != nil {
+		return nil, err
+	}
+
+	if !firstUse {
+		if err = s.revokeSession(ctx, data.UserID, data.SessionID); err != nil {
+			return nil, err
+		}
+		return nil, errRefreshTokenReused
+	}
+
+	return session, nil
+}
+
+func (s *service) getSession(ctx context.Context, sessionID string) (*sessionpkg.Session, error) {
+	value, err := s.redis.Get(ctx, sessionpkg.Key(sessionID))
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return sessionpkg.Decode(value)
+}
+
+func (s *service) revokeSession(ctx context.Context, UserID, sessionID string) error {
+	if err := s.redis.Del(ctx, sessionpkg.Key(sessionID)); err != nil {
+		return err
+	}
+
+	return s.redis.SRem(ctx, sessionpkg.UserKey(UserID), sessionID)
+}
+
+func (s *service) RefreshToken(
+	ctx context.Context,
+	req *domain.RefreshTokenRequest,
+) (*domain.AuthResponse, errpkg.ErrorService) {
+	session, err := s.rotateRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		if err == errRefreshTokenReused {
+			return nil, errpkg.DefaultServiceError(
+				errpkg.ErrReloginNeeded,
+				"refresh token already used, please login again",
+			)
+		}
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if session == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"invalid refresh token",
+		)
+	}
+
+	user, err := s.repo.GetUserById(ctx, session.UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if user == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInvalidToken,
+			"invalid refresh token",
+		)
+	}
+
+	profile, err := s.repo.GetProfileByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	session.LastSeenAt = s.time.Now()
+	res, err := s.issueTokens(ctx, user, profile, session)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			"internal server error create token",
+		)
+	}
+
+	return res, nil
+}
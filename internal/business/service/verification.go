@@ -0,0 +1,456 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/ijlik/dating-user/internal/adapter/redis"
+	"github.com/ijlik/dating-user/internal/adapter/repository"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	blobstorepkg "github.com/ijlik/dating-user/pkg/blobstore"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	facematchpkg "github.com/ijlik/dating-user/pkg/facematch"
+	imagingpkg "github.com/ijlik/dating-user/pkg/imaging"
+)
+
+// verificationQueueSize verifications returned by one read of the review
+// queue
+const verificationQueueSize = 50
+
+// verificationPoseKey pose requested to the user, the selfie must be sent
+// before it expires and a pose is used once
+func verificationPoseKey(UserID string) string {
+	return fmt.Sprintf("verification_pose:%s", UserID)
+}
+
+// verificationKeyPrefix every selfie of the profile is stored under this
+// prefix
+func verificationKeyPrefix(profileID string) string {
+	return "verifications/" + profileID
+}
+
+func (s *service) verificationPoseExpiry() time.Duration {
+	minutes := s.config.GetInt("VERIFICATION_POSE_EXPIRY_IN_MINUTE")
+	if minutes == 0 {
+		minutes = 10
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+// verificationThresholds similarity in percent from which the face matcher
+// approve the verification by itself and below which it reject it, anything
+// between wait for a manual review
+func (s *service) verificationThresholds() (float64, float64) {
+	approve := s.config.GetInt("VERIFICATION_APPROVE_SIMILARITY")
+	if approve == 0 {
+		approve = 90
+	}
+	reject := s.config.GetInt("VERIFICATION_REJECT_SIMILARITY")
+	if reject == 0 {
+		reject = 40
+	}
+
+	return float64(approve) / 100, float64(reject) / 100
+}
+
+// verificationStatus selfie which does not show the requested pose is never
+// approved without a review, missing result (face matcher not available) is
+// reviewed manually
+func (s *service) verificationStatus(result *facematchpkg.Result) constant.VerificationStatus {
+	if result == nil {
+		return constant.VERIFICATION_STATUS_PENDING
+	}
+
+	approve, reject := s.verificationThresholds()
+	if result.Similarity < reject {
+		return constant.VERIFICATION_STATUS_REJECTED
+	}
+	if result.Similarity >= approve && result.PoseMatched {
+		return constant.VERIFICATION_STATUS_APPROVED
+	}
+
+	return constant.VERIFICATION_STATUS_PENDING
+}
+
+// getVerifiableProfile profile can start a verification when it is not
+// verified yet and no verification is waiting for review
+func (s *service) getVerifiableProfile(
+	ctx context.Context,
+	UserID string,
+) (*repository.Profile, errpkg.ErrorService) {
+	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if profile == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"profile not found",
+		)
+	}
+	if profile.IsVerified {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"profile is already verified",
+		)
+	}
+
+	latest, err := s.repo.GetLatestProfileVerification(ctx, profile.ID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if latest != nil && latest.Status == constant.VERIFICATION_STATUS_PENDING {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"verification is waiting for review",
+		)
+	}
+
+	return profile, nil
+}
+
+// StartVerification pick the pose the user has to do on the selfie
+func (s *service) StartVerification(
+	ctx context.Context,
+	UserID string,
+) (*domain.VerificationChallenge, errpkg.ErrorService) {
+	if _, errs := s.getVerifiableProfile(ctx, UserID); errs != nil {
+		return nil, errs
+	}
+
+	poses := constant.VerificationPoses
+	pose := poses[int(s.math.NumericCode(4).Int())%len(poses)]
+
+	expiry := s.verificationPoseExpiry()
+	if err := s.redis.SetWithExpiration(ctx, verificationPoseKey(UserID), pose, expiry); err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return &domain.VerificationChallenge{
+		Pose:      pose,
+		ExpiresAt: s.time.Now().Add(expiry),
+	}, nil
+}
+
+// storeSelfie selfie is re-encoded like the photos, nothing but pixels is
+// kept
+func (s *service) storeSelfie(
+	ctx context.Context,
+	profileID string,
+	req *domain.SubmitVerificationRequest,
+) (string, []byte, errpkg.ErrorService) {
+	src, err := req.Selfie.Open()
+	if err != nil {
+		return "", nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	defer src.Close()
+
+	content, err := io.ReadAll(src)
+	if err != nil {
+		return "", nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	img, err := imagingpkg.Decode(content)
+	if err == imagingpkg.ErrUnsupportedFormat {
+		return "", nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"invalid file type: only JPEG and PNG images are allowed",
+		)
+	}
+	if err != nil {
+		return "", nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"invalid image: the file could not be decoded",
+		)
+	}
+
+	encoded, err := imagingpkg.EncodeJPEG(imagingpkg.Resize(img, photoRenditions[0].maxDimension), photoJPEGQuality)
+	if err != nil {
+		return "", nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	key := blobstorepkg.ContentKey(verificationKeyPrefix(profileID), content, ".jpg")
+	if err = s.blobStore.Put(ctx, key, bytes.NewReader(encoded), int64(len(encoded)), "image/jpeg"); err != nil {
+		return "", nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	return key, encoded, nil
+}
+
+// matchSelfie nil result when the face matcher is not configured or failed,
+// the verification is then reviewed manually
+func (s *service) matchSelfie(
+	ctx context.Context,
+	selfie []byte,
+	pose string,
+	photos []*repository.ProfilePhoto,
+) *facematchpkg.Result {
+	if s.faceMatcher == nil {
+		return nil
+	}
+
+	req := facematchpkg.Request{
+		Selfie: selfie,
+		Pose:   pose,
+	}
+	for _, photo := range photos {
+		src, err := s.blobStore.Get(ctx, photoRenditionKey(photo.BlobKey, photoRenditionCard))
+		if err != nil {
+			log.Println("failed to read photo for face match: ", err)
+			return nil
+		}
+		content, err := io.ReadAll(src)
+		src.Close()
+		if err != nil {
+			log.Println("failed to read photo for face match: ", err)
+			return nil
+		}
+		req.Photos = append(req.Photos, content)
+	}
+
+	result, err := s.faceMatcher.Match(ctx, req)
+	if err != nil {
+		log.Println("failed to match selfie: ", err)
+		return nil
+	}
+
+	return result
+}
+
+// SubmitVerification selfie is compared to the profile photos, unsure match
+// land on the review queue
+func (s *service) SubmitVerification(
+	ctx context.Context,
+	UserID string,
+	req *domain.SubmitVerificationRequest,
+) (*domain.Verification, errpkg.ErrorService) {
+	profile, errs := s.getVerifiableProfile(ctx, UserID)
+	if errs != nil {
+		return nil, errs
+	}
+
+	pose, err := s.redis.Get(ctx, verificationPoseKey(UserID))
+	if err == redis.Nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"no verification in progress or the pose is expired",
+		)
+	}
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	photos, err := s.repo.GetProfilePhotos(ctx, profile.ID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if len(photos) == 0 {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrBadRequest,
+			"profile has no photo to compare the selfie with",
+		)
+	}
+
+	key, selfie, errs := s.storeSelfie(ctx, profile.ID, req)
+	if errs != nil {
+		return nil, errs
+	}
+
+	// pose is used once, another selfie need another pose
+	if err = s.redis.Del(ctx, verificationPoseKey(UserID)); err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	result := s.matchSelfie(ctx, selfie, pose, photos)
+	verification := &repository.CreateProfileVerification{
+		ProfileID: profile.ID,
+		Pose:      pose,
+		SelfieKey: key,
+		Status:    s.verificationStatus(result),
+	}
+	if result != nil {
+		verification.Similarity = sql.NullFloat64{Float64: result.Similarity, Valid: true}
+		verification.PoseMatched = sql.NullBool{Bool: result.PoseMatched, Valid: true}
+	}
+
+	id, err := s.repo.CreateProfileVerification(ctx, verification)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	now := s.time.Now()
+	res := &domain.Verification{
+		ID:        id,
+		Pose:      pose,
+		Status:    verification.Status.String(),
+		CreatedAt: now,
+	}
+	if verification.Status != constant.VERIFICATION_STATUS_PENDING {
+		res.ReviewedAt = &now
+	}
+
+	return res, nil
+}
+
+// GetVerification latest verification of the user
+func (s *service) GetVerification(
+	ctx context.Context,
+	UserID string,
+) (*domain.Verification, errpkg.ErrorService) {
+	profile, err := s.repo.GetProfileByUserID(ctx, UserID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if profile == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"profile not found",
+		)
+	}
+
+	verification, err := s.repo.GetLatestProfileVerification(ctx, profile.ID)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if verification == nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"verification not found",
+		)
+	}
+
+	return VerificationRes(verification), nil
+}
+
+// GetVerificationQueue pending verifications, oldest first, with signed URLs
+// of the selfie and the profile photos
+func (s *service) GetVerificationQueue(
+	ctx context.Context,
+) ([]*domain.VerificationReview, errpkg.ErrorService) {
+	verifications, err := s.repo.GetPendingProfileVerifications(ctx, verificationQueueSize)
+	if err != nil {
+		return nil, errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+
+	expires := s.mediaExpires()
+	result := []*domain.VerificationReview{}
+	for _, verification := range verifications {
+		photos, err := s.repo.GetProfilePhotos(ctx, verification.ProfileID)
+		if err != nil {
+			return nil, errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+
+		item := &domain.VerificationReview{
+			ID:          verification.ID,
+			ProfileID:   verification.ProfileID,
+			Pose:        verification.Pose,
+			Photos:      []string{},
+			Similarity:  nullFloatRes(verification.Similarity),
+			PoseMatched: nullBoolRes(verification.PoseMatched),
+			CreatedAt:   verification.CreatedAt,
+		}
+		item.SelfieURL, err = s.buildMediaUrl(verification.SelfieKey, expires)
+		if err != nil {
+			return nil, errpkg.DefaultServiceError(
+				errpkg.ErrInternal,
+				err.Error(),
+			)
+		}
+		for _, photo := range photos {
+			link, err := s.buildMediaUrl(photoRenditionKey(photo.BlobKey, photoRenditionCard), expires)
+			if err != nil {
+				return nil, errpkg.DefaultServiceError(
+					errpkg.ErrInternal,
+					err.Error(),
+				)
+			}
+			item.Photos = append(item.Photos, link)
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// ReviewVerification manual decision on a pending verification, approving it
+// give the profile its verified badge
+func (s *service) ReviewVerification(
+	ctx context.Context,
+	reviewerID string,
+	id string,
+	approved bool,
+) errpkg.ErrorService {
+	status := constant.VERIFICATION_STATUS_REJECTED
+	if approved {
+		status = constant.VERIFICATION_STATUS_APPROVED
+	}
+
+	reviewed, err := s.repo.ReviewProfileVerification(ctx, id, status, reviewerID)
+	if err != nil {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrInternal,
+			err.Error(),
+		)
+	}
+	if !reviewed {
+		return errpkg.DefaultServiceError(
+			errpkg.ErrNotFound,
+			"no pending verification found",
+		)
+	}
+
+	return nil
+}
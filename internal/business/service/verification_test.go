@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	"github.com/ijlik/dating-user/pkg/constant"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	facematchpkg "github.com/ijlik/dating-user/pkg/facematch"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	getLatestProfileVerificationQueryMock = "SELECT id, profile_id, pose, selfie_key, similarity, pose_matched, status, reviewer_id, created_at, reviewed_at FROM profile_verifications WHERE profile_id = \\$1 ORDER BY created_at DESC LIMIT 1"
+	getProfileVerificationsQueryMock      = "SELECT id, profile_id, pose, selfie_key, similarity, pose_matched, status, reviewer_id, created_at, reviewed_at FROM profile_verifications WHERE profile_id = \\$1 ORDER BY created_at"
+	createProfileVerificationQueryMock    = "INSERT INTO profile_verifications \\(profile_id, pose, selfie_key, similarity, pose_matched, status, created_at, reviewed_at\\)"
+	reviewProfileVerificationQueryMock    = "UPDATE profile_verifications SET status = \\$2, reviewer_id = \\$3, reviewed_at = CURRENT_TIMESTAMP WHERE id = \\$1 AND status = 'PENDING' RETURNING profile_id"
+	verifyProfileQueryMock                = "UPDATE profiles SET is_verified = true WHERE id = \\$1"
+)
+
+var profileVerificationColumnsMock = []string{"id", "profile_id", "pose", "selfie_key", "similarity", "pose_matched", "status", "reviewer_id", "created_at", "reviewed_at"}
+
+// expectVerifiableProfile profile "profile_id_1" of UserID, not verified and
+// without verification waiting for review
+func expectVerifiableProfile(mock sqlmock.Sqlmock, UserID string) {
+	profile := testProfile(UserID)
+	profile.Photos = validString(testPhotoKey(1))
+	expectProfileByUserID(mock, UserID, profile)
+	mock.ExpectQuery(getLatestProfileVerificationQueryMock).WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows(profileVerificationColumnsMock))
+}
+
+func newSelfieRequest(t *testing.T) *domain.SubmitVerificationRequest {
+	return &domain.SubmitVerificationRequest{Selfie: newPhotoFileHeader(t, "selfie.png", testPNG)}
+}
+
+func TestStartVerification(t *testing.T) {
+	svc, mock, rdb := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	expectVerifiableProfile(mock, UserID)
+
+	challenge, errs := svc.StartVerification(ctx, UserID)
+	assert.Nil(t, errs)
+	assert.Contains(t, constant.VerificationPoses, challenge.Pose)
+	assert.Equal(t, challenge.Pose, rdb.values[verificationPoseKey(UserID)])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStartVerificationRejected(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	// already verified
+	profile := testProfile(UserID)
+	profile.IsVerified = true
+	expectProfileByUserID(mock, UserID, profile)
+
+	_, errs := svc.StartVerification(ctx, UserID)
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.Equal(t, "profile is already verified", errs.Error())
+
+	// waiting for review
+	expectProfileByUserID(mock, UserID, testProfile(UserID))
+	mock.ExpectQuery(getLatestProfileVerificationQueryMock).WithArgs("profile_id_1").
+		WillReturnRows(sqlmock.NewRows(profileVerificationColumnsMock).
+			AddRow("verification_id_1", "profile_id_1", "wave", "verifications/profile_id_1/a.jpg", 0.7, true, constant.VERIFICATION_STATUS_PENDING.String(), nil, time.Now(), nil))
+
+	_, errs = svc.StartVerification(ctx, UserID)
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.Equal(t, "verification is waiting for review", errs.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitVerificationApproved(t *testing.T) {
+	svc, mock, rdb := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	matcher := facematchpkg.NewFakeMatcher(facematchpkg.Result{Similarity: 0.96, PoseMatched: true})
+	svc.faceMatcher = matcher
+
+	cardKey := photoRenditionKey(testPhotoKey(1), photoRenditionCard)
+	assert.NoError(t, svc.blobStore.Put(ctx, cardKey, strings.NewReader("card"), 4, "image/jpeg"))
+	rdb.values[verificationPoseKey(UserID)] = "wave"
+
+	expectVerifiableProfile(mock, UserID)
+	expectProfilePhotos(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectQuery(createProfileVerificationQueryMock).
+		WithArgs("profile_id_1", "wave", sqlmock.AnyArg(), 0.96, true, constant.VERIFICATION_STATUS_APPROVED).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("verification_id_1"))
+	mock.ExpectExec(verifyProfileQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	verification, errs := svc.SubmitVerification(ctx, UserID, newSelfieRequest(t))
+	assert.Nil(t, errs)
+	assert.Equal(t, "verification_id_1", verification.ID)
+	assert.Equal(t, "APPROVED", verification.Status)
+	assert.NotNil(t, verification.ReviewedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// selfie is compared with the card rendition for the requested pose
+	requests := matcher.Requests()
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "wave", requests[0].Pose)
+	assert.Equal(t, [][]byte{[]byte("card")}, requests[0].Photos)
+	assert.NotEmpty(t, requests[0].Selfie)
+
+	// pose is used once
+	_, pending := rdb.values[verificationPoseKey(UserID)]
+	assert.False(t, pending)
+}
+
+func TestSubmitVerificationManualReview(t *testing.T) {
+	svc, mock, rdb := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	// face matcher is not available
+	rdb.values[verificationPoseKey(UserID)] = "wave"
+
+	expectVerifiableProfile(mock, UserID)
+	expectProfilePhotos(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectQuery(createProfileVerificationQueryMock).
+		WithArgs("profile_id_1", "wave", sqlmock.AnyArg(), nil, nil, constant.VERIFICATION_STATUS_PENDING).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("verification_id_1"))
+	mock.ExpectCommit()
+
+	verification, errs := svc.SubmitVerification(ctx, UserID, newSelfieRequest(t))
+	assert.Nil(t, errs)
+	assert.Equal(t, "PENDING", verification.Status)
+	assert.Nil(t, verification.ReviewedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitVerificationPoseExpired(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+	UserID := "user_id_1"
+
+	expectVerifiableProfile(mock, UserID)
+
+	_, errs := svc.SubmitVerification(ctx, UserID, newSelfieRequest(t))
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrBadRequest, errs.GetCode())
+	assert.Equal(t, "no verification in progress or the pose is expired", errs.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerificationStatus(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+
+	tests := []struct {
+		name   string
+		result *facematchpkg.Result
+		status constant.VerificationStatus
+	}{
+		{"matcher not available", nil, constant.VERIFICATION_STATUS_PENDING},
+		{"confident match", &facematchpkg.Result{Similarity: 0.9, PoseMatched: true}, constant.VERIFICATION_STATUS_APPROVED},
+		{"confident match without the pose", &facematchpkg.Result{Similarity: 0.95, PoseMatched: false}, constant.VERIFICATION_STATUS_PENDING},
+		{"unsure match", &facematchpkg.Result{Similarity: 0.6, PoseMatched: true}, constant.VERIFICATION_STATUS_PENDING},
+		{"different face", &facematchpkg.Result{Similarity: 0.2, PoseMatched: true}, constant.VERIFICATION_STATUS_REJECTED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.status, svc.verificationStatus(tt.result))
+		})
+	}
+}
+
+func TestMatchSelfieFailed(t *testing.T) {
+	svc, _, _ := newTokenTestService(t)
+	ctx := context.Background()
+
+	matcher := facematchpkg.NewFakeMatcher(facematchpkg.Result{})
+	matcher.SetResult(facematchpkg.Result{}, errors.New("provider unavailable"))
+	svc.faceMatcher = matcher
+
+	// failure fall back to a manual review
+	assert.Nil(t, svc.matchSelfie(ctx, []byte("selfie"), "wave", nil))
+	assert.Len(t, matcher.Requests(), 1)
+}
+
+func TestGetVerificationQueue(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+
+	getPendingProfileVerificationsQueryMock := "SELECT id, profile_id, pose, selfie_key, similarity, pose_matched, status, reviewer_id, created_at, reviewed_at FROM profile_verifications WHERE status = 'PENDING' ORDER BY created_at LIMIT \\$1"
+	mock.ExpectQuery(getPendingProfileVerificationsQueryMock).WithArgs(verificationQueueSize).
+		WillReturnRows(sqlmock.NewRows(profileVerificationColumnsMock).
+			AddRow("verification_id_1", "profile_id_1", "wave", "verifications/profile_id_1/a.jpg", 0.7, false, constant.VERIFICATION_STATUS_PENDING.String(), nil, time.Now(), nil))
+	expectProfilePhotos(mock, 2)
+
+	queue, errs := svc.GetVerificationQueue(ctx)
+	assert.Nil(t, errs)
+	assert.Len(t, queue, 1)
+	assert.Equal(t, "wave", queue[0].Pose)
+	assert.Equal(t, 0.7, *queue[0].Similarity)
+	assert.False(t, *queue[0].PoseMatched)
+	assert.True(t, strings.HasPrefix(queue[0].SelfieURL, "http://localhost:8080/media/verifications/profile_id_1/a.jpg?expires="))
+	assert.Len(t, queue[0].Photos, 2)
+
+	// reviewer can open the selfie with the signed url
+	assert.NoError(t, svc.blobStore.Put(ctx, "verifications/profile_id_1/a.jpg", strings.NewReader("jpg"), 3, "image/jpeg"))
+	src, errs := svc.GetMedia(ctx, mediaRequest(t, queue[0].SelfieURL))
+	assert.Nil(t, errs)
+	src.Close()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReviewVerification(t *testing.T) {
+	svc, mock, _ := newTokenTestService(t)
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(reviewProfileVerificationQueryMock).
+		WithArgs("verification_id_1", constant.VERIFICATION_STATUS_APPROVED, "admin_id_1").
+		WillReturnRows(sqlmock.NewRows([]string{"profile_id"}).AddRow("profile_id_1"))
+	mock.ExpectExec(verifyProfileQueryMock).WithArgs("profile_id_1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	errs := svc.ReviewVerification(ctx, "admin_id_1", "verification_id_1", true)
+	assert.Nil(t, errs)
+
+	// already reviewed by another admin
+	mock.ExpectBegin()
+	mock.ExpectQuery(reviewProfileVerificationQueryMock).
+		WithArgs("verification_id_1", constant.VERIFICATION_STATUS_REJECTED, "admin_id_2").
+		WillReturnRows(sqlmock.NewRows([]string{"profile_id"}))
+	mock.ExpectCommit()
+
+	errs = svc.ReviewVerification(ctx, "admin_id_2", "verification_id_1", false)
+	assert.NotNil(t, errs)
+	assert.Equal(t, errpkg.ErrNotFound, errs.GetCode())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	ctxsdk "github.com/ijlik/dating-user/pkg/context"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	httppkg "github.com/ijlik/dating-user/pkg/http"
+)
+
+func (rh *requestHandler) DeactivateAccount(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	errs := rh.service.DeactivateAccount(ctx, UserID)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, httppkg.DefaultSuccessResponse(nil))
+}
+
+func (rh *requestHandler) DeleteAccount(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	data, errs := rh.service.DeleteAccount(ctx, UserID)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) RequestDataExport(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	data, errs := rh.service.RequestDataExport(ctx, UserID)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(http.StatusAccepted, response)
+}
+
+// DownloadDataExport public endpoint, access is granted by the signed url
+func (rh *requestHandler) DownloadDataExport(c *gin.Context) {
+	var req domain.DownloadDataExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+	req.ID = c.Param("id")
+
+	body, errs := rh.service.GetDataExport(c.Request.Context(), &req)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+	defer body.Close()
+
+	c.Header("Cache-Control", "no-store")
+	c.DataFromReader(http.StatusOK, -1, "application/zip", body, map[string]string{
+		"Content-Disposition": `attachment; filename="data-export.zip"`,
+	})
+}
+
+func (rh *requestHandler) AttachPhone(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	var request domain.AttachPhoneRequest
+	if err := decodeRequest(c, &request); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+
+	data, errs := rh.service.AttachPhone(ctx, UserID, &request)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) VerifyPhone(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	var request domain.VerifyPhoneRequest
+	if err := decodeRequest(c, &request); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+
+	errs := rh.service.VerifyPhone(ctx, UserID, &request)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, httppkg.DefaultSuccessResponse(nil))
+}
+
+func (rh *requestHandler) RequestEmailChange(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	var request domain.ChangeEmailRequest
+	if err := decodeRequest(c, &request); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+
+	if err := request.Validate(rh.config.GetBool("ALLOWED_DISPOSABLE_EMAIL")); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+
+	data, errs := rh.service.RequestEmailChange(ctx, UserID, &request)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) ConfirmEmailChange(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	var request domain.ConfirmEmailChangeRequest
+	if err := decodeRequest(c, &request); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+
+	errs := rh.service.ConfirmEmailChange(ctx, UserID, &request)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, httppkg.DefaultSuccessResponse(nil))
+}
+
+// CancelEmailChange public endpoint, opened from the notice sent to the old
+// address
+func (rh *requestHandler) CancelEmailChange(c *gin.Context) {
+	errs := rh.service.CancelEmailChange(c.Request.Context(), c.Param("token"))
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, httppkg.DefaultSuccessResponse(nil))
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	ctxsdk "github.com/ijlik/dating-user/pkg/context"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	httppkg "github.com/ijlik/dating-user/pkg/http"
+)
+
+func (rh *requestHandler) UnlockUser(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	errs := rh.service.UnlockUser(ctx, c.Param("id"))
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, httppkg.DefaultSuccessResponse(nil))
+}
+
+func (rh *requestHandler) GetEmailChanges(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	data, errs := rh.service.GetEmailChanges(ctx, c.Param("id"))
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) GetVerificationQueue(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	data, errs := rh.service.GetVerificationQueue(ctx)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) ApproveVerification(c *gin.Context) {
+	rh.reviewVerification(c, true)
+}
+
+func (rh *requestHandler) RejectVerification(c *gin.Context) {
+	rh.reviewVerification(c, false)
+}
+
+func (rh *requestHandler) reviewVerification(c *gin.Context, approved bool) {
+	ctx := c.Request.Context()
+	reviewerID := ctxsdk.GetUserID(ctx)
+	if reviewerID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	id := c.Param("id")
+	if !domain.ValidVerificationID(id) {
+		httppkg.BuildErrorResponse(c, errpkg.ErrNotFound, "verification not found")
+		return
+	}
+
+	errs := rh.service.ReviewVerification(ctx, reviewerID, id, approved)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, httppkg.DefaultSuccessResponse(nil))
+}
@@ -1,7 +1,6 @@
 package http
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -46,6 +45,8 @@ func (rh *requestHandler) LoginOrRegister(c *gin.Context) {
 		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, "")
 		return
 	}
+	request.UserAgent = c.Request.UserAgent()
+	request.IP = c.ClientIP()
 
 	if err := request.Validate(); err != nil {
 		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
@@ -62,15 +63,67 @@ func (rh *requestHandler) LoginOrRegister(c *gin.Context) {
 	c.JSON(response.HttpCode, response)
 }
 
+func (rh *requestHandler) LoginWithMagicLink(c *gin.Context) {
+	ctx := c.Request.Context()
+	request := domain.MagicLinkRequest{
+		Token: c.Param("token"),
+		DeviceInfo: domain.DeviceInfo{
+			DeviceName: c.Query("device_name"),
+			UserAgent:  c.Request.UserAgent(),
+			IP:         c.ClientIP(),
+		},
+	}
+
+	if err := request.Validate(); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+
+	data, errs := rh.service.LoginWithMagicLink(ctx, &request)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) RefreshToken(c *gin.Context) {
+	ctx := c.Request.Context()
+	var request domain.RefreshTokenRequest
+	err := decodeRequest(c, &request)
+	if err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, "")
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+
+	data, errs := rh.service.RefreshToken(ctx, &request)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
 func (rh *requestHandler) Logout(c *gin.Context) {
 	ctx := c.Request.Context()
-	UserID := fmt.Sprintf("%v", ctx.Value(ctxsdk.USER_ID))
+	UserID := ctxsdk.GetUserID(ctx)
 	if UserID == "" {
 		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
 		return
 	}
 
-	errs := rh.service.Logout(ctx, fmt.Sprintf("%v", UserID))
+	sessionID := ctxsdk.GetSessionID(ctx)
+
+	errs := rh.service.Logout(ctx, UserID, sessionID)
 	if errs != nil {
 		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
 		return
@@ -79,15 +132,64 @@ func (rh *requestHandler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, httppkg.DefaultSuccessResponse(nil))
 }
 
-func (rh *requestHandler) ShowProfile(c *gin.Context) {
+func (rh *requestHandler) ListSessions(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+	sessionID := ctxsdk.GetSessionID(ctx)
+
+	data, errs := rh.service.ListSessions(ctx, UserID, sessionID)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) RevokeSession(c *gin.Context) {
 	ctx := c.Request.Context()
-	UserID := fmt.Sprintf("%v", ctx.Value(ctxsdk.USER_ID))
+	UserID := ctxsdk.GetUserID(ctx)
 	if UserID == "" {
 		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
 		return
 	}
-	email := fmt.Sprintf("%v", ctx.Value(ctxsdk.EMAIL))
-	if email == "" {
+
+	errs := rh.service.RevokeSession(ctx, UserID, c.Param("id"))
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, httppkg.DefaultSuccessResponse(nil))
+}
+
+func (rh *requestHandler) RevokeOtherSessions(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+	sessionID := ctxsdk.GetSessionID(ctx)
+
+	errs := rh.service.RevokeOtherSessions(ctx, UserID, sessionID)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, httppkg.DefaultSuccessResponse(nil))
+}
+
+func (rh *requestHandler) ShowProfile(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
 		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
 		return
 	}
@@ -98,6 +200,9 @@ func (rh *requestHandler) ShowProfile(c *gin.Context) {
 		return
 	}
 
+	// sent back on If-Match by PATCH /profile
+	c.Header("ETag", data.ETag())
+
 	response := httppkg.DefaultSuccessResponse(data)
 	c.JSON(response.HttpCode, response)
 }
@@ -1,7 +1,6 @@
 package http
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/ijlik/dating-user/internal/business/domain"
 	ctxsdk "github.com/ijlik/dating-user/pkg/context"
@@ -11,7 +10,7 @@ import (
 
 func (rh *requestHandler) ShowFeeds(c *gin.Context) {
 	ctx := c.Request.Context()
-	swiperId := fmt.Sprintf("%v", ctx.Value(ctxsdk.PROFILE_ID))
+	swiperId := ctxsdk.GetProfileID(ctx)
 	if swiperId == "" {
 		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
 		return
@@ -31,7 +30,7 @@ func (rh *requestHandler) ShowFeeds(c *gin.Context) {
 
 func (rh *requestHandler) Swipes(c *gin.Context) {
 	ctx := c.Request.Context()
-	UserID := fmt.Sprintf("%v", ctx.Value(ctxsdk.USER_ID))
+	UserID := ctxsdk.GetUserID(ctx)
 	if UserID == "" {
 		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
 		return
@@ -56,3 +55,50 @@ func (rh *requestHandler) Swipes(c *gin.Context) {
 	response := httppkg.DefaultSuccessResponse(nil)
 	c.JSON(response.HttpCode, response)
 }
+
+func (rh *requestHandler) GetDiscoveryPreferences(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	data, errs := rh.service.GetDiscoveryPreferences(ctx, UserID)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) UpdateDiscoveryPreferences(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	var request domain.UpdateDiscoveryPreferencesRequest
+	err := decodeRequest(c, &request)
+	if err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+	if errs := request.Validate(); errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	data, errs := rh.service.UpdateDiscoveryPreferences(ctx, UserID, &request)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
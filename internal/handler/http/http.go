@@ -1,22 +1,29 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	configdata "github.com/ijlik/dating-user/pkg/config/data"
+	"github.com/ijlik/dating-user/pkg/constant"
 	httppkg "github.com/ijlik/dating-user/pkg/http"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/ijlik/dating-user/internal/business/port"
 	httpmiddlewaresdk "github.com/ijlik/dating-user/pkg/http/middleware"
+	jwtpkg "github.com/ijlik/dating-user/pkg/jwt"
 )
 
 type requestHandler struct {
 	config  configdata.Config
 	service port.UserDomainService
-	pubKey  string
+	keySet  jwtpkg.KeySet
 	rdb     redis.Cmdable
+	limiter httpmiddlewaresdk.Limiter
 }
 
 func HandlerHttp(
@@ -24,14 +31,14 @@ func HandlerHttp(
 	config configdata.Config,
 	service port.UserDomainService,
 	rdb redis.Cmdable,
+	keySet jwtpkg.KeySet,
 ) {
-	pubkey := config.GetString("TOKEN_PUBLIC_KEY")
-
 	rh := requestHandler{
 		config:  config,
 		service: service,
-		pubKey:  pubkey,
+		keySet:  keySet,
 		rdb:     rdb,
+		limiter: httpmiddlewaresdk.NewRedisLimiter(rdb),
 	}
 
 	routeHandler(router, rh)
@@ -47,34 +54,194 @@ func HandlerHttp(
 }
 
 func routeHandler(router *gin.Engine, rh requestHandler) {
+	router.GET("/.well-known/jwks.json", rh.Jwks)
+
 	authRoute := router.Group("/auth")
-	authRoute.POST("/otp/resend", rh.ResendOtp)
-	authRoute.POST("/otp", rh.LoginOrRegister)
-	authRoute.Use(
-		httpmiddlewaresdk.WithLoginAndRedis(rh.pubKey, rh.rdb),
-	).POST("/logout", rh.Logout)
-	authRoute.Use(
-		httpmiddlewaresdk.WithLoginAndRedis(rh.pubKey, rh.rdb),
-	).GET("/me", rh.ShowProfile)
+	authRoute.POST("/otp/resend", httpmiddlewaresdk.WithRateLimit(
+		rh.limiter,
+		rh.rateLimitRule("otp_resend_ip", "20/1h", httpmiddlewaresdk.KeyByIP),
+		rh.rateLimitRule("otp_resend_email", "5/1h", httpmiddlewaresdk.KeyByBodyField("email")),
+		rh.rateLimitRule("otp_resend_phone", "5/1h", httpmiddlewaresdk.KeyByBodyField("phone")),
+	), rh.ResendOtp)
+	authRoute.POST("/otp", httpmiddlewaresdk.WithRateLimit(
+		rh.limiter,
+		rh.rateLimitRule("otp_ip", "30/15m", httpmiddlewaresdk.KeyByIP),
+		rh.rateLimitRule("otp_email", "10/15m", httpmiddlewaresdk.KeyByBodyField("email")),
+		rh.rateLimitRule("otp_phone", "10/15m", httpmiddlewaresdk.KeyByBodyField("phone")),
+	), rh.LoginOrRegister)
+	authRoute.POST("/token/refresh", httpmiddlewaresdk.WithRateLimit(
+		rh.limiter,
+		rh.rateLimitRule("token_refresh_ip", "60/1m", httpmiddlewaresdk.KeyByIP),
+	), rh.RefreshToken)
+	authRoute.GET("/magic/:token", httpmiddlewaresdk.WithRateLimit(
+		rh.limiter,
+		rh.rateLimitRule("magic_link_ip", "30/15m", httpmiddlewaresdk.KeyByIP),
+	), rh.LoginWithMagicLink)
+
+	sessionRoute := authRoute.Group("").Use(
+		httpmiddlewaresdk.WithLoginAndRedis(rh.keySet, rh.rdb),
+	)
+	sessionRoute.POST("/logout", rh.Logout)
+	sessionRoute.GET("/me", rh.ShowProfile)
+	sessionRoute.GET("/sessions", rh.ListSessions)
+	sessionRoute.DELETE("/sessions", rh.RevokeOtherSessions)
+	sessionRoute.DELETE("/sessions/:id", rh.RevokeSession)
+
+	// signed url and cancel token are the credential, links are opened from
+	// email
+	router.GET("/account/export/:id", rh.DownloadDataExport)
+
+	// photos of the blob store, access is granted by the signed url
+	router.GET("/media/*key", rh.GetMedia)
+	router.GET("/account/email/cancel/:token", httpmiddlewaresdk.WithRateLimit(
+		rh.limiter,
+		rh.rateLimitRule("email_change_cancel_ip", "30/15m", httpmiddlewaresdk.KeyByIP),
+	), rh.CancelEmailChange)
+
+	accountRoute := router.Group("/account").Use(
+		httpmiddlewaresdk.WithLoginAndRedis(rh.keySet, rh.rdb),
+		httpmiddlewaresdk.WithScope(constant.SCOPE_USER),
+	)
+	accountRoute.POST("/deactivate", rh.DeactivateAccount)
+	accountRoute.DELETE("", rh.DeleteAccount)
+	accountRoute.POST("/export", rh.RequestDataExport)
+	accountRoute.POST("/phone", httpmiddlewaresdk.WithRateLimit(
+		rh.limiter,
+		rh.rateLimitRule("account_phone_user", "5/1h", httpmiddlewaresdk.KeyByUserID),
+	), rh.AttachPhone)
+	accountRoute.POST("/phone/verify", httpmiddlewaresdk.WithRateLimit(
+		rh.limiter,
+		rh.rateLimitRule("account_phone_verify_user", "10/15m", httpmiddlewaresdk.KeyByUserID),
+	), rh.VerifyPhone)
+	accountRoute.POST("/email", httpmiddlewaresdk.WithRateLimit(
+		rh.limiter,
+		rh.rateLimitRule("account_email_user", "5/1h", httpmiddlewaresdk.KeyByUserID),
+	), rh.RequestEmailChange)
+	accountRoute.POST("/email/verify", httpmiddlewaresdk.WithRateLimit(
+		rh.limiter,
+		rh.rateLimitRule("account_email_verify_user", "10/15m", httpmiddlewaresdk.KeyByUserID),
+	), rh.ConfirmEmailChange)
+
+	// user who did not complete onboarding can only use routes listed on
+	// ONBOARDING_ALLOWED_ROUTES among the guarded ones
+	onboardingAllowed := rh.onboardingAllowedRoutes()
 
 	onboardRoute := router.Group("/on-boarding").Use(
-		httpmiddlewaresdk.WithLoginAndRedis(rh.pubKey, rh.rdb),
+		httpmiddlewaresdk.WithLoginAndRedis(rh.keySet, rh.rdb),
+		httpmiddlewaresdk.WithScope(constant.SCOPE_USER),
 	)
+	onboardRoute.GET("", rh.GetOnboardingProgress)
 	onboardRoute.POST("/personal-info", rh.UpdatePersonalInfo)
 	onboardRoute.POST("/photos", rh.UpdatePhotos)
 	onboardRoute.POST("/hobby-and-interest", rh.UpdateHobbyAndInterest)
 	onboardRoute.POST("/location", rh.UpdateLocation)
 
+	profileRoute := router.Group("/profile").Use(
+		httpmiddlewaresdk.WithLoginAndRedis(rh.keySet, rh.rdb),
+		httpmiddlewaresdk.WithScope(constant.SCOPE_USER),
+		httpmiddlewaresdk.WithOnboardingCompleted(rh.missingOnboardingSteps, onboardingAllowed...),
+	)
+	profileRoute.PATCH("", rh.PatchProfile)
+	profileRoute.GET("/prompts", rh.GetProfilePrompts)
+	profileRoute.GET("/verification", rh.GetVerification)
+	profileRoute.POST("/verification", httpmiddlewaresdk.WithRateLimit(
+		rh.limiter,
+		rh.rateLimitRule("verification_user", "5/1h", httpmiddlewaresdk.KeyByUserID),
+	), rh.StartVerification)
+	profileRoute.POST("/verification/selfie", rh.SubmitVerification)
+
+	// photos are managed during onboarding too, reaching the minimum count
+	// complete the photos step
+	photoRoute := router.Group("/profile/photos").Use(
+		httpmiddlewaresdk.WithLoginAndRedis(rh.keySet, rh.rdb),
+		httpmiddlewaresdk.WithScope(constant.SCOPE_USER),
+	)
+	photoRoute.GET("", rh.GetProfilePhotos)
+	photoRoute.POST("", rh.AddProfilePhotos)
+	photoRoute.PUT("/order", rh.ReorderProfilePhotos)
+	photoRoute.PUT("/:id/primary", rh.SetPrimaryProfilePhoto)
+	photoRoute.DELETE("/:id", rh.DeleteProfilePhoto)
+
 	feedsRoute := router.Group("/feeds").Use(
-		httpmiddlewaresdk.WithLoginAndRedis(rh.pubKey, rh.rdb),
+		httpmiddlewaresdk.WithLoginAndRedis(rh.keySet, rh.rdb),
+		httpmiddlewaresdk.WithScope(constant.SCOPE_USER),
+		httpmiddlewaresdk.WithOnboardingCompleted(rh.missingOnboardingSteps, onboardingAllowed...),
 	)
 	feedsRoute.GET("", rh.ShowFeeds)
 	feedsRoute.POST("", rh.Swipes)
+	feedsRoute.GET("/preferences", rh.GetDiscoveryPreferences)
+	feedsRoute.PUT("/preferences", rh.UpdateDiscoveryPreferences)
 
 	paymentRoute := router.Group("/payment").Use(
-		httpmiddlewaresdk.WithLoginAndRedis(rh.pubKey, rh.rdb),
+		httpmiddlewaresdk.WithLoginAndRedis(rh.keySet, rh.rdb),
+		httpmiddlewaresdk.WithScope(constant.SCOPE_USER),
+		httpmiddlewaresdk.WithOnboardingCompleted(rh.missingOnboardingSteps, onboardingAllowed...),
+	)
+	paymentRoute.POST("", httpmiddlewaresdk.WithRateLimit(
+		rh.limiter,
+		rh.rateLimitRule("payment_user", "10/1m", httpmiddlewaresdk.KeyByUserID),
+	), rh.CreatePayment)
+
+	adminRoute := router.Group("/admin").Use(
+		httpmiddlewaresdk.WithLoginAndRedis(rh.keySet, rh.rdb),
+		httpmiddlewaresdk.WithScope(constant.SCOPE_ADMIN),
 	)
-	paymentRoute.POST("", rh.CreatePayment)
+	adminRoute.POST("/users/:id/unlock", rh.UnlockUser)
+	adminRoute.GET("/users/:id/email-changes", rh.GetEmailChanges)
+	adminRoute.GET("/verifications", rh.GetVerificationQueue)
+	adminRoute.POST("/verifications/:id/approve", rh.ApproveVerification)
+	adminRoute.POST("/verifications/:id/reject", rh.RejectVerification)
+}
+
+// rateLimitRule rate is read from RATE_LIMIT_<NAME> (e.g. RATE_LIMIT_OTP_IP=30/15m),
+// fallback to default rate when not set or invalid
+func (rh requestHandler) rateLimitRule(
+	name string,
+	defaultRate string,
+	key httpmiddlewaresdk.KeyFunc,
+) httpmiddlewaresdk.RateLimitRule {
+	configKey := fmt.Sprintf("RATE_LIMIT_%s", strings.ToUpper(name))
+	rate := rh.config.GetString(configKey)
+	if rate == "" {
+		rate = defaultRate
+	}
+
+	limit, window, err := httpmiddlewaresdk.ParseRate(rate)
+	if err != nil {
+		log.Printf("%s: %s, using default %s\n", configKey, err, defaultRate)
+		limit, window, _ = httpmiddlewaresdk.ParseRate(defaultRate)
+	}
+
+	return httpmiddlewaresdk.RateLimitRule{
+		Name:   name,
+		Limit:  limit,
+		Window: window,
+		Key:    key,
+	}
+}
+
+// onboardingAllowedRoutes read ONBOARDING_ALLOWED_ROUTES, comma separated
+// "<METHOD> <path>" (e.g. "GET /feeds,POST /payment")
+func (rh requestHandler) onboardingAllowedRoutes() []string {
+	var routes []string
+	for _, route := range rh.config.GetArray("ONBOARDING_ALLOWED_ROUTES") {
+		parts := strings.Fields(route)
+		if len(parts) != 2 {
+			continue
+		}
+		routes = append(routes, httpmiddlewaresdk.RouteKey(strings.ToUpper(parts[0]), parts[1]))
+	}
+
+	return routes
+}
+
+func (rh requestHandler) missingOnboardingSteps(ctx context.Context, UserID string) ([]string, error) {
+	progress, errs := rh.service.GetOnboardingProgress(ctx, UserID)
+	if errs != nil {
+		return nil, errs
+	}
+
+	return progress.MissingSteps, nil
 }
 
 func decodeRequest(c *gin.Context, i interface{}) error {
@@ -0,0 +1,22 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	httppkg "github.com/ijlik/dating-user/pkg/http"
+)
+
+// Jwks publish verification keys for other services, response follow RFC 7517
+// instead of default response format
+func (rh *requestHandler) Jwks(c *gin.Context) {
+	jwks, err := rh.keySet.JWKS()
+	if err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrInternal, err.Error())
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
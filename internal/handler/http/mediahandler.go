@@ -0,0 +1,62 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	httppkg "github.com/ijlik/dating-user/pkg/http"
+)
+
+// GetMedia public endpoint, access is granted by the signed url. Stored
+// objects never change under their key, they are cached until the url expires
+func (rh *requestHandler) GetMedia(c *gin.Context) {
+	var req domain.GetMediaRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+	req.Key = strings.TrimPrefix(c.Param("key"), "/")
+
+	body, errs := rh.service.GetMedia(c.Request.Context(), &req)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+	defer body.Close()
+
+	// range request need to seek, object of a remote store is buffered
+	content, ok := body.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			httppkg.BuildErrorResponse(c, errpkg.ErrInternal, err.Error())
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(req.Key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	maxAge := req.Expires - time.Now().Unix()
+	if maxAge < 0 {
+		maxAge = 0
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d, immutable", maxAge))
+	c.Header("ETag", fmt.Sprintf("%q", req.Key))
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, content)
+}
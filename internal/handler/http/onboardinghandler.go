@@ -1,18 +1,34 @@
 package http
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/ijlik/dating-user/internal/business/domain"
 	ctxsdk "github.com/ijlik/dating-user/pkg/context"
 	errpkg "github.com/ijlik/dating-user/pkg/error"
 	httppkg "github.com/ijlik/dating-user/pkg/http"
-	"mime/multipart"
 )
 
+func (rh *requestHandler) GetOnboardingProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	data, errs := rh.service.GetOnboardingProgress(ctx, UserID)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
 func (rh *requestHandler) UpdatePersonalInfo(c *gin.Context) {
 	ctx := c.Request.Context()
-	UserID := fmt.Sprintf("%v", ctx.Value(ctxsdk.USER_ID))
+	UserID := ctxsdk.GetUserID(ctx)
 	if UserID == "" {
 		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
 		return
@@ -41,21 +57,12 @@ func (rh *requestHandler) UpdatePersonalInfo(c *gin.Context) {
 
 func (rh *requestHandler) UpdatePhotos(c *gin.Context) {
 	ctx := c.Request.Context()
-	UserID := fmt.Sprintf("%v", ctx.Value(ctxsdk.USER_ID))
+	UserID := ctxsdk.GetUserID(ctx)
 	if UserID == "" {
 		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
 		return
 	}
-	var photos []*multipart.FileHeader
-	for _, i := range []string{"0", "1", "2", "3", "4"} {
-		formName := fmt.Sprintf("photos[%s]", i)
-		file, err := c.FormFile(formName)
-		if err != nil {
-			break
-		}
-		photos = append(photos, file)
-	}
-	request := domain.UpdatePhotos{Photos: photos}
+	request := domain.UpdatePhotos{Photos: photosFromForm(c)}
 	err := request.Validate()
 	if err != nil {
 		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
@@ -74,7 +81,7 @@ func (rh *requestHandler) UpdatePhotos(c *gin.Context) {
 
 func (rh *requestHandler) UpdateHobbyAndInterest(c *gin.Context) {
 	ctx := c.Request.Context()
-	UserID := fmt.Sprintf("%v", ctx.Value(ctxsdk.USER_ID))
+	UserID := ctxsdk.GetUserID(ctx)
 	if UserID == "" {
 		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
 		return
@@ -103,7 +110,7 @@ func (rh *requestHandler) UpdateHobbyAndInterest(c *gin.Context) {
 
 func (rh *requestHandler) UpdateLocation(c *gin.Context) {
 	ctx := c.Request.Context()
-	UserID := fmt.Sprintf("%v", ctx.Value(ctxsdk.USER_ID))
+	UserID := ctxsdk.GetUserID(ctx)
 	if UserID == "" {
 		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
 		return
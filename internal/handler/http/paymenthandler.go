@@ -1,7 +1,6 @@
 package http
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/ijlik/dating-user/internal/business/domain"
 	ctxsdk "github.com/ijlik/dating-user/pkg/context"
@@ -11,7 +10,7 @@ import (
 
 func (rh *requestHandler) CreatePayment(c *gin.Context) {
 	ctx := c.Request.Context()
-	UserID := fmt.Sprintf("%v", ctx.Value(ctxsdk.USER_ID))
+	UserID := ctxsdk.GetUserID(ctx)
 	if UserID == "" {
 		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
 		return
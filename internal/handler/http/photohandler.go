@@ -0,0 +1,150 @@
+package http
+
+import (
+	"fmt"
+	"mime/multipart"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	ctxsdk "github.com/ijlik/dating-user/pkg/context"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	httppkg "github.com/ijlik/dating-user/pkg/http"
+)
+
+// photosFromForm files of the "photos" field, "photos[0]".."photos[n]" sent
+// by older clients are still accepted
+func photosFromForm(c *gin.Context) []*multipart.FileHeader {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil
+	}
+
+	photos := form.File["photos"]
+	for i := 0; ; i++ {
+		files := form.File[fmt.Sprintf("photos[%d]", i)]
+		if len(files) == 0 {
+			break
+		}
+		photos = append(photos, files...)
+	}
+
+	return photos
+}
+
+func (rh *requestHandler) GetProfilePhotos(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	data, errs := rh.service.GetProfilePhotos(ctx, UserID)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) AddProfilePhotos(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	request := domain.UpdatePhotos{Photos: photosFromForm(c)}
+	if errs := request.Validate(); errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	data, errs := rh.service.AddProfilePhotos(ctx, UserID, &request)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) DeleteProfilePhoto(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	id := c.Param("id")
+	if !domain.ValidPhotoID(id) {
+		httppkg.BuildErrorResponse(c, errpkg.ErrNotFound, "photo not found")
+		return
+	}
+
+	data, errs := rh.service.DeleteProfilePhoto(ctx, UserID, id)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) ReorderProfilePhotos(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	var request domain.ReorderProfilePhotosRequest
+	if err := decodeRequest(c, &request); err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+	if errs := request.Validate(); errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	data, errs := rh.service.ReorderProfilePhotos(ctx, UserID, &request)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) SetPrimaryProfilePhoto(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	id := c.Param("id")
+	if !domain.ValidPhotoID(id) {
+		httppkg.BuildErrorResponse(c, errpkg.ErrNotFound, "photo not found")
+		return
+	}
+
+	data, errs := rh.service.SetPrimaryProfilePhoto(ctx, UserID, id)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
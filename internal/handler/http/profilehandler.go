@@ -0,0 +1,53 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ijlik/dating-user/internal/business/domain"
+	ctxsdk "github.com/ijlik/dating-user/pkg/context"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+	httppkg "github.com/ijlik/dating-user/pkg/http"
+)
+
+func (rh *requestHandler) PatchProfile(c *gin.Context) {
+	ctx := c.Request.Context()
+	UserID := ctxsdk.GetUserID(ctx)
+	if UserID == "" {
+		httppkg.BuildErrorResponse(c, errpkg.ErrUnauthorize, "")
+		return
+	}
+
+	var request domain.PatchProfileRequest
+	err := decodeRequest(c, &request)
+	if err != nil {
+		httppkg.BuildErrorResponse(c, errpkg.ErrBadRequest, err.Error())
+		return
+	}
+	if errs := request.Validate(); errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+	request.IfMatch = c.GetHeader("If-Match")
+
+	data, errs := rh.service.PatchProfile(ctx, UserID, &request)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	c.Header("ETag", data.ETag())
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
+
+func (rh *requestHandler) GetProfilePrompts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	data, errs := rh.service.GetProfilePrompts(ctx)
+	if errs != nil {
+		httppkg.BuildErrorResponse(c, errs.GetCode(), errs.Error())
+		return
+	}
+
+	response := httppkg.DefaultSuccessResponse(data)
+	c.JSON(response.HttpCode, response)
+}
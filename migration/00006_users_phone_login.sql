@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL;
+ALTER TABLE users ALTER COLUMN phone TYPE VARCHAR(16);
+ALTER TABLE users ADD CONSTRAINT chk_users_email_or_phone CHECK (email IS NOT NULL OR phone IS NOT NULL);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_phone ON users(phone) WHERE phone IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_phone;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_email_or_phone;
+ALTER TABLE users ALTER COLUMN phone TYPE VARCHAR(15);
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS idx_code_otp_type ON one_time_password_logs(onetime_password_type, code);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_code_otp_type;
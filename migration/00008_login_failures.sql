@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS login_failures (
+    user_id uuid NOT NULL,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    lockout_level INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP NULL,
+    previous_status VARCHAR(20) NULL,
+    last_failed_at TIMESTAMP NULL,
+    PRIMARY KEY (user_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS login_failures;
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS delete_scheduled_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_delete_scheduled_at ON users(delete_scheduled_at) WHERE delete_scheduled_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_delete_scheduled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS delete_scheduled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deactivated_at;
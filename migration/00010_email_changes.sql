@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS email_changes (
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    user_id uuid NOT NULL,
+    old_email VARCHAR(255) NOT NULL,
+    new_email VARCHAR(255) NOT NULL,
+    cancel_token TEXT NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'PENDING', -- "PENDING, CONFIRMED, CANCELLED, REVERTED"
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    confirmed_at TIMESTAMP NULL,
+    cancelled_at TIMESTAMP NULL,
+    PRIMARY KEY (id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_changes_user_id ON email_changes(user_id, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_changes_cancel_token ON email_changes(cancel_token);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_email_changes_cancel_token;
+DROP INDEX IF EXISTS idx_email_changes_user_id;
+DROP TABLE IF EXISTS email_changes;
@@ -0,0 +1,41 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS user_onboarding_steps (
+    user_id uuid NOT NULL,
+    step VARCHAR(30) NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending', -- "pending, done"
+    completed_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, step),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- "personal-info:done,photos:pending,..." become one row per step
+INSERT INTO user_onboarding_steps (user_id, step, status, completed_at, created_at)
+SELECT users.id,
+       TRIM(SPLIT_PART(item.part, ':', 1)),
+       TRIM(SPLIT_PART(item.part, ':', 2)),
+       CASE WHEN TRIM(SPLIT_PART(item.part, ':', 2)) = 'done' THEN COALESCE(users.updated_at, users.created_at) END,
+       users.created_at
+FROM users, UNNEST(STRING_TO_ARRAY(users.onboarding_steps, ',')) AS item(part)
+WHERE TRIM(SPLIT_PART(item.part, ':', 1)) <> '' AND TRIM(SPLIT_PART(item.part, ':', 2)) IN ('pending', 'done')
+ON CONFLICT (user_id, step) DO NOTHING;
+
+ALTER TABLE users DROP COLUMN IF EXISTS onboarding_steps;
+
+-- +goose Down
+ALTER TABLE users ADD COLUMN IF NOT EXISTS onboarding_steps TEXT NOT NULL DEFAULT '';
+
+UPDATE users SET onboarding_steps = steps.value
+FROM (
+    SELECT user_id, STRING_AGG(step || ':' || status, ',' ORDER BY CASE step
+        WHEN 'personal-info' THEN 1
+        WHEN 'photos' THEN 2
+        WHEN 'hobby-and-interest' THEN 3
+        WHEN 'location' THEN 4
+        ELSE 5 END) AS value
+    FROM user_onboarding_steps
+    GROUP BY user_id
+) AS steps
+WHERE users.id = steps.user_id;
+
+DROP TABLE IF EXISTS user_onboarding_steps;
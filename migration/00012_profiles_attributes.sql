@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS bio TEXT NULL,
+    ADD COLUMN IF NOT EXISTS job_title VARCHAR(100) NULL,
+    ADD COLUMN IF NOT EXISTS company VARCHAR(100) NULL,
+    ADD COLUMN IF NOT EXISTS education VARCHAR(100) NULL,
+    ADD COLUMN IF NOT EXISTS height_cm SMALLINT NULL,
+    ADD COLUMN IF NOT EXISTS languages TEXT NULL, -- ISO 639-1 codes, "en,id"
+    ADD COLUMN IF NOT EXISTS prompts JSONB NULL; -- [{"prompt_id", "question", "answer"}]
+
+-- +goose Down
+ALTER TABLE profiles
+    DROP COLUMN IF EXISTS bio,
+    DROP COLUMN IF EXISTS job_title,
+    DROP COLUMN IF EXISTS company,
+    DROP COLUMN IF EXISTS education,
+    DROP COLUMN IF EXISTS height_cm,
+    DROP COLUMN IF EXISTS languages,
+    DROP COLUMN IF EXISTS prompts;
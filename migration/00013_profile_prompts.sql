@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS profile_prompts (
+    id VARCHAR(50) NOT NULL,
+    question VARCHAR(150) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT True,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+INSERT INTO profile_prompts (id, question, position) VALUES
+    ('typical-sunday', 'A typical Sunday', 1),
+    ('simple-pleasures', 'My simple pleasures', 2),
+    ('two-truths-and-a-lie', 'Two truths and a lie', 3),
+    ('green-flags', 'I know we will get along if', 4),
+    ('unusual-skill', 'My most unusual skill', 5),
+    ('travel-next', 'Next place I want to travel to', 6)
+ON CONFLICT (id) DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS profile_prompts;
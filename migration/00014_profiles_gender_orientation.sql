@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE profiles
+    ALTER COLUMN gender TYPE VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS gender_custom VARCHAR(30) NULL, -- label of gender "Other"
+    ADD COLUMN IF NOT EXISTS orientation VARCHAR(20) NULL,
+    ADD COLUMN IF NOT EXISTS show_me TEXT NULL; -- genders to discover, "Male,Non-binary", empty is everyone
+
+-- +goose Down
+ALTER TABLE profiles
+    DROP COLUMN IF EXISTS gender_custom,
+    DROP COLUMN IF EXISTS orientation,
+    DROP COLUMN IF EXISTS show_me;
+
+UPDATE profiles SET gender = NULL WHERE gender NOT IN ('Male', 'Female');
+
+ALTER TABLE profiles
+    ALTER COLUMN gender TYPE VARCHAR(10);
@@ -0,0 +1,16 @@
+-- +goose Up
+-- genders shown are the "show me" of the profile, it also decide who can
+-- discover the user
+CREATE TABLE IF NOT EXISTS discovery_preferences (
+    user_id uuid NOT NULL,
+    min_age SMALLINT NOT NULL,
+    max_age SMALLINT NOT NULL,
+    max_distance_km INT NOT NULL DEFAULT 0, -- 0 is anywhere
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL,
+    PRIMARY KEY (user_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS discovery_preferences;
@@ -0,0 +1,104 @@
+-- +goose Up
+-- location was stored as "longitude:latitude" text, coordinates are now numeric
+-- with a geohash (9 characters, about 5 m) used as a geospatial index without
+-- PostGIS
+ALTER TABLE profiles
+    ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION NULL,
+    ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION NULL,
+    ADD COLUMN IF NOT EXISTS geohash VARCHAR(12) NULL;
+
+ALTER TABLE profiles
+    ADD CONSTRAINT profiles_latitude_range CHECK (latitude BETWEEN -90 AND 90),
+    ADD CONSTRAINT profiles_longitude_range CHECK (longitude BETWEEN -180 AND 180),
+    ADD CONSTRAINT profiles_coordinates_pair CHECK ((latitude IS NULL) = (longitude IS NULL));
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION geohash_encode(lat DOUBLE PRECISION, lon DOUBLE PRECISION, hash_length INT)
+RETURNS TEXT AS $$
+DECLARE
+    alphabet CONSTANT TEXT := '0123456789bcdefghjkmnpqrstuvwxyz';
+    min_lat DOUBLE PRECISION := -90;
+    max_lat DOUBLE PRECISION := 90;
+    min_lon DOUBLE PRECISION := -180;
+    max_lon DOUBLE PRECISION := 180;
+    mid DOUBLE PRECISION;
+    result TEXT := '';
+    even BOOLEAN := true;
+    bit_index INT := 0;
+    ch INT := 0;
+BEGIN
+    WHILE LENGTH(result) < hash_length LOOP
+        IF even THEN
+            mid := (min_lon + max_lon) / 2;
+            IF lon >= mid THEN
+                ch := ch | (1 << (4 - bit_index));
+                min_lon := mid;
+            ELSE
+                max_lon := mid;
+            END IF;
+        ELSE
+            mid := (min_lat + max_lat) / 2;
+            IF lat >= mid THEN
+                ch := ch | (1 << (4 - bit_index));
+                min_lat := mid;
+            ELSE
+                max_lat := mid;
+            END IF;
+        END IF;
+        even := NOT even;
+        IF bit_index < 4 THEN
+            bit_index := bit_index + 1;
+        ELSE
+            result := result || SUBSTR(alphabet, ch + 1, 1);
+            bit_index := 0;
+            ch := 0;
+        END IF;
+    END LOOP;
+    RETURN result;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+-- +goose StatementEnd
+
+-- strings out of range or not numeric are dropped, the user is asked for the
+-- location again by onboarding
+UPDATE profiles
+SET latitude = parsed.latitude,
+    longitude = parsed.longitude
+FROM (
+    SELECT id,
+           SPLIT_PART(location, ':', 2)::DOUBLE PRECISION AS latitude,
+           SPLIT_PART(location, ':', 1)::DOUBLE PRECISION AS longitude
+    FROM profiles
+    WHERE location ~ '^-?\d+(\.\d+)?:-?\d+(\.\d+)?$'
+    OFFSET 0 -- keeps the casts after the format check
+) AS parsed
+WHERE profiles.id = parsed.id
+  AND parsed.latitude BETWEEN -90 AND 90
+  AND parsed.longitude BETWEEN -180 AND 180;
+
+UPDATE profiles SET geohash = geohash_encode(latitude, longitude, 9) WHERE latitude IS NOT NULL;
+
+UPDATE user_onboarding_steps SET status = 'pending', completed_at = NULL
+WHERE step = 'location'
+  AND user_id IN (SELECT user_id FROM profiles WHERE latitude IS NULL);
+
+DROP FUNCTION geohash_encode(DOUBLE PRECISION, DOUBLE PRECISION, INT);
+
+ALTER TABLE profiles DROP COLUMN IF EXISTS location;
+
+CREATE INDEX IF NOT EXISTS profiles_geohash_idx ON profiles (geohash text_pattern_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS profiles_geohash_idx;
+
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS location TEXT NULL;
+
+UPDATE profiles SET location = longitude::TEXT || ':' || latitude::TEXT WHERE latitude IS NOT NULL;
+
+ALTER TABLE profiles
+    DROP CONSTRAINT IF EXISTS profiles_latitude_range,
+    DROP CONSTRAINT IF EXISTS profiles_longitude_range,
+    DROP CONSTRAINT IF EXISTS profiles_coordinates_pair,
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS geohash;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- photos were stored as "storage/photos/<profile id>/<file name>", they are now
+-- object keys of the blob store, the local store root being "storage"
+UPDATE profiles
+SET photos = REGEXP_REPLACE(photos, '(^|,)storage/', '\1', 'g')
+WHERE photos LIKE 'storage/%';
+
+-- +goose Down
+UPDATE profiles
+SET photos = REGEXP_REPLACE(photos, '(^|,)photos/', '\1storage/photos/', 'g')
+WHERE photos LIKE 'photos/%';
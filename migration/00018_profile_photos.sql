@@ -0,0 +1,34 @@
+-- +goose Up
+-- profiles.photos is kept as the ordered keys (primary first) so profile and
+-- feeds read it without a join, every change goes through profile_photos
+CREATE TABLE IF NOT EXISTS profile_photos (
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    profile_id uuid NOT NULL,
+    blob_key TEXT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    is_primary BOOLEAN NOT NULL DEFAULT False,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    FOREIGN KEY (profile_id) REFERENCES profiles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_photos_profile_id ON profile_photos(profile_id, position);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_profile_photos_blob_key ON profile_photos(profile_id, blob_key);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_profile_photos_primary ON profile_photos(profile_id) WHERE is_primary;
+
+-- "key1,key2,..." become one row per photo, the first one is primary
+INSERT INTO profile_photos (profile_id, blob_key, position, is_primary, created_at)
+SELECT profiles.id,
+       TRIM(item.blob_key),
+       item.position - 1,
+       item.position = 1,
+       COALESCE(profiles.updated_at, profiles.created_at)
+FROM profiles, UNNEST(STRING_TO_ARRAY(profiles.photos, ',')) WITH ORDINALITY AS item(blob_key, position)
+WHERE TRIM(item.blob_key) <> ''
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_profile_photos_primary;
+DROP INDEX IF EXISTS idx_profile_photos_blob_key;
+DROP INDEX IF EXISTS idx_profile_photos_profile_id;
+DROP TABLE IF EXISTS profile_photos;
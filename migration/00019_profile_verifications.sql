@@ -0,0 +1,32 @@
+-- +goose Up
+-- selfie of the user doing a requested pose, compared to the profile photos by
+-- the face matcher. Unsure match wait as PENDING for a manual review
+CREATE TABLE IF NOT EXISTS profile_verifications (
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    profile_id uuid NOT NULL,
+    pose VARCHAR(50) NOT NULL,
+    selfie_key TEXT NOT NULL,
+    similarity NUMERIC(4, 3) NULL, -- null when the face matcher was not available
+    pose_matched BOOLEAN NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'PENDING', -- "PENDING, APPROVED, REJECTED"
+    reviewer_id uuid NULL, -- null when decided by the face matcher
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP NULL,
+    PRIMARY KEY (id),
+    FOREIGN KEY (profile_id) REFERENCES profiles (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_verifications_profile_id ON profile_verifications(profile_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_profile_verifications_pending ON profile_verifications(created_at) WHERE status = 'PENDING';
+
+-- badge shown on the profile and the feeds, users can choose to only discover
+-- verified profiles
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS is_verified BOOLEAN NOT NULL DEFAULT False;
+ALTER TABLE discovery_preferences ADD COLUMN IF NOT EXISTS verified_only BOOLEAN NOT NULL DEFAULT False;
+
+-- +goose Down
+ALTER TABLE discovery_preferences DROP COLUMN IF EXISTS verified_only;
+ALTER TABLE profiles DROP COLUMN IF EXISTS is_verified;
+DROP INDEX IF EXISTS idx_profile_verifications_pending;
+DROP INDEX IF EXISTS idx_profile_verifications_profile_id;
+DROP TABLE IF EXISTS profile_verifications;
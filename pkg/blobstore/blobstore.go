@@ -0,0 +1,50 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore object storage addressed by slash separated keys, e.g.
+// "photos/<profile id>/<sha256>.jpg"
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get ErrNotFound when there is no object under the key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete missing object is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// ContentKey key derived from the content, same content under the same prefix
+// always land on the same key
+func ContentKey(prefix string, content []byte, ext string) string {
+	sum := sha256.Sum256(content)
+	return path.Join(prefix, hex.EncodeToString(sum[:])+ext)
+}
+
+// ValidKey relative key without empty, "." or ".." segment
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func joinURL(baseURL, key string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + key
+}
@@ -0,0 +1,27 @@
+package constant
+
+type EmailChangeStatus string
+
+const (
+	EMAIL_CHANGE_STATUS_PENDING   EmailChangeStatus = "PENDING"
+	EMAIL_CHANGE_STATUS_CONFIRMED EmailChangeStatus = "CONFIRMED"
+	EMAIL_CHANGE_STATUS_CANCELLED EmailChangeStatus = "CANCELLED"
+	// EMAIL_CHANGE_STATUS_REVERTED confirmed change undone from the old address
+	EMAIL_CHANGE_STATUS_REVERTED EmailChangeStatus = "REVERTED"
+)
+
+var mapEmailChangeStatus = map[EmailChangeStatus]string{
+	EMAIL_CHANGE_STATUS_PENDING:   "PENDING",
+	EMAIL_CHANGE_STATUS_CONFIRMED: "CONFIRMED",
+	EMAIL_CHANGE_STATUS_CANCELLED: "CANCELLED",
+	EMAIL_CHANGE_STATUS_REVERTED:  "REVERTED",
+}
+
+func (s EmailChangeStatus) String() string {
+	item, ok := mapEmailChangeStatus[s]
+	if ok {
+		return item
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,75 @@
+package constant
+
+import "strings"
+
+type Gender string
+
+// gender catalogue, a new gender only needs to be listed on Genders
+const (
+	GENDER_MALE       Gender = "Male"
+	GENDER_FEMALE     Gender = "Female"
+	GENDER_NON_BINARY Gender = "Non-binary"
+	// GENDER_OTHER may be described by the user with a custom label
+	GENDER_OTHER Gender = "Other"
+)
+
+var Genders = []Gender{
+	GENDER_MALE,
+	GENDER_FEMALE,
+	GENDER_NON_BINARY,
+	GENDER_OTHER,
+}
+
+func (g Gender) String() string {
+	return string(g)
+}
+
+// ParseGender match the catalogue case insensitive
+func ParseGender(value string) (Gender, bool) {
+	for _, gender := range Genders {
+		if strings.EqualFold(gender.String(), strings.TrimSpace(value)) {
+			return gender, true
+		}
+	}
+
+	return "", false
+}
+
+type Orientation string
+
+const (
+	ORIENTATION_STRAIGHT    Orientation = "Straight"
+	ORIENTATION_GAY         Orientation = "Gay"
+	ORIENTATION_LESBIAN     Orientation = "Lesbian"
+	ORIENTATION_BISEXUAL    Orientation = "Bisexual"
+	ORIENTATION_PANSEXUAL   Orientation = "Pansexual"
+	ORIENTATION_ASEXUAL     Orientation = "Asexual"
+	ORIENTATION_QUEER       Orientation = "Queer"
+	ORIENTATION_QUESTIONING Orientation = "Questioning"
+)
+
+var Orientations = []Orientation{
+	ORIENTATION_STRAIGHT,
+	ORIENTATION_GAY,
+	ORIENTATION_LESBIAN,
+	ORIENTATION_BISEXUAL,
+	ORIENTATION_PANSEXUAL,
+	ORIENTATION_ASEXUAL,
+	ORIENTATION_QUEER,
+	ORIENTATION_QUESTIONING,
+}
+
+func (o Orientation) String() string {
+	return string(o)
+}
+
+// ParseOrientation match the catalogue case insensitive
+func ParseOrientation(value string) (Orientation, bool) {
+	for _, orientation := range Orientations {
+		if strings.EqualFold(orientation.String(), strings.TrimSpace(value)) {
+			return orientation, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,35 @@
+package constant
+
+type OnboardingStep string
+
+const (
+	ONBOARDING_STEP_PERSONAL_INFO      OnboardingStep = "personal-info"
+	ONBOARDING_STEP_PHOTOS             OnboardingStep = "photos"
+	ONBOARDING_STEP_HOBBY_AND_INTEREST OnboardingStep = "hobby-and-interest"
+	ONBOARDING_STEP_LOCATION           OnboardingStep = "location"
+)
+
+func (s OnboardingStep) String() string {
+	return string(s)
+}
+
+type OnboardingStepStatus string
+
+const (
+	ONBOARDING_STEP_STATUS_PENDING OnboardingStepStatus = "pending"
+	ONBOARDING_STEP_STATUS_DONE    OnboardingStepStatus = "done"
+)
+
+var mapOnboardingStepStatus = map[OnboardingStepStatus]string{
+	ONBOARDING_STEP_STATUS_PENDING: "pending",
+	ONBOARDING_STEP_STATUS_DONE:    "done",
+}
+
+func (s OnboardingStepStatus) String() string {
+	item, ok := mapOnboardingStepStatus[s]
+	if ok {
+		return item
+	}
+
+	return "unknown"
+}
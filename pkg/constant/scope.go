@@ -0,0 +1,24 @@
+package constant
+
+type Scope string
+
+const (
+	SCOPE_USER    Scope = "user"
+	SCOPE_PREMIUM Scope = "premium"
+	SCOPE_ADMIN   Scope = "admin"
+)
+
+var mapScope = map[Scope]string{
+	SCOPE_USER:    "user",
+	SCOPE_PREMIUM: "premium",
+	SCOPE_ADMIN:   "admin",
+}
+
+func (s Scope) String() string {
+	item, ok := mapScope[s]
+	if ok {
+		return item
+	}
+
+	return "unknown"
+}
@@ -6,12 +6,16 @@ const (
 	USER_STATUS_UNVERIFIED UserStatus = "UNVERIFIED"
 	USER_STATUS_ACTIVE     UserStatus = "ACTIVE"
 	USER_STATUS_DEACTIVE   UserStatus = "DEACTIVE"
+	// USER_STATUS_TEMPORARY_BLOCKED too many failed login, see login_failures
+	USER_STATUS_TEMPORARY_BLOCKED UserStatus = "TEMPORARY_BLOCKED"
 )
 
 var mapStatus = map[UserStatus]string{
 	USER_STATUS_UNVERIFIED: "UNVERIFIED",
 	USER_STATUS_ACTIVE:     "ACTIVE",
 	USER_STATUS_DEACTIVE:   "DEACTIVE",
+
+	USER_STATUS_TEMPORARY_BLOCKED: "TEMPORARY_BLOCKED",
 }
 
 func (s UserStatus) String() string {
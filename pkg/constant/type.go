@@ -7,6 +7,12 @@ const (
 	OTP_TYPE_SMS        OneTimePasswordType = "SMS"
 	OTP_TYPE_PHONE_CALL OneTimePasswordType = "PHONE_CALL"
 	OTP_TYPE_WHATSAPP   OneTimePasswordType = "WHATSAPP"
+	OTP_TYPE_MAGIC_LINK OneTimePasswordType = "MAGIC_LINK"
+	// OTP_TYPE_NEW_EMAIL confirm change of email, never used for login
+	OTP_TYPE_NEW_EMAIL OneTimePasswordType = "NEW_EMAIL"
+	// OTP_TYPE_NEW_PHONE confirm phone attached to the account, sent by sms
+	// but never used for login
+	OTP_TYPE_NEW_PHONE OneTimePasswordType = "NEW_PHONE"
 )
 
 var mapOtpType = map[OneTimePasswordType]string{
@@ -14,6 +20,9 @@ var mapOtpType = map[OneTimePasswordType]string{
 	OTP_TYPE_SMS:        "SMS",
 	OTP_TYPE_PHONE_CALL: "PHONE_CALL",
 	OTP_TYPE_WHATSAPP:   "WHATSAPP",
+	OTP_TYPE_MAGIC_LINK: "MAGIC_LINK",
+	OTP_TYPE_NEW_EMAIL:  "NEW_EMAIL",
+	OTP_TYPE_NEW_PHONE:  "NEW_PHONE",
 }
 
 func (o OneTimePasswordType) String() string {
@@ -25,6 +34,22 @@ func (o OneTimePasswordType) String() string {
 	return "unknown"
 }
 
+var OtpTypeString = map[string]OneTimePasswordType{
+	"EMAIL":      OTP_TYPE_EMAIL,
+	"SMS":        OTP_TYPE_SMS,
+	"PHONE_CALL": OTP_TYPE_PHONE_CALL,
+	"WHATSAPP":   OTP_TYPE_WHATSAPP,
+}
+
+func GetOtpType(key string) OneTimePasswordType {
+	item, ok := OtpTypeString[key]
+	if ok {
+		return item
+	}
+
+	return "unknown"
+}
+
 type OneTimeLogStatus string
 
 const (
@@ -0,0 +1,36 @@
+package constant
+
+type VerificationStatus string
+
+const (
+	// VERIFICATION_STATUS_PENDING waiting for a manual review
+	VERIFICATION_STATUS_PENDING  VerificationStatus = "PENDING"
+	VERIFICATION_STATUS_APPROVED VerificationStatus = "APPROVED"
+	VERIFICATION_STATUS_REJECTED VerificationStatus = "REJECTED"
+)
+
+var mapVerificationStatus = map[VerificationStatus]string{
+	VERIFICATION_STATUS_PENDING:  "PENDING",
+	VERIFICATION_STATUS_APPROVED: "APPROVED",
+	VERIFICATION_STATUS_REJECTED: "REJECTED",
+}
+
+func (s VerificationStatus) String() string {
+	item, ok := mapVerificationStatus[s]
+	if ok {
+		return item
+	}
+
+	return "unknown"
+}
+
+// VerificationPoses pose the user is asked to do on the selfie, one is picked
+// at random for every verification so a photo taken beforehand can not be used
+var VerificationPoses = []string{
+	"thumbs_up",
+	"peace_sign",
+	"hand_on_chin",
+	"wave",
+	"touch_nose",
+	"cover_one_eye",
+}
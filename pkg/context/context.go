@@ -13,6 +13,9 @@ const (
 	PHONE
 	EMAIL
 	STATUS
+	SESSION_ID
+	SCOPES
+	PREMIUM
 )
 
 func SetContext(ctx context.Context, list map[ContextMetadata]any) context.Context {
@@ -22,3 +25,60 @@ func SetContext(ctx context.Context, list map[ContextMetadata]any) context.Conte
 
 	return ctx
 }
+
+func getString(ctx context.Context, key ContextMetadata) string {
+	val, ok := ctx.Value(key).(string)
+	if !ok {
+		return ""
+	}
+
+	return val
+}
+
+func GetUserID(ctx context.Context) string {
+	return getString(ctx, USER_ID)
+}
+
+func GetProfileID(ctx context.Context) string {
+	return getString(ctx, PROFILE_ID)
+}
+
+func GetPhone(ctx context.Context) string {
+	return getString(ctx, PHONE)
+}
+
+func GetEmail(ctx context.Context) string {
+	return getString(ctx, EMAIL)
+}
+
+func GetSessionID(ctx context.Context) string {
+	return getString(ctx, SESSION_ID)
+}
+
+func GetScopes(ctx context.Context) []string {
+	val, ok := ctx.Value(SCOPES).([]string)
+	if !ok {
+		return nil
+	}
+
+	return val
+}
+
+func HasScope(ctx context.Context, scope string) bool {
+	for _, s := range GetScopes(ctx) {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func IsPremium(ctx context.Context) bool {
+	val, ok := ctx.Value(PREMIUM).(bool)
+	if !ok {
+		return false
+	}
+
+	return val
+}
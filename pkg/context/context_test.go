@@ -19,3 +19,29 @@ func TestSetContextMetadata(t *testing.T) {
 	assert.Equal(t, ctxVal.Value(USER_ID), "1")
 	assert.Equal(t, ctxVal.Value(AUTH), "token")
 }
+
+func TestTypedAccessor(t *testing.T) {
+	var data = map[ContextMetadata]any{
+		USER_ID:    "1",
+		PROFILE_ID: "2",
+		EMAIL:      "test@example.com",
+		SESSION_ID: "session",
+		SCOPES:     []string{"user", "premium"},
+		PREMIUM:    true,
+	}
+
+	ctx := SetContext(context.Background(), data)
+	assert.Equal(t, "1", GetUserID(ctx))
+	assert.Equal(t, "2", GetProfileID(ctx))
+	assert.Equal(t, "test@example.com", GetEmail(ctx))
+	assert.Equal(t, "session", GetSessionID(ctx))
+	assert.Equal(t, "", GetPhone(ctx))
+	assert.Equal(t, []string{"user", "premium"}, GetScopes(ctx))
+	assert.True(t, HasScope(ctx, "premium"))
+	assert.False(t, HasScope(ctx, "admin"))
+	assert.True(t, IsPremium(ctx))
+
+	empty := context.Background()
+	assert.Equal(t, "", GetUserID(empty))
+	assert.False(t, IsPremium(empty))
+}
@@ -46,6 +46,8 @@ const (
 	ErrTokenAlreadyUsed
 	ErrMaxUserReached
 	ErrAccessLimited
+	ErrOnboardingIncomplete
+	ErrPreconditionFailed
 )
 
 var mapCode = map[ErrCode]string{
@@ -63,6 +65,8 @@ var mapCode = map[ErrCode]string{
 	ErrTokenAlreadyUsed:     "11",
 	ErrMaxUserReached:       "12",
 	ErrAccessLimited:        "13",
+	ErrOnboardingIncomplete: "14",
+	ErrPreconditionFailed:   "15",
 }
 
 var mapHttpStatus = map[ErrCode]int{
@@ -73,13 +77,15 @@ var mapHttpStatus = map[ErrCode]int{
 	ErrAlreadyRegistered:    http.StatusConflict,
 	ErrEmptyPassword:        http.StatusUnprocessableEntity,
 	ErrFailedToSendDeeplink: http.StatusInternalServerError,
-	ErrTemporaryBlocked:     http.StatusUnprocessableEntity,
+	ErrTemporaryBlocked:     http.StatusTooManyRequests,
 	ErrUnauthorize:          http.StatusUnauthorized,
 	ErrInvalidPassword:      http.StatusBadRequest,
 	ErrReloginNeeded:        http.StatusUnauthorized,
 	ErrTokenAlreadyUsed:     http.StatusUnprocessableEntity,
 	ErrMaxUserReached:       http.StatusUnprocessableEntity,
 	ErrAccessLimited:        http.StatusForbidden,
+	ErrOnboardingIncomplete: http.StatusForbidden,
+	ErrPreconditionFailed:   http.StatusPreconditionFailed,
 }
 
 var mapText = map[ErrCode]string{
@@ -97,4 +103,6 @@ var mapText = map[ErrCode]string{
 	ErrTokenAlreadyUsed:     "Token Already Use",
 	ErrMaxUserReached:       "Maximum 5 Users",
 	ErrAccessLimited:        "Access limited",
+	ErrOnboardingIncomplete: "Onboarding Incomplete",
+	ErrPreconditionFailed:   "Resource Was Modified, Please Reload",
 }
@@ -0,0 +1,25 @@
+package facematch
+
+import (
+	"context"
+)
+
+// Request selfie taken by the user while doing the requested pose, compared
+// to the photos of their profile
+type Request struct {
+	Selfie []byte
+	Pose   string
+	Photos [][]byte
+}
+
+// Result Similarity is the best match between the selfie and the photos, from
+// 0 (different person) to 1 (same person). PoseMatched tell the selfie show
+// the requested pose, a selfie made ahead of time can not
+type Result struct {
+	Similarity  float64
+	PoseMatched bool
+}
+
+type FaceMatcher interface {
+	Match(ctx context.Context, req Request) (*Result, error)
+}
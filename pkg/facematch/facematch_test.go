@@ -0,0 +1,71 @@
+package facematch
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeMatcher(t *testing.T) {
+	matcher := NewFakeMatcher(Result{Similarity: 0.95, PoseMatched: true})
+	ctx := context.Background()
+
+	result, err := matcher.Match(ctx, Request{Selfie: []byte("selfie"), Pose: "thumbs_up"})
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{Similarity: 0.95, PoseMatched: true}, result)
+
+	matcher.SetResult(Result{}, errors.New("provider is down"))
+	_, err = matcher.Match(ctx, Request{Selfie: []byte("selfie"), Pose: "wave"})
+	assert.EqualError(t, err, "provider is down")
+
+	assert.Len(t, matcher.Requests(), 2)
+	assert.Equal(t, "wave", matcher.Requests()[1].Pose)
+}
+
+func TestHttpMatcher(t *testing.T) {
+	var received httpRequest
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"similarity":0.87,"pose_matched":true}`))
+	}))
+	defer server.Close()
+
+	matcher := NewHttpMatcher(server.URL, "secret")
+	result, err := matcher.Match(context.Background(), Request{
+		Selfie: []byte("selfie"),
+		Pose:   "thumbs_up",
+		Photos: [][]byte{[]byte("photo1"), []byte("photo2")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &Result{Similarity: 0.87, PoseMatched: true}, result)
+	assert.Equal(t, "Bearer secret", authorization)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("selfie")), received.Selfie)
+	assert.Equal(t, "thumbs_up", received.Pose)
+	assert.Len(t, received.Photos, 2)
+}
+
+func TestHttpMatcherFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/invalid" {
+			_, _ = w.Write([]byte(`{"similarity":7}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("no face found"))
+	}))
+	defer server.Close()
+
+	_, err := NewHttpMatcher(server.URL, "").Match(context.Background(), Request{Selfie: []byte("selfie")})
+	assert.EqualError(t, err, "face match provider responded with status 502: no face found")
+
+	_, err = NewHttpMatcher(server.URL+"/invalid", "").Match(context.Background(), Request{Selfie: []byte("selfie")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,53 @@
+package facematch
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeMatcher answer the same result to every request and keep the requests,
+// used for testing
+type FakeMatcher struct {
+	mu       sync.Mutex
+	result   Result
+	err      error
+	requests []Request
+}
+
+func NewFakeMatcher(result Result) *FakeMatcher {
+	return &FakeMatcher{
+		result: result,
+	}
+}
+
+// SetResult answer of the next requests, a non nil err fail them
+func (f *FakeMatcher) SetResult(result Result, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.result = result
+	f.err = err
+}
+
+func (f *FakeMatcher) Match(ctx context.Context, req Request) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, req)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	result := f.result
+	return &result, nil
+}
+
+func (f *FakeMatcher) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	requests := make([]Request, len(f.requests))
+	copy(requests, f.requests)
+
+	return requests
+}
@@ -0,0 +1,89 @@
+package facematch
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// httpMatcher face comparison provider behind a json api, images are sent
+// base64 encoded
+type httpMatcher struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+type httpRequest struct {
+	Selfie string   `json:"selfie"`
+	Pose   string   `json:"pose"`
+	Photos []string `json:"photos"`
+}
+
+type httpResponse struct {
+	Similarity  float64 `json:"similarity"`
+	PoseMatched bool    `json:"pose_matched"`
+}
+
+func NewHttpMatcher(url, token string) FaceMatcher {
+	return &httpMatcher{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		url:   url,
+		token: token,
+	}
+}
+
+func (h *httpMatcher) Match(ctx context.Context, req Request) (*Result, error) {
+	body := httpRequest{
+		Selfie: base64.StdEncoding.EncodeToString(req.Selfie),
+		Pose:   req.Pose,
+	}
+	for _, photo := range req.Photos {
+		body.Photos = append(body.Photos, base64.StdEncoding.EncodeToString(photo))
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if h.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("face match provider responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+
+	var data httpResponse
+	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	if data.Similarity < 0 || data.Similarity > 1 {
+		return nil, fmt.Errorf("face match provider returned similarity out of range: %v", data.Similarity)
+	}
+
+	return &Result{
+		Similarity:  data.Similarity,
+		PoseMatched: data.PoseMatched,
+	}, nil
+}
@@ -0,0 +1,183 @@
+package geohash
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+	// MaxPrecision cells of 12 characters are a few centimeters wide
+	MaxPrecision = 12
+
+	earthRadiusKm = 6371.0
+	kmPerDegree   = math.Pi * earthRadiusKm / 180
+)
+
+// Box bounds of a geohash cell in degrees
+type Box struct {
+	MinLat float64
+	MaxLat float64
+	MinLon float64
+	MaxLon float64
+}
+
+func (b Box) center() (float64, float64) {
+	return (b.MinLat + b.MaxLat) / 2, (b.MinLon + b.MaxLon) / 2
+}
+
+// Encode geohash of the coordinate, precision is clamped to 1..MaxPrecision
+func Encode(lat, lon float64, precision int) string {
+	if precision < 1 {
+		precision = 1
+	}
+	if precision > MaxPrecision {
+		precision = MaxPrecision
+	}
+
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	var sb strings.Builder
+	even := true
+	bit, ch := 0, 0
+	for sb.Len() < precision {
+		if even {
+			mid := (minLon + maxLon) / 2
+			if lon >= mid {
+				ch |= 1 << (4 - bit)
+				minLon = mid
+			} else {
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch |= 1 << (4 - bit)
+				minLat = mid
+			} else {
+				maxLat = mid
+			}
+		}
+		even = !even
+		if bit < 4 {
+			bit++
+			continue
+		}
+		sb.WriteByte(base32[ch])
+		bit, ch = 0, 0
+	}
+
+	return sb.String()
+}
+
+// Decode bounds of the cell, false when hash has a character outside the
+// geohash alphabet
+func Decode(hash string) (Box, bool) {
+	box := Box{MinLat: -90, MaxLat: 90, MinLon: -180, MaxLon: 180}
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		idx := strings.IndexRune(base32, c)
+		if idx < 0 {
+			return Box{}, false
+		}
+		for bit := 4; bit >= 0; bit-- {
+			set := idx&(1<<bit) != 0
+			if even {
+				mid := (box.MinLon + box.MaxLon) / 2
+				if set {
+					box.MinLon = mid
+				} else {
+					box.MaxLon = mid
+				}
+			} else {
+				mid := (box.MinLat + box.MaxLat) / 2
+				if set {
+					box.MinLat = mid
+				} else {
+					box.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+
+	return box, true
+}
+
+// Neighbors the cells around hash with the same precision, longitude wraps
+// around the antimeridian and cells past the poles are left out
+func Neighbors(hash string) []string {
+	box, ok := Decode(hash)
+	if !ok || hash == "" {
+		return nil
+	}
+	lat, lon := box.center()
+	height := box.MaxLat - box.MinLat
+	width := box.MaxLon - box.MinLon
+
+	var result []string
+	for _, dLat := range []float64{-1, 0, 1} {
+		for _, dLon := range []float64{-1, 0, 1} {
+			if dLat == 0 && dLon == 0 {
+				continue
+			}
+			nLat := lat + dLat*height
+			if nLat < -90 || nLat > 90 {
+				continue
+			}
+			nLon := lon + dLon*width
+			if nLon >= 180 {
+				nLon -= 360
+			}
+			if nLon < -180 {
+				nLon += 360
+			}
+			if neighbor := Encode(nLat, nLon, len(hash)); neighbor != hash {
+				result = appendUnique(result, neighbor)
+			}
+		}
+	}
+
+	return result
+}
+
+// Cover cells which together contain every point within radiusKm of the
+// coordinate: the cell of the coordinate and its neighbors, at the finest
+// precision whose cells are still larger than the radius. Nil when the radius
+// is too large for a geohash to narrow anything down
+func Cover(lat, lon, radiusKm float64) []string {
+	if radiusKm <= 0 {
+		return nil
+	}
+
+	precision := 0
+	for p := 1; p <= MaxPrecision; p++ {
+		box, _ := Decode(Encode(lat, lon, p))
+		heightKm := (box.MaxLat - box.MinLat) * kmPerDegree
+		// narrowest width of the block, on the neighbor row closest to a pole
+		edgeLat := math.Min(90, math.Max(math.Abs(box.MinLat), math.Abs(box.MaxLat))+(box.MaxLat-box.MinLat))
+		widthKm := (box.MaxLon - box.MinLon) * kmPerDegree * math.Cos(edgeLat*math.Pi/180)
+		if heightKm < radiusKm {
+			break
+		}
+		if widthKm >= radiusKm {
+			precision = p
+		}
+	}
+	if precision == 0 {
+		return nil
+	}
+
+	hash := Encode(lat, lon, precision)
+	return append([]string{hash}, Neighbors(hash)...)
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, item := range list {
+		if item == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,64 @@
+package geohash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, "u4pruydqqvj", Encode(57.64911, 10.40744, 11))
+	assert.Equal(t, "ezs42", Encode(42.605, -5.603, 5))
+	assert.Equal(t, "s", Encode(0, 0, 0))
+	assert.Len(t, Encode(0, 0, 20), MaxPrecision)
+}
+
+func TestDecode(t *testing.T) {
+	box, ok := Decode("u4pruydqqvj")
+	assert.True(t, ok)
+	assert.InDelta(t, 57.64911, box.MinLat, 0.0001)
+	assert.InDelta(t, 10.40744, box.MinLon, 0.0001)
+	assert.True(t, box.MinLat <= 57.64911 && 57.64911 <= box.MaxLat)
+	assert.True(t, box.MinLon <= 10.40744 && 10.40744 <= box.MaxLon)
+
+	_, ok = Decode("abc")
+	assert.False(t, ok)
+}
+
+func TestNeighbors(t *testing.T) {
+	assert.ElementsMatch(t, []string{
+		"u4pruydqqvm", "u4pruydqqvq", "u4pruydqqvn", "u4pruydqqvk",
+		"u4pruydqqvh", "u4pruydqquu", "u4pruydqquv", "u4pruydqquy",
+	}, Neighbors("u4pruydqqvj"))
+
+	// wraps around the antimeridian
+	neighbors := Neighbors(Encode(0, 179.99, 4))
+	assert.Len(t, neighbors, 8)
+	assert.Contains(t, neighbors, Encode(0, -179.99, 4))
+
+	// nothing past the pole
+	assert.Len(t, Neighbors(Encode(89.99, 0, 4)), 5)
+	assert.Nil(t, Neighbors("abc"))
+}
+
+func TestCover(t *testing.T) {
+	cells := Cover(-6.2088, 106.8456, 10)
+	assert.Len(t, cells, 9)
+	assert.Equal(t, Encode(-6.2088, 106.8456, len(cells[0])), cells[0])
+	for _, cell := range cells {
+		assert.Len(t, cell, 4)
+	}
+
+	// a point 9 km away is inside one of the cells
+	inside := false
+	for _, cell := range cells {
+		if Encode(-6.2088+0.081, 106.8456, len(cell)) == cell {
+			inside = true
+		}
+	}
+	assert.True(t, inside)
+
+	assert.Len(t, Cover(-6.2088, 106.8456, 1)[0], 5)
+	assert.Nil(t, Cover(-6.2088, 106.8456, 0))
+	assert.Nil(t, Cover(-6.2088, 106.8456, 20000))
+}
@@ -0,0 +1,47 @@
+package hash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+type Hasher interface {
+	Hash(value string) (string, error)
+	Compare(value, hashed string) bool
+}
+
+type hmacHasher struct {
+	pepper func() string
+}
+
+// NewHmacHasher keyed hash using HMAC-SHA256, pepper is read on every call so
+// pepper reloaded from vault is picked up
+func NewHmacHasher(pepper func() string) Hasher {
+	return &hmacHasher{
+		pepper: pepper,
+	}
+}
+
+func (h *hmacHasher) Hash(value string) (string, error) {
+	pepper := h.pepper()
+	if pepper == "" {
+		return "", errors.New("missing hash pepper")
+	}
+
+	mac := hmac.New(sha256.New, []byte(pepper))
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Compare hash the value and compare it with hashed value in constant time
+func (h *hmacHasher) Compare(value, hashed string) bool {
+	expected, err := h.Hash(value)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal([]byte(expected), []byte(hashed))
+}
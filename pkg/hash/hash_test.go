@@ -0,0 +1,34 @@
+package hash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHmacHasher(t *testing.T) {
+	hasher := NewHmacHasher(func() string { return "pepper" })
+
+	hashed, err := hasher.Hash("123456")
+	assert.NoError(t, err)
+	assert.Len(t, hashed, 64)
+	assert.NotContains(t, hashed, "123456")
+
+	same, err := hasher.Hash("123456")
+	assert.NoError(t, err)
+	assert.Equal(t, hashed, same)
+
+	assert.True(t, hasher.Compare("123456", hashed))
+	assert.False(t, hasher.Compare("654321", hashed))
+
+	other := NewHmacHasher(func() string { return "other" })
+	assert.False(t, other.Compare("123456", hashed))
+}
+
+func TestHmacHasherMissingPepper(t *testing.T) {
+	hasher := NewHmacHasher(func() string { return "" })
+
+	_, err := hasher.Hash("123456")
+	assert.Error(t, err)
+	assert.False(t, hasher.Compare("123456", ""))
+}
@@ -2,14 +2,16 @@ package middleware
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"github.com/ijlik/dating-user/pkg/constant"
 	ctxsdk "github.com/ijlik/dating-user/pkg/context"
 	"github.com/ijlik/dating-user/pkg/jwt"
+	"github.com/ijlik/dating-user/pkg/session"
 )
 
 type DatabaseData struct {
@@ -29,8 +31,8 @@ const (
 )
 
 func WithLoginAndRedis(
-	pubKey string,
-	redis redis.Cmdable,
+	keySet jwt.KeySet,
+	rdb redis.Cmdable,
 ) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		auth := ctx.GetHeader(authorization)
@@ -40,35 +42,61 @@ func WithLoginAndRedis(
 			return
 		}
 
-		resp, err := jwt.ValidateToken(ArrAuth[1], pubKey)
+		claims, err := keySet.ValidateToken(ArrAuth[1])
 		if err != nil {
 			UnauthorizedResponse(ctx, err.Error())
 			return
 		}
 
-		data, err := json.Marshal(resp)
+		// append claims to context value
+		cmd := ctxsdk.SetContext(ctx.Request.Context(), map[ctxsdk.ContextMetadata]any{
+			ctxsdk.AUTH:       ArrAuth[1],
+			ctxsdk.USER_ID:    claims.UserID,
+			ctxsdk.PROFILE_ID: claims.ProfileID,
+			ctxsdk.PHONE:      claims.Phone,
+			ctxsdk.EMAIL:      claims.Email,
+			ctxsdk.SESSION_ID: claims.SessionID,
+			ctxsdk.SCOPES:     claims.Scopes,
+			ctxsdk.PREMIUM:    claims.Premium,
+		})
+		ctx.Request = ctx.Request.WithContext(cmd)
+
+		// validate session is still active on the registry
+		key := session.Key(claims.SessionID)
+		value, err := rdb.Get(ctx.Request.Context(), key).Result()
 		if err != nil {
-			UnauthorizedResponse(ctx, err.Error())
+			UnauthorizedResponse(ctx, "User already logged out, please login")
 			return
 		}
 
-		var md map[ctxsdk.ContextMetadata]any
-		err = json.Unmarshal([]byte(data), &md)
-		if err != nil {
-			UnauthorizedResponse(ctx, err.Error())
+		sess, err := session.Decode(value)
+		if err != nil || sess.UserID != claims.UserID {
+			UnauthorizedResponse(ctx, "User already logged out, please login")
 			return
 		}
 
-		// append medata token to context value
-		cmd := ctxsdk.SetContext(ctx.Request.Context(), md)
-		ctx.Request = ctx.Request.WithContext(cmd)
+		// update last seen, keep the session expiration as is
+		now := time.Now().UTC()
+		if sess.NeedTouch(now) {
+			sess.LastSeenAt = now
+			if value, err = sess.Encode(); err == nil {
+				rdb.Set(ctx.Request.Context(), key, value, redis.KeepTTL)
+			}
+		}
 
-		// validate user already logout or login
-		UserID := ctx.Request.Context().Value(ctxsdk.USER_ID)
-		_, err = redis.Get(ctx.Request.Context(), fmt.Sprintf("%s", UserID)).Result()
-		if err != nil {
-			UnauthorizedResponse(ctx, "User already logged out, please login")
-			return
+		ctx.Next()
+	}
+}
+
+// WithScope guard route to token having every given scope, must be used after
+// WithLoginAndRedis
+func WithScope(scopes ...constant.Scope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, scope := range scopes {
+			if !ctxsdk.HasScope(ctx.Request.Context(), scope.String()) {
+				ForbiddenResponse(ctx, fmt.Sprintf("Missing %s scope", scope))
+				return
+			}
 		}
 
 		ctx.Next()
@@ -81,7 +109,7 @@ func WithAllowedCORS() gin.HandlerFunc {
 			c.Header("Access-Control-Allow-Origin", origin)
 			c.Header("Access-Control-Allow-Credentials", "true")
 			c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Menu-Slug, X-Origin-Path, X-Request-Id")
-			c.Header("Access-Control-Allow-Methods", "POST, HEAD, PATCH, OPTIONS, GET, PUT")
+			c.Header("Access-Control-Allow-Methods", "POST, HEAD, PATCH, OPTIONS, GET, PUT, DELETE")
 
 			if c.Request.Method == "OPTIONS" {
 				c.AbortWithStatus(204)
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ijlik/dating-user/pkg/constant"
+	ctxsdk "github.com/ijlik/dating-user/pkg/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	withScopes := func(scopes ...string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			ctx := ctxsdk.SetContext(c.Request.Context(), map[ctxsdk.ContextMetadata]any{
+				ctxsdk.SCOPES: scopes,
+			})
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+		}
+	}
+
+	router := gin.New()
+	router.GET("/user", withScopes("user"), WithScope(constant.SCOPE_ADMIN), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/admin", withScopes("user", "admin"), WithScope(constant.SCOPE_ADMIN), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestWithAllowedCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(WithAllowedCORS())
+	router.DELETE("/auth/sessions/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// preflight of delete route
+	req := httptest.NewRequest(http.MethodOptions, "/auth/sessions/session_id_1", nil)
+	req.Header.Set("Origin", "https://dating.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://dating.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), http.MethodDelete)
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	ctxsdk "github.com/ijlik/dating-user/pkg/context"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
+)
+
+// OnboardingCheck return required onboarding steps the user has not done
+// yet, empty when onboarding is completed
+type OnboardingCheck func(ctx context.Context, UserID string) ([]string, error)
+
+// RouteKey identify a route on the allow-list, e.g. "GET /feeds"
+func RouteKey(method, path string) string {
+	return method + " " + path
+}
+
+// WithOnboardingCompleted guard route to user who completed every required
+// onboarding step, routes on allowed (see RouteKey) stay usable
+// mid-onboarding. Must be used after WithLoginAndRedis
+func WithOnboardingCompleted(check OnboardingCheck, allowed ...string) gin.HandlerFunc {
+	allowList := make(map[string]bool, len(allowed))
+	for _, route := range allowed {
+		allowList[route] = true
+	}
+
+	return func(ctx *gin.Context) {
+		if allowList[RouteKey(ctx.Request.Method, ctx.FullPath())] {
+			ctx.Next()
+			return
+		}
+
+		UserID := ctxsdk.GetUserID(ctx.Request.Context())
+		if UserID == "" {
+			UnauthorizedResponse(ctx, "Missing user")
+			return
+		}
+
+		missing, err := check(ctx.Request.Context(), UserID)
+		if err != nil {
+			log.Println("onboarding check: ", err)
+			ctx.JSON(http.StatusInternalServerError, DefaultResponse{
+				Code:    errpkg.GetCode(errpkg.ErrInternal),
+				Message: errpkg.GetMessage(errpkg.ErrInternal),
+			})
+			ctx.Abort()
+			return
+		}
+
+		if len(missing) > 0 {
+			OnboardingIncompleteResponse(ctx, missing)
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	ctxsdk "github.com/ijlik/dating-user/pkg/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithOnboardingCompleted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	withUser := func(c *gin.Context) {
+		ctx := ctxsdk.SetContext(c.Request.Context(), map[ctxsdk.ContextMetadata]any{
+			ctxsdk.USER_ID: c.GetHeader("X-User"),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+	check := func(ctx context.Context, UserID string) ([]string, error) {
+		switch UserID {
+		case "done":
+			return nil, nil
+		case "broken":
+			return nil, errors.New("connection reset")
+		default:
+			return []string{"photos", "location"}, nil
+		}
+	}
+
+	router := gin.New()
+	group := router.Group("/feeds").Use(withUser, WithOnboardingCompleted(check, RouteKey(http.MethodGet, "/feeds/preferences")))
+	group.GET("", func(c *gin.Context) { c.Status(http.StatusOK) })
+	group.GET("/preferences", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(path, UserID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-User", UserID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/feeds", "pending")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var body struct {
+		Code string `json:"code"`
+		Data struct {
+			MissingSteps []string `json:"missing_steps"`
+		} `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "14", body.Code)
+	assert.Equal(t, []string{"photos", "location"}, body.Data.MissingSteps)
+
+	// allow-listed route is usable mid-onboarding
+	assert.Equal(t, http.StatusOK, serve("/feeds/preferences", "pending").Code)
+
+	assert.Equal(t, http.StatusOK, serve("/feeds", "done").Code)
+	assert.Equal(t, http.StatusInternalServerError, serve("/feeds", "broken").Code)
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	ctxsdk "github.com/ijlik/dating-user/pkg/context"
+)
+
+// Limiter count hit of a key inside a sliding window, retryAfter is filled
+// when the hit is not allowed
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// slidingWindowScript keep one sorted set member per hit scored by its time
+// in millisecond. Script is executed atomically by redis so concurrent request
+// on different instance can not go over the limit, no lock is needed
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+
+local count = redis.call('ZCARD', key)
+if count >= limit then
+	local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+	return {0, tonumber(oldest[2]) + window - now}
+end
+
+redis.call('ZADD', key, now, ARGV[4])
+redis.call('PEXPIRE', key, window)
+
+return {1, 0}
+`)
+
+type redisLimiter struct {
+	rdb redis.Cmdable
+}
+
+func NewRedisLimiter(rdb redis.Cmdable) Limiter {
+	return &redisLimiter{rdb}
+}
+
+func (l *redisLimiter) Allow(
+	ctx context.Context,
+	key string,
+	limit int,
+	window time.Duration,
+) (bool, time.Duration, error) {
+	now := time.Now()
+	// member must be unique, two hit on the same millisecond is counted twice
+	member := strconv.FormatInt(now.UnixNano(), 10)
+
+	result, err := slidingWindowScript.Run(
+		ctx,
+		l.rdb,
+		[]string{key},
+		now.UnixMilli(),
+		window.Milliseconds(),
+		limit,
+		member,
+	).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit result: %v", result)
+	}
+
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
+
+// KeyFunc return the value request is limited by, empty value skip the rule
+type KeyFunc func(ctx *gin.Context) string
+
+func KeyByIP(ctx *gin.Context) string {
+	return ctx.ClientIP()
+}
+
+// KeyByUserID must be used after WithLoginAndRedis
+func KeyByUserID(ctx *gin.Context) string {
+	return ctxsdk.GetUserID(ctx.Request.Context())
+}
+
+// maxKeyBodySize body read by KeyByBodyField, it runs before auth so the
+// whole body must not be buffered
+const maxKeyBodySize = 16 << 10
+
+// KeyByBodyField limit by field of json body (e.g. email), body is restored
+// so handler can still decode it. Body over maxKeyBodySize is cut, handler
+// will fail to decode it
+func KeyByBodyField(field string) KeyFunc {
+	return func(ctx *gin.Context) string {
+		if ctx.Request.Body == nil {
+			return ""
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxKeyBodySize))
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return ""
+		}
+
+		var data map[string]interface{}
+		if err = json.Unmarshal(body, &data); err != nil {
+			return ""
+		}
+
+		value, ok := data[field].(string)
+		if !ok {
+			return ""
+		}
+
+		return strings.ToLower(strings.TrimSpace(value))
+	}
+}
+
+type RateLimitRule struct {
+	// Name used on redis key, must be unique per route and key
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    KeyFunc
+}
+
+// ParseRate parse rate written as <limit>/<window>, e.g. 5/1m or 100/1h
+func ParseRate(value string) (int, time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid rate %q", value)
+	}
+
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit <= 0 {
+		return 0, 0, fmt.Errorf("invalid rate limit %q", value)
+	}
+
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("invalid rate window %q", value)
+	}
+
+	return limit, window, nil
+}
+
+// WithRateLimit reject request once any rule is over its limit. Limiter error
+// is logged and request is let through, auth should not go down with redis
+func WithRateLimit(limiter Limiter, rules ...RateLimitRule) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, rule := range rules {
+			value := rule.Key(ctx)
+			if value == "" {
+				continue
+			}
+
+			key := fmt.Sprintf("rate_limit:%s:%s", rule.Name, value)
+			allowed, retryAfter, err := limiter.Allow(ctx.Request.Context(), key, rule.Limit, rule.Window)
+			if err != nil {
+				log.Println("rate limit error: ", err)
+				continue
+			}
+
+			if !allowed {
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+				if seconds < 1 {
+					seconds = 1
+				}
+				TooManyRequestsResponse(ctx, seconds, "Too many requests, please try again later")
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// countLimiter fixed counter per key, good enough to test the middleware
+type countLimiter struct {
+	hits map[string]int
+	err  error
+}
+
+func (l *countLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	if l.err != nil {
+		return false, 0, l.err
+	}
+
+	if l.hits[key] >= limit {
+		return false, window, nil
+	}
+	l.hits[key]++
+
+	return true, 0, nil
+}
+
+func TestParseRate(t *testing.T) {
+	limit, window, err := ParseRate("5/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, limit)
+	assert.Equal(t, time.Minute, window)
+
+	for _, value := range []string{"", "5", "0/1m", "five/1m", "5/minute", "5/-1m"} {
+		_, _, err = ParseRate(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := &countLimiter{hits: map[string]int{}}
+	router := gin.New()
+	router.POST("/otp", WithRateLimit(
+		limiter,
+		RateLimitRule{Name: "otp_email", Limit: 2, Window: time.Minute, Key: KeyByBodyField("email")},
+	), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	send := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/otp", strings.NewReader(body)))
+		return w
+	}
+
+	// body is still readable by handler
+	w := send(`{"email":"user@mail.com"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"email":"user@mail.com"}`, w.Body.String())
+
+	// email is normalized before counting
+	w = send(`{"email":" USER@mail.com"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = send(`{"email":"user@mail.com"}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), `"code":"07"`)
+
+	// other email has its own limit
+	w = send(`{"email":"other@mail.com"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// rule without key value is skipped
+	w = send(`{"phone":"+6281234567890"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, limiter.hits, 2)
+
+	// body over the cap is not counted and handler only get the capped part
+	w = send(`{"email":"big@mail.com","padding":"` + strings.Repeat("a", maxKeyBodySize) + `"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, w.Body.String(), maxKeyBodySize)
+	assert.Len(t, limiter.hits, 2)
+}
+
+func TestWithRateLimitLimiterError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/", WithRateLimit(
+		&countLimiter{err: errors.New("connection refused")},
+		RateLimitRule{Name: "ip", Limit: 1, Window: time.Minute, Key: KeyByIP},
+	), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
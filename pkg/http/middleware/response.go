@@ -2,18 +2,22 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	errpkg "github.com/ijlik/dating-user/pkg/error"
 )
 
 type DefaultResponse struct {
-	Code     string `json:"code"`
-	Message  string `json:"message"`
-	HttpCode int    `json:"-"`
+	Code     string      `json:"code"`
+	Message  string      `json:"message"`
+	Data     interface{} `json:"data,omitempty"`
+	HttpCode int         `json:"-"`
 }
 
 const (
 	UnauthorizedCode = "01"
+	ForbiddenCode    = "02"
 )
 
 func UnauthorizedResponse(ctx *gin.Context, msg string) {
@@ -23,3 +27,33 @@ func UnauthorizedResponse(ctx *gin.Context, msg string) {
 	})
 	ctx.Abort()
 }
+
+func ForbiddenResponse(ctx *gin.Context, msg string) {
+	ctx.JSON(http.StatusForbidden, DefaultResponse{
+		Code:    ForbiddenCode,
+		Message: msg,
+	})
+	ctx.Abort()
+}
+
+func TooManyRequestsResponse(ctx *gin.Context, retryAfter int, msg string) {
+	ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+	ctx.JSON(errpkg.GetHttpStatus(errpkg.ErrTemporaryBlocked), DefaultResponse{
+		Code:    errpkg.GetCode(errpkg.ErrTemporaryBlocked),
+		Message: msg,
+	})
+	ctx.Abort()
+}
+
+// OnboardingIncompleteResponse missing steps are listed so the app can send
+// the user back to the right onboarding screen
+func OnboardingIncompleteResponse(ctx *gin.Context, missingSteps []string) {
+	ctx.JSON(errpkg.GetHttpStatus(errpkg.ErrOnboardingIncomplete), DefaultResponse{
+		Code:    errpkg.GetCode(errpkg.ErrOnboardingIncomplete),
+		Message: errpkg.GetMessage(errpkg.ErrOnboardingIncomplete),
+		Data: map[string][]string{
+			"missing_steps": missingSteps,
+		},
+	})
+	ctx.Abort()
+}
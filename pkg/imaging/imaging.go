@@ -0,0 +1,128 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// MaxPixels larger image is rejected before being decoded, a small file can
+// declare huge dimensions
+const MaxPixels = 40_000_000
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooLarge          = errors.New("image dimensions are too large")
+)
+
+// Decode content type is sniffed from the bytes, only JPEG and PNG are
+// accepted. Orientation of the JPEG EXIF is applied and transparency is
+// flattened on white, metadata is never carried to the decoded image
+func Decode(content []byte) (*image.RGBA, error) {
+	var (
+		decode       func(r *bytes.Reader) (image.Image, error)
+		decodeConfig func(r *bytes.Reader) (image.Config, error)
+	)
+	switch http.DetectContentType(content) {
+	case "image/jpeg":
+		decode = func(r *bytes.Reader) (image.Image, error) { return jpeg.Decode(r) }
+		decodeConfig = func(r *bytes.Reader) (image.Config, error) { return jpeg.DecodeConfig(r) }
+	case "image/png":
+		decode = func(r *bytes.Reader) (image.Image, error) { return png.Decode(r) }
+		decodeConfig = func(r *bytes.Reader) (image.Config, error) { return png.DecodeConfig(r) }
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	config, err := decodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, err := decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(img, img.Bounds(), src, bounds.Min, draw.Over)
+
+	return orient(img, exifOrientation(content)), nil
+}
+
+// Resize scale the image down so its longest side is at most maxDimension,
+// smaller image is returned as is
+func Resize(img *image.RGBA, maxDimension int) *image.RGBA {
+	srcWidth, srcHeight := img.Bounds().Dx(), img.Bounds().Dy()
+	if srcWidth <= maxDimension && srcHeight <= maxDimension {
+		return img
+	}
+
+	width, height := maxDimension, maxDimension
+	if srcWidth > srcHeight {
+		height = srcHeight * maxDimension / srcWidth
+	} else {
+		width = srcWidth * maxDimension / srcHeight
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	// every destination pixel is the average of the source pixels it covers
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, (y+1)*srcHeight/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, (x+1)*srcWidth/width
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, count uint32
+			for sy := y0; sy < y1; sy++ {
+				offset := img.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(img.Pix[offset])
+					g += uint32(img.Pix[offset+1])
+					b += uint32(img.Pix[offset+2])
+					a += uint32(img.Pix[offset+3])
+					count++
+					offset += 4
+				}
+			}
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r / count)
+			dst.Pix[offset+1] = uint8(g / count)
+			dst.Pix[offset+2] = uint8(b / count)
+			dst.Pix[offset+3] = uint8(a / count)
+		}
+	}
+
+	return dst
+}
+
+// EncodeJPEG output carry no metadata
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,170 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// halfImage left half red, right half blue
+func halfImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	return buf.Bytes()
+}
+
+// withEXIF insert an APP1 segment with the orientation and a fake GPS payload
+// right after SOI
+func withEXIF(content []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = append(tiff, 0x00, 0x01) // one entry
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry, exifOrientationTag)
+	binary.BigEndian.PutUint16(entry[2:], 3) // SHORT
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+	tiff = append(tiff, []byte("GPS-6.2088,106.8456")...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, content[:2]...)
+	out = append(out, segment...)
+	return append(out, content[2:]...)
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r>>8 > 200 && g>>8 < 80 && b>>8 < 80
+}
+
+func isBlue(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r>>8 < 80 && g>>8 < 80 && b>>8 > 200
+}
+
+func TestDecodeUnsupported(t *testing.T) {
+	_, err := Decode([]byte("<html><body>not an image</body></html>"))
+	assert.Equal(t, ErrUnsupportedFormat, err)
+
+	// GIF is an image but not accepted
+	_, err = Decode([]byte("GIF89a\x01\x00\x01\x00"))
+	assert.Equal(t, ErrUnsupportedFormat, err)
+
+	// sniffed as JPEG, broken afterward
+	_, err = Decode([]byte("\xFF\xD8\xFF\xE0 broken"))
+	assert.Error(t, err)
+}
+
+func TestDecodeTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))))
+	content := buf.Bytes()
+
+	// IHDR declare 100000x100000, CRC is recomputed so only the size is wrong
+	binary.BigEndian.PutUint32(content[16:], 100000)
+	binary.BigEndian.PutUint32(content[20:], 100000)
+	binary.BigEndian.PutUint32(content[29:], crc32.ChecksumIEEE(content[12:29]))
+
+	_, err := Decode(content)
+	assert.Equal(t, ErrTooLarge, err)
+}
+
+func TestDecodeFlattenTransparency(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 2, 2))))
+
+	img, err := Decode(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, img.RGBAAt(0, 0))
+}
+
+func TestDecodeOrientation(t *testing.T) {
+	content := encodeJPEG(t, halfImage(32, 16))
+
+	// as stored
+	img, err := Decode(content)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 32, 16), img.Bounds())
+
+	// rotated clockwise: left (red) half become the top
+	img, err = Decode(withEXIF(content, 6))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 16, 32), img.Bounds())
+	assert.True(t, isRed(img.At(8, 4)))
+	assert.True(t, isBlue(img.At(8, 28)))
+
+	// rotated counter clockwise: left (red) half become the bottom
+	img, err = Decode(withEXIF(content, 8))
+	assert.NoError(t, err)
+	assert.True(t, isBlue(img.At(8, 4)))
+	assert.True(t, isRed(img.At(8, 28)))
+
+	// upside down
+	img, err = Decode(withEXIF(content, 3))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 32, 16), img.Bounds())
+	assert.True(t, isBlue(img.At(4, 8)))
+	assert.True(t, isRed(img.At(28, 8)))
+}
+
+func TestEncodeStripMetadata(t *testing.T) {
+	content := withEXIF(encodeJPEG(t, halfImage(32, 16)), 6)
+	assert.True(t, bytes.Contains(content, []byte("GPS-6.2088")))
+
+	img, err := Decode(content)
+	assert.NoError(t, err)
+	out, err := EncodeJPEG(img, 85)
+	assert.NoError(t, err)
+	assert.False(t, bytes.Contains(out, []byte("Exif")))
+	assert.False(t, bytes.Contains(out, []byte("GPS-6.2088")))
+	assert.Equal(t, 1, exifOrientation(out))
+}
+
+func TestResize(t *testing.T) {
+	img := halfImage(400, 200)
+
+	resized := Resize(img, 100)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), resized.Bounds())
+	assert.True(t, isRed(resized.At(10, 25)))
+	assert.True(t, isBlue(resized.At(90, 25)))
+
+	// portrait keep its aspect ratio
+	resized = Resize(halfImage(200, 400), 100)
+	assert.Equal(t, image.Rect(0, 0, 50, 100), resized.Bounds())
+
+	// never upscaled
+	assert.Same(t, img, Resize(img, 1000))
+}
+
+func TestExifOrientationMalformed(t *testing.T) {
+	assert.Equal(t, 1, exifOrientation(nil))
+	assert.Equal(t, 1, exifOrientation([]byte("\xFF\xD8\xFF\xE1\xFF\xFFExif")))
+	assert.Equal(t, 1, exifOrientation(withEXIF([]byte("\xFF\xD8"), 9)))
+	assert.Equal(t, 6, exifOrientation(withEXIF([]byte("\xFF\xD8"), 6)))
+}
@@ -0,0 +1,28 @@
+package jwt
+
+import (
+	jwtauth "github.com/golang-jwt/jwt"
+)
+
+// Claims of access token, registered claims (iss, aud, exp, ...) are filled by
+// KeySet when token is created
+type Claims struct {
+	UserID    string   `json:"uid"`
+	ProfileID string   `json:"pid"`
+	Email     string   `json:"email,omitempty"`
+	Phone     string   `json:"phone,omitempty"`
+	SessionID string   `json:"sid"`
+	Scopes    []string `json:"scope"`
+	Premium   bool     `json:"premium"`
+	jwtauth.StandardClaims
+}
+
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,282 @@
+package jwt
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	jwtauth "github.com/golang-jwt/jwt"
+	configdata "github.com/ijlik/dating-user/pkg/config/data"
+)
+
+// DefaultKeyID used when signing key id is not configured
+const DefaultKeyID = "default"
+
+// KeyConfig raw (base64 PEM) keys, VerificationKeys is map of kid to public key
+// Issuer and Audience are checked on validation when not empty
+type KeyConfig struct {
+	SigningKeyID     string
+	SigningKey       string
+	VerificationKeys map[string]string
+	Issuer           string
+	Audience         string
+}
+
+// KeyLoader called on every sign and validate so rotated keys are picked up
+// without restarting the service
+type KeyLoader func() KeyConfig
+
+// ConfigKeyLoader read keys from config, config reloaded from vault will
+// rotate the signing key
+//   - TOKEN_SIGNING_KEY_ID kid of TOKEN_SECRET_KEY
+//   - TOKEN_SECRET_KEY private key used to sign new token
+//   - TOKEN_PUBLIC_KEYS json map of kid to public key, keep retired key
+//     here until every token signed by it is expired
+//   - TOKEN_PUBLIC_KEY single public key, registered with signing key id
+//   - TOKEN_ISSUER and TOKEN_AUDIENCE iss and aud claims
+func ConfigKeyLoader(config configdata.Config) KeyLoader {
+	return func() KeyConfig {
+		kid := config.GetString("TOKEN_SIGNING_KEY_ID")
+		if kid == "" {
+			kid = DefaultKeyID
+		}
+
+		keys := map[string]string{}
+		for id, key := range config.GetMap("TOKEN_PUBLIC_KEYS") {
+			keys[id] = key
+		}
+
+		if key := config.GetString("TOKEN_PUBLIC_KEY"); key != "" {
+			if _, ok := keys[kid]; !ok {
+				keys[kid] = key
+			}
+		}
+
+		return KeyConfig{
+			SigningKeyID:     kid,
+			SigningKey:       config.GetString("TOKEN_SECRET_KEY"),
+			VerificationKeys: keys,
+			Issuer:           config.GetString("TOKEN_ISSUER"),
+			Audience:         config.GetString("TOKEN_AUDIENCE"),
+		}
+	}
+}
+
+type KeySet interface {
+	CreateToken(ttl time.Duration, claims Claims) (string, error)
+	ValidateToken(token string) (*Claims, error)
+	JWKS() (*JWKS, error)
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type keySet struct {
+	loader KeyLoader
+
+	mu          sync.RWMutex
+	privateKeys map[string]*rsa.PrivateKey
+	publicKeys  map[string]*rsa.PublicKey
+}
+
+func NewKeySet(loader KeyLoader) KeySet {
+	return &keySet{
+		loader:      loader,
+		privateKeys: map[string]*rsa.PrivateKey{},
+		publicKeys:  map[string]*rsa.PublicKey{},
+	}
+}
+
+// parsed keys are cached by their raw value, rotated key is parsed once
+func (k *keySet) privateKey(raw string) (*rsa.PrivateKey, error) {
+	k.mu.RLock()
+	key, ok := k.privateKeys[raw]
+	k.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode key: %w", err)
+	}
+
+	key, err = jwtauth.ParseRSAPrivateKeyFromPEM(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+
+	k.mu.Lock()
+	k.privateKeys[raw] = key
+	k.mu.Unlock()
+
+	return key, nil
+}
+
+func (k *keySet) publicKey(raw string) (*rsa.PublicKey, error) {
+	k.mu.RLock()
+	key, ok := k.publicKeys[raw]
+	k.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode key: %w", err)
+	}
+
+	key, err = jwtauth.ParseRSAPublicKeyFromPEM(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+
+	k.mu.Lock()
+	k.publicKeys[raw] = key
+	k.mu.Unlock()
+
+	return key, nil
+}
+
+// verificationKeys return every active public key by kid, public part of
+// signing key is always included
+func (k *keySet) verificationKeys(config KeyConfig) (map[string]*rsa.PublicKey, error) {
+	keys := map[string]*rsa.PublicKey{}
+
+	for kid, raw := range config.VerificationKeys {
+		key, err := k.publicKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		keys[kid] = key
+	}
+
+	if _, ok := keys[config.SigningKeyID]; !ok && config.SigningKey != "" {
+		key, err := k.privateKey(config.SigningKey)
+		if err != nil {
+			return nil, err
+		}
+		keys[config.SigningKeyID] = &key.PublicKey
+	}
+
+	return keys, nil
+}
+
+func (k *keySet) CreateToken(ttl time.Duration, claims Claims) (string, error) {
+	config := k.loader()
+	if config.SigningKey == "" {
+		return "", errors.New("create: missing signing key")
+	}
+
+	key, err := k.privateKey(config.SigningKey)
+	if err != nil {
+		return "", fmt.Errorf("create: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	claims.Subject = claims.UserID
+	claims.Issuer = config.Issuer
+	claims.Audience = config.Audience
+	claims.ExpiresAt = now.Add(ttl).Unix()
+	claims.IssuedAt = now.Unix()
+	claims.NotBefore = now.Unix()
+
+	token := jwtauth.NewWithClaims(jwtauth.SigningMethodRS256, &claims)
+	token.Header["kid"] = config.SigningKeyID
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("create: sign token: %w", err)
+	}
+
+	return signed, nil
+}
+
+func (k *keySet) ValidateToken(token string) (*Claims, error) {
+	config := k.loader()
+	keys, err := k.verificationKeys(config)
+	if err != nil {
+		return nil, fmt.Errorf("validate: %w", err)
+	}
+
+	var claims Claims
+	parsedToken, err := jwtauth.ParseWithClaims(token, &claims, func(t *jwtauth.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwtauth.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected method: %s", t.Header["alg"])
+		}
+
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			// token issued before key rotation support
+			kid = config.SigningKeyID
+		}
+
+		key, ok := keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %s", kid)
+		}
+
+		return key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("validate: %w", err)
+	}
+
+	if !parsedToken.Valid {
+		return nil, fmt.Errorf("validate: invalid token")
+	}
+
+	if config.Issuer != "" && !claims.VerifyIssuer(config.Issuer, true) {
+		return nil, fmt.Errorf("validate: invalid issuer")
+	}
+
+	if config.Audience != "" && !claims.VerifyAudience(config.Audience, true) {
+		return nil, fmt.Errorf("validate: invalid audience")
+	}
+
+	return &claims, nil
+}
+
+func (k *keySet) JWKS() (*JWKS, error) {
+	keys, err := k.verificationKeys(k.loader())
+	if err != nil {
+		return nil, err
+	}
+
+	kids := make([]string, 0, len(keys))
+	for kid := range keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := &JWKS{Keys: []JWK{}}
+	for _, kid := range kids {
+		key := keys[kid]
+		jwks.Keys = append(jwks.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwtauth.SigningMethodRS256.Alg(),
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	return jwks, nil
+}
@@ -0,0 +1,189 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	jwtauth "github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateTestKey(t *testing.T) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+
+	private := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+
+	publicBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	public := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: publicBytes,
+	})
+
+	return base64.StdEncoding.EncodeToString(private), base64.StdEncoding.EncodeToString(public)
+}
+
+func mustDecode(t *testing.T, value string) []byte {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	assert.NoError(t, err)
+
+	return decoded
+}
+
+func TestKeySetCreateAndValidate(t *testing.T) {
+	private, public := generateTestKey(t)
+	keySet := NewKeySet(func() KeyConfig {
+		return KeyConfig{
+			SigningKeyID:     "k1",
+			SigningKey:       private,
+			VerificationKeys: map[string]string{"k1": public},
+		}
+	})
+
+	token, err := keySet.CreateToken(time.Minute, Claims{
+		UserID:    "user_id",
+		ProfileID: "profile_id",
+		SessionID: "session_id",
+		Scopes:    []string{"user", "premium"},
+		Premium:   true,
+	})
+	assert.NoError(t, err)
+
+	claims, err := keySet.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user_id", claims.UserID)
+	assert.Equal(t, "user_id", claims.Subject)
+	assert.Equal(t, "profile_id", claims.ProfileID)
+	assert.Equal(t, "session_id", claims.SessionID)
+	assert.True(t, claims.Premium)
+	assert.True(t, claims.HasScope("premium"))
+	assert.False(t, claims.HasScope("admin"))
+}
+
+func TestKeySetIssuerAndAudience(t *testing.T) {
+	private, _ := generateTestKey(t)
+	config := KeyConfig{
+		SigningKeyID: "k1",
+		SigningKey:   private,
+		Issuer:       "dating-user",
+		Audience:     "dating-apps",
+	}
+	keySet := NewKeySet(func() KeyConfig { return config })
+
+	token, err := keySet.CreateToken(time.Minute, Claims{UserID: "user_id"})
+	assert.NoError(t, err)
+
+	claims, err := keySet.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "dating-user", claims.Issuer)
+	assert.Equal(t, "dating-apps", claims.Audience)
+
+	config.Issuer = "other-service"
+	_, err = keySet.ValidateToken(token)
+	assert.Error(t, err)
+
+	config.Issuer = "dating-user"
+	config.Audience = "other-apps"
+	_, err = keySet.ValidateToken(token)
+	assert.Error(t, err)
+}
+
+func TestKeySetRotation(t *testing.T) {
+	oldPrivate, oldPublic := generateTestKey(t)
+	newPrivate, newPublic := generateTestKey(t)
+
+	config := KeyConfig{
+		SigningKeyID:     "k1",
+		SigningKey:       oldPrivate,
+		VerificationKeys: map[string]string{"k1": oldPublic},
+	}
+	keySet := NewKeySet(func() KeyConfig { return config })
+
+	oldToken, err := keySet.CreateToken(time.Minute, Claims{UserID: "old"})
+	assert.NoError(t, err)
+
+	// rotate signing key, keep the retired key for verification
+	config = KeyConfig{
+		SigningKeyID:     "k2",
+		SigningKey:       newPrivate,
+		VerificationKeys: map[string]string{"k1": oldPublic, "k2": newPublic},
+	}
+
+	newToken, err := keySet.CreateToken(time.Minute, Claims{UserID: "new"})
+	assert.NoError(t, err)
+
+	claims, err := keySet.ValidateToken(oldToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", claims.UserID)
+
+	claims, err = keySet.ValidateToken(newToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", claims.UserID)
+
+	// retired key removed, old token no longer valid
+	config.VerificationKeys = map[string]string{"k2": newPublic}
+	_, err = keySet.ValidateToken(oldToken)
+	assert.Error(t, err)
+}
+
+func TestKeySetLegacyTokenWithoutKid(t *testing.T) {
+	private, public := generateTestKey(t)
+	keySet := NewKeySet(func() KeyConfig {
+		return KeyConfig{
+			SigningKeyID:     DefaultKeyID,
+			SigningKey:       private,
+			VerificationKeys: map[string]string{DefaultKeyID: public},
+		}
+	})
+
+	key, err := jwtauth.ParseRSAPrivateKeyFromPEM(mustDecode(t, private))
+	assert.NoError(t, err)
+	token, err := jwtauth.NewWithClaims(jwtauth.SigningMethodRS256, &Claims{UserID: "user_id"}).SignedString(key)
+	assert.NoError(t, err)
+
+	claims, err := keySet.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user_id", claims.UserID)
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	private, _ := generateTestKey(t)
+	_, retiredPublic := generateTestKey(t)
+	keySet := NewKeySet(func() KeyConfig {
+		return KeyConfig{
+			SigningKeyID:     "k2",
+			SigningKey:       private,
+			VerificationKeys: map[string]string{"k1": retiredPublic},
+		}
+	})
+
+	jwks, err := keySet.JWKS()
+	assert.NoError(t, err)
+	assert.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "k1", jwks.Keys[0].Kid)
+	assert.Equal(t, "k2", jwks.Keys[1].Kid)
+	assert.Equal(t, "RS256", jwks.Keys[1].Alg)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+}
+
+func TestKeySetCacheParsedKey(t *testing.T) {
+	private, public := generateTestKey(t)
+	ks := NewKeySet(func() KeyConfig {
+		return KeyConfig{SigningKeyID: "k1", SigningKey: private}
+	}).(*keySet)
+
+	first, err := ks.publicKey(public)
+	assert.NoError(t, err)
+	second, err := ks.publicKey(public)
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+}
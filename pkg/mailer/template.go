@@ -4,14 +4,33 @@ type Mailer int
 
 const (
 	LOGIN Mailer = iota + 1
+	DEEPLINK
+	ACCOUNT_LOCKED
+	DATA_EXPORT
+	EMAIL_CHANGE_VERIFY
+	EMAIL_CHANGE_NOTICE
 )
 
 var mapTemplate = map[Mailer]string{
-	LOGIN: loginTemplate,
+	LOGIN:    loginTemplate,
+	DEEPLINK: deeplinkTemplate,
+
+	ACCOUNT_LOCKED: accountLockedTemplate,
+	DATA_EXPORT:    dataExportTemplate,
+
+	EMAIL_CHANGE_VERIFY: emailChangeVerifyTemplate,
+	EMAIL_CHANGE_NOTICE: emailChangeNoticeTemplate,
 }
 
 var mapSubject = map[Mailer]string{
-	LOGIN: "Login Verification",
+	LOGIN:    "Login Verification",
+	DEEPLINK: "Login Link",
+
+	ACCOUNT_LOCKED: "Account Temporarily Locked",
+	DATA_EXPORT:    "Your Data Export is Ready",
+
+	EMAIL_CHANGE_VERIFY: "Confirm Your New Email",
+	EMAIL_CHANGE_NOTICE: "Your Email is Being Changed",
 }
 
 var (
@@ -26,4 +45,63 @@ var (
     <hr style="border:none;border-top:1px solid #eee" />
   </div>
 </div>`
+
+	deeplinkTemplate = `<div style="font-family: Helvetica,Arial,sans-serif;min-width:1000px;overflow:auto;line-height:2">
+  <div style="margin:50px auto;width:70%;padding:20px 0">
+    <div style="border-bottom:1px solid #eee">
+      <p style="font-size:1.4em;color: #267adc;text-decoration:none;font-weight:600">Login Link</p>
+    </div>
+    <p style="font-size:1.1em">Hi there, thank you for choosing dating apps,<br /> Click the button below to login, this link can only be used once and is valid for {{ .ExpiryInMinute}} minutes</p>
+    <a href="{{ .Link}}" style="display:inline-block;background: #267adc;margin: 20px 10px 20px 0px;padding: 0 10px;color: #fff;border-radius: 4px;text-decoration:none;font-weight:600">Login to dating apps</a>
+    <p style="font-size:0.9em;">If you did not request this link, you can ignore this email.<br />Regards,<br />dating apps</p>
+    <hr style="border:none;border-top:1px solid #eee" />
+  </div>
+</div>`
+
+	accountLockedTemplate = `<div style="font-family: Helvetica,Arial,sans-serif;min-width:1000px;overflow:auto;line-height:2">
+  <div style="margin:50px auto;width:70%;padding:20px 0">
+    <div style="border-bottom:1px solid #eee">
+      <p style="font-size:1.4em;color: #267adc;text-decoration:none;font-weight:600">Account Temporarily Locked</p>
+    </div>
+    <p style="font-size:1.1em">Hi there,<br /> We noticed too many failed login attempts on your account, login is locked until {{ .LockedUntil}}.</p>
+    <p style="font-size:0.9em;">If this was not you, someone may be trying to access your account. Do not share your verification code with anyone.<br />Regards,<br />dating apps</p>
+    <hr style="border:none;border-top:1px solid #eee" />
+  </div>
+</div>`
+
+	dataExportTemplate = `<div style="font-family: Helvetica,Arial,sans-serif;min-width:1000px;overflow:auto;line-height:2">
+  <div style="margin:50px auto;width:70%;padding:20px 0">
+    <div style="border-bottom:1px solid #eee">
+      <p style="font-size:1.4em;color: #267adc;text-decoration:none;font-weight:600">Your Data Export is Ready</p>
+    </div>
+    <p style="font-size:1.1em">Hi there,<br /> A copy of the data we store about you is ready, click the button below to download it, this link is valid for {{ .ExpiryInHour}} hours</p>
+    <a href="{{ .Link}}" style="display:inline-block;background: #267adc;margin: 20px 10px 20px 0px;padding: 0 10px;color: #fff;border-radius: 4px;text-decoration:none;font-weight:600">Download my data</a>
+    <p style="font-size:0.9em;">If you did not request this export, please secure your account.<br />Regards,<br />dating apps</p>
+    <hr style="border:none;border-top:1px solid #eee" />
+  </div>
+</div>`
+
+	emailChangeVerifyTemplate = `<div style="font-family: Helvetica,Arial,sans-serif;min-width:1000px;overflow:auto;line-height:2">
+  <div style="margin:50px auto;width:70%;padding:20px 0">
+    <div style="border-bottom:1px solid #eee">
+      <p style="font-size:1.4em;color: #267adc;text-decoration:none;font-weight:600">Confirm Your New Email</p>
+    </div>
+    <p style="font-size:1.1em">Hi there,<br /> Below is the verification code to use this address for your dating apps account, this verification code is valid for {{ .ExpiryInMinute}} minutes</p>
+    <h2 style="background: #267adc;margin: 20px 10px 20px 0px;width: max-content;padding: 0 10px;color: #fff;border-radius: 4px;">{{ .Code}}</h2>
+    <p style="font-size:0.9em;">If you did not request this change, you can ignore this email.<br />Regards,<br />dating apps</p>
+    <hr style="border:none;border-top:1px solid #eee" />
+  </div>
+</div>`
+
+	emailChangeNoticeTemplate = `<div style="font-family: Helvetica,Arial,sans-serif;min-width:1000px;overflow:auto;line-height:2">
+  <div style="margin:50px auto;width:70%;padding:20px 0">
+    <div style="border-bottom:1px solid #eee">
+      <p style="font-size:1.4em;color: #267adc;text-decoration:none;font-weight:600">Your Email is Being Changed</p>
+    </div>
+    <p style="font-size:1.1em">Hi there,<br /> We received a request to change the email of your dating apps account to {{ .NewEmail}}.</p>
+    <a href="{{ .Link}}" style="display:inline-block;background: #267adc;margin: 20px 10px 20px 0px;padding: 0 10px;color: #fff;border-radius: 4px;text-decoration:none;font-weight:600">This was not me</a>
+    <p style="font-size:0.9em;">If this was not you, click the button above to cancel the change, it also restores this address within {{ .RevertInDay}} days after the change is confirmed.<br />Regards,<br />dating apps</p>
+    <hr style="border:none;border-top:1px solid #eee" />
+  </div>
+</div>`
 )
@@ -3,13 +3,22 @@ package math
 import (
 	"crypto/rand"
 	"fmt"
+	"math/big"
 	"strconv"
 )
 
+const (
+	numericCharset = "0123456789"
+	// without 0, O, 1 and I which are easy to mistype
+	alphaNumericCharset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
 type Math interface {
 	RandomNumber() *MathResponse
 	RandomNumberWithLen(len int) *MathResponse
 	AlphaNumericRandom(len int) *MathResponse
+	NumericCode(len int) *MathResponse
+	AlphaNumericCode(len int) *MathResponse
 }
 
 type MathResponse struct {
@@ -89,3 +98,37 @@ func (m *math) AlphaNumericRandom(len int) *MathResponse {
 		Value: fmt.Sprintf("%X", b),
 	}
 }
+
+// NumericCode fixed length code, leading zero is kept
+func (m *math) NumericCode(len int) *MathResponse {
+	return randomCode(len, numericCharset)
+}
+
+// AlphaNumericCode fixed length upper case code
+func (m *math) AlphaNumericCode(len int) *MathResponse {
+	return randomCode(len, alphaNumericCharset)
+}
+
+// randomCode every character is picked uniformly from charset
+func randomCode(length int, charset string) *MathResponse {
+	if length == 0 {
+		length = 6
+	}
+
+	max := big.NewInt(int64(len(charset)))
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return &MathResponse{
+				Valid: false,
+			}
+		}
+		code[i] = charset[n.Int64()]
+	}
+
+	return &MathResponse{
+		Valid: true,
+		Value: string(code),
+	}
+}
@@ -0,0 +1,52 @@
+package math
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumericCode(t *testing.T) {
+	m := NewMath()
+
+	for i := 0; i < 100; i++ {
+		code := m.NumericCode(6)
+		assert.True(t, code.Valid)
+		assert.Len(t, code.String(), 6)
+		assert.Regexp(t, "^[0-9]{6}$", code.String())
+	}
+
+	assert.Len(t, m.NumericCode(0).String(), 6)
+	assert.Len(t, m.NumericCode(8).String(), 8)
+}
+
+func TestAlphaNumericCode(t *testing.T) {
+	m := NewMath()
+
+	code := m.AlphaNumericCode(10)
+	assert.True(t, code.Valid)
+	assert.Len(t, code.String(), 10)
+	for _, c := range code.String() {
+		assert.True(t, strings.ContainsRune(alphaNumericCharset, c))
+	}
+}
+
+func TestRandomCodeDistribution(t *testing.T) {
+	// every digit should show up on each position
+	seen := make([]map[byte]bool, 4)
+	for i := range seen {
+		seen[i] = map[byte]bool{}
+	}
+
+	for i := 0; i < 2000; i++ {
+		code := randomCode(4, numericCharset).String()
+		for pos := 0; pos < 4; pos++ {
+			seen[pos][code[pos]] = true
+		}
+	}
+
+	for pos := range seen {
+		assert.Len(t, seen[pos], 10)
+	}
+}
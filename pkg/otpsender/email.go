@@ -0,0 +1,32 @@
+package otpsender
+
+import (
+	"context"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+	mailerpkg "github.com/ijlik/dating-user/pkg/mailer"
+)
+
+type emailSender struct {
+	mailer mailerpkg.Mail
+}
+
+func NewEmailSender(mailer mailerpkg.Mail) OtpSender {
+	return &emailSender{
+		mailer: mailer,
+	}
+}
+
+func (e *emailSender) Channel() constant.OneTimePasswordType {
+	return constant.OTP_TYPE_EMAIL
+}
+
+func (e *emailSender) Send(ctx context.Context, recipient, code string) error {
+	return e.mailer.Send(
+		mailerpkg.LOGIN,
+		recipient,
+		map[string]interface{}{
+			"Code": code,
+		},
+	)
+}
@@ -0,0 +1,99 @@
+package otpsender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
+
+// gatewaySender deliver otp code through http sms / whatsapp / voice provider
+type gatewaySender struct {
+	client  *http.Client
+	url     string
+	token   string
+	channel constant.OneTimePasswordType
+}
+
+type gatewayRequest struct {
+	To      string `json:"to"`
+	Channel string `json:"channel"`
+	Message string `json:"message"`
+}
+
+func NewSmsSender(url, token string) OtpSender {
+	return newGatewaySender(constant.OTP_TYPE_SMS, url, token)
+}
+
+func NewWhatsappSender(url, token string) OtpSender {
+	return newGatewaySender(constant.OTP_TYPE_WHATSAPP, url, token)
+}
+
+func NewPhoneCallSender(url, token string) OtpSender {
+	return newGatewaySender(constant.OTP_TYPE_PHONE_CALL, url, token)
+}
+
+func newGatewaySender(
+	channel constant.OneTimePasswordType,
+	url,
+	token string,
+) OtpSender {
+	return &gatewaySender{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		url:     url,
+		token:   token,
+		channel: channel,
+	}
+}
+
+func (g *gatewaySender) Channel() constant.OneTimePasswordType {
+	return g.channel
+}
+
+func (g *gatewaySender) Send(ctx context.Context, recipient, code string) error {
+	payload, err := json.Marshal(gatewayRequest{
+		To:      recipient,
+		Channel: strings.ToLower(g.channel.String()),
+		Message: buildMessage(g.channel, code),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("otp gateway responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func buildMessage(channel constant.OneTimePasswordType, code string) string {
+	if channel == constant.OTP_TYPE_PHONE_CALL {
+		// spell the code digit by digit for text to speech
+		return fmt.Sprintf("Your dating apps verification code is %s.", strings.Join(strings.Split(code, ""), ", "))
+	}
+
+	return fmt.Sprintf("Your dating apps verification code is %s. Do not share this code with anyone.", code)
+}
@@ -0,0 +1,29 @@
+package otpsender
+
+import (
+	"context"
+	"log"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
+
+// logSender print the code to the service log instead of delivering it, only
+// meant for local run without otp gateway
+type logSender struct {
+	channel constant.OneTimePasswordType
+}
+
+func NewLogSender(channel constant.OneTimePasswordType) OtpSender {
+	return &logSender{
+		channel: channel,
+	}
+}
+
+func (l *logSender) Channel() constant.OneTimePasswordType {
+	return l.channel
+}
+
+func (l *logSender) Send(ctx context.Context, recipient, code string) error {
+	log.Printf("otp %s to %s: %s\n", l.channel.String(), recipient, code)
+	return nil
+}
@@ -0,0 +1,68 @@
+package otpsender
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
+
+type Message struct {
+	Channel   constant.OneTimePasswordType
+	Recipient string
+	Code      string
+}
+
+// MemorySender keep every sent code in memory, used for testing and local run
+type MemorySender struct {
+	mu       sync.Mutex
+	channel  constant.OneTimePasswordType
+	messages []Message
+}
+
+func NewMemorySender(channel constant.OneTimePasswordType) *MemorySender {
+	return &MemorySender{
+		channel: channel,
+	}
+}
+
+func (m *MemorySender) Channel() constant.OneTimePasswordType {
+	return m.channel
+}
+
+func (m *MemorySender) Send(ctx context.Context, recipient, code string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, Message{
+		Channel:   m.channel,
+		Recipient: recipient,
+		Code:      code,
+	})
+
+	return nil
+}
+
+func (m *MemorySender) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make([]Message, len(m.messages))
+	copy(messages, m.messages)
+
+	return messages
+}
+
+// LastCode return latest code sent to recipient, empty if nothing sent
+func (m *MemorySender) LastCode(recipient string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Recipient == recipient {
+			return m.messages[i].Code
+		}
+	}
+
+	return ""
+}
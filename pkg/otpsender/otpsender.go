@@ -0,0 +1,29 @@
+package otpsender
+
+import (
+	"context"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+)
+
+type OtpSender interface {
+	Channel() constant.OneTimePasswordType
+	Send(ctx context.Context, recipient, code string) error
+}
+
+// Registry hold one sender for every channel supported by the service
+type Registry map[constant.OneTimePasswordType]OtpSender
+
+func NewRegistry(senders ...OtpSender) Registry {
+	registry := make(Registry)
+	for _, sender := range senders {
+		registry[sender.Channel()] = sender
+	}
+
+	return registry
+}
+
+func (r Registry) Get(channel constant.OneTimePasswordType) (OtpSender, bool) {
+	sender, ok := r[channel]
+	return sender, ok
+}
@@ -0,0 +1,73 @@
+package otpsender
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ijlik/dating-user/pkg/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	sms := NewMemorySender(constant.OTP_TYPE_SMS)
+	whatsapp := NewMemorySender(constant.OTP_TYPE_WHATSAPP)
+	registry := NewRegistry(sms, whatsapp)
+
+	sender, ok := registry.Get(constant.OTP_TYPE_SMS)
+	assert.True(t, ok)
+	assert.Equal(t, constant.OTP_TYPE_SMS, sender.Channel())
+
+	_, ok = registry.Get(constant.OTP_TYPE_PHONE_CALL)
+	assert.False(t, ok)
+}
+
+func TestMemorySender(t *testing.T) {
+	sender := NewMemorySender(constant.OTP_TYPE_SMS)
+	ctx := context.Background()
+
+	assert.NoError(t, sender.Send(ctx, "+6281234567890", "111111"))
+	assert.NoError(t, sender.Send(ctx, "+6281234567891", "222222"))
+	assert.NoError(t, sender.Send(ctx, "+6281234567890", "333333"))
+
+	assert.Len(t, sender.Messages(), 3)
+	assert.Equal(t, "333333", sender.LastCode("+6281234567890"))
+	assert.Equal(t, "222222", sender.LastCode("+6281234567891"))
+	assert.Equal(t, "", sender.LastCode("+6281234567899"))
+}
+
+func TestGatewaySender(t *testing.T) {
+	var received gatewayRequest
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := NewWhatsappSender(server.URL, "secret")
+	err := sender.Send(context.Background(), "+6281234567890", "123456")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer secret", authorization)
+	assert.Equal(t, "+6281234567890", received.To)
+	assert.Equal(t, "whatsapp", received.Channel)
+	assert.Contains(t, received.Message, "123456")
+}
+
+func TestGatewaySenderFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	sender := NewSmsSender(server.URL, "")
+	err := sender.Send(context.Background(), "+6281234567890", "123456")
+	assert.Error(t, err)
+}
+
+func TestPhoneCallMessage(t *testing.T) {
+	assert.Equal(t, "Your dating apps verification code is 1, 2, 3.", buildMessage(constant.OTP_TYPE_PHONE_CALL, "123"))
+}
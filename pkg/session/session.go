@@ -0,0 +1,58 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// session:<session id> hold the session data of one device
+	sessionKey = "session:%s"
+	// sessions:<user id> set of active session id owned by user
+	userSessionsKey = "sessions:%s"
+)
+
+// LastSeenInterval minimum interval before last seen time is updated again
+const LastSeenInterval = time.Minute
+
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func Key(sessionID string) string {
+	return fmt.Sprintf(sessionKey, sessionID)
+}
+
+func UserKey(userID string) string {
+	return fmt.Sprintf(userSessionsKey, userID)
+}
+
+func (s *Session) Encode() (string, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func Decode(value string) (*Session, error) {
+	var s Session
+	if err := json.Unmarshal([]byte(value), &s); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// NeedTouch report whether last seen time is old enough to be updated
+func (s *Session) NeedTouch(now time.Time) bool {
+	return now.Sub(s.LastSeenAt) >= LastSeenInterval
+}
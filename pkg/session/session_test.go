@@ -0,0 +1,42 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	s := &Session{
+		ID:         "session_id",
+		UserID:     "user_id",
+		DeviceName: "Pixel 7",
+		UserAgent:  "okhttp/4.9",
+		IP:         "10.0.0.1",
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	value, err := s.Encode()
+	assert.NoError(t, err)
+
+	decoded, err := Decode(value)
+	assert.NoError(t, err)
+	assert.Equal(t, s, decoded)
+}
+
+func TestNeedTouch(t *testing.T) {
+	now := time.Now()
+	s := &Session{LastSeenAt: now.Add(-30 * time.Second)}
+	assert.False(t, s.NeedTouch(now))
+
+	s.LastSeenAt = now.Add(-2 * time.Minute)
+	assert.True(t, s.NeedTouch(now))
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "session:abc", Key("abc"))
+	assert.Equal(t, "sessions:user", UserKey("user"))
+}